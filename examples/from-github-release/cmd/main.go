@@ -15,6 +15,7 @@ import (
 	"github.com/joy-dx/gophorth/pkg/config/options"
 	"github.com/joy-dx/gophorth/pkg/releaser/releaserconfig"
 	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterclients"
 	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
 	"github.com/joy-dx/relay"
 	relayCfg "github.com/joy-dx/relay/config"
@@ -38,6 +39,7 @@ var (
 			cfg.Net = netCfg.DefaultNetSvcConfig()
 			cfg.Relay = relayCfg.DefaultRelaySvcConfig()
 			cfg.Releaser = releaserdto.DefaultReleaserConfig()
+			cfg.Github = updaterclients.DefaultFromGithubConfig()
 			if stateErr := cfg.Process(); stateErr != nil {
 				log.Fatal(stateErr)
 			}
@@ -84,5 +86,6 @@ func init() {
 	cliflags.NetCobraAndViper(rootCmd)
 	releaserconfig.CobraAndViper(rootCmd)
 	updaterdto.CobraAndViper(rootCmd)
+	updaterclients.GithubCobraAndViper(rootCmd)
 
 }
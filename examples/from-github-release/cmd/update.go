@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -14,7 +13,6 @@ import (
 	"github.com/joy-dx/gophorth/examples/from-github-release/config"
 	"github.com/joy-dx/relay"
 
-	"github.com/google/go-github/v81/github"
 	"github.com/joy-dx/gonetic"
 	"github.com/joy-dx/gophorth/pkg/updater"
 	"github.com/joy-dx/gophorth/pkg/updater/updaterclients"
@@ -36,28 +34,13 @@ var (
 			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 			defer stop()
 
-			// Create an HTTP client with the custom timeout
-			httpClient := &http.Client{
-				Timeout: 20 * time.Second,
+			// Token, enterprise URL and owner may come from flags, config or GITHUB_TOKEN.
+			// The "<asset>.asc" signature published alongside each artefact is discovered automatically
+			githubClientCfg := cfgSvc.Github
+			if githubClientCfg.Owner == "" {
+				githubClientCfg.WithOwner("joy-dx").
+					WithRepo("app-update-example")
 			}
-			githubAgent := github.NewClient(httpClient)
-
-			// Getting the signature is simply a matter of suffixing .asc to the URL
-			getSignatureFunction := func(ctx context.Context, cfg *updaterclients.GithubAgentCfg) (string, error) {
-				signatureURL := cfg.VersionLink.DownloadURL + ".asc"
-
-				response, err := cfg.NetSvc.Get(ctx, signatureURL, true)
-				if err != nil {
-					return "", err
-				}
-				return string(response.Body), nil
-			}
-
-			githubClientCfg := updaterclients.DefaultFromGithubConfig()
-			githubClientCfg.WithOwner("joy-dx").
-				WithRepo("app-update-example").
-				WithClient(githubAgent).
-				WithGetSignatureFunc(getSignatureFunction)
 			githubClient := updaterclients.NewFromGithub(&githubClientCfg)
 
 			// Update Client
@@ -9,6 +9,7 @@ import (
 	netCfg "github.com/joy-dx/gonetic/config"
 	"github.com/joy-dx/gophorth/pkg/file"
 	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterclients"
 	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
 	"github.com/joy-dx/relay/config"
 	"github.com/spf13/viper"
@@ -18,10 +19,11 @@ import (
 type ConfigSvc struct {
 	cfgFilePath          string
 	cfgEnvironmentPrefix string
-	Net                  netCfg.NetSvcConfig        `json:"net" yaml:"net" mapstructure:"net"`
-	Relay                config.RelaySvcConfig      `json:"relay" yaml:"relay" mapstructure:"relay"`
-	Releaser             releaserdto.ReleaserConfig `json:"releaser" yaml:"releaser" mapstructure:"releaser"`
-	Updater              updaterdto.UpdaterConfig   `json:"updater" yaml:"updater" mapstructure:"updater"`
+	Net                  netCfg.NetSvcConfig             `json:"net" yaml:"net" mapstructure:"net"`
+	Relay                config.RelaySvcConfig           `json:"relay" yaml:"relay" mapstructure:"relay"`
+	Releaser             releaserdto.ReleaserConfig      `json:"releaser" yaml:"releaser" mapstructure:"releaser"`
+	Updater              updaterdto.UpdaterConfig        `json:"updater" yaml:"updater" mapstructure:"updater"`
+	Github               updaterclients.FromGithubConfig `json:"github" yaml:"github" mapstructure:"github"`
 }
 
 func (a *ConfigSvc) SaveState() error {
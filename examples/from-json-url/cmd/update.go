@@ -71,7 +71,7 @@ var (
 						cfg.Relay.Debug(updater.RlyUpdaterLog{Msg: fmt.Sprintf("found asset with matching platform: %s %s", asset.Platform, asset.Arch)})
 						if cfg.UpdaterCfg.Variant == asset.Variant {
 							cfg.Relay.Debug(updater.RlyUpdaterLog{Msg: fmt.Sprintf("found wanted variant %s", asset.Variant)})
-							return asset, nil
+							return *asset.WithReleaseSummary(releaseSummary), nil
 						}
 					}
 
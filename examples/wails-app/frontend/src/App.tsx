@@ -1,7 +1,7 @@
 import {useEffect, useMemo, useState} from 'react';
 import './App.css';
 import {releaserdto, updaterdto} from "@wailsmodels";
-import {CheckForUpdate, StartUpdate, UpdaterStateGet} from "@wailsapi/UpdaterInterface";
+import {CancelUpdate, CheckForUpdate, StartUpdate, UpdateProgress, UpdaterStateGet} from "@wailsapi/UpdaterInterface";
 import {UseLogStore} from "@lib";
 
 const LogEntries = () => {
@@ -25,11 +25,32 @@ function App() {
     const [isUpdateAvailable, setIsUpdateAvailable] = useState<boolean>(false);
     const [releaseAsset, setReleaseAsset] = useState<releaserdto.ReleaseAsset>();
     const [isBusy, setIsBusy] = useState<boolean>(false);
+    const [isUpdating, setIsUpdating] = useState<boolean>(false);
+    const [progress, setProgress] = useState<updaterdto.JobProgress>();
 
     useEffect(() => {
         UpdaterStateGet().then(setUpdaterState, setResponseText)
     }, []);
 
+    useEffect(() => {
+        if (!isUpdating) {
+            return
+        }
+        const timer = setInterval(() => {
+            UpdateProgress().then((jobProgress: updaterdto.JobProgress) => {
+                setProgress(jobProgress)
+                if (jobProgress.stage === "complete") {
+                    setResponseText("update ready, the app will now shutdown and update")
+                }
+                if (jobProgress.stage === "failed" || jobProgress.stage === "cancelled") {
+                    setResponseText(jobProgress.error ?? jobProgress.stage)
+                    setIsUpdating(false)
+                }
+            }, setResponseText)
+        }, 500)
+        return () => clearInterval(timer)
+    }, [isUpdating]);
+
     function onUpdateCheckClick() {
         setIsBusy(true)
         CheckForUpdate().then((releaseAsset: releaserdto.ReleaseAsset) => {
@@ -45,12 +66,17 @@ function App() {
     function onUpdateStartClick() {
         setIsBusy(true)
         StartUpdate().then(() => {
-           setResponseText("app will automatically shutdown and update in 3 second")
+            setResponseText("")
+            setIsUpdating(true)
         }, setResponseText).finally(() => {
             setIsBusy(false)
         });
     }
 
+    function onUpdateCancelClick() {
+        CancelUpdate().then(() => setIsUpdating(false), setResponseText)
+    }
+
     const updateLog = updaterState?.updater_log
 
     return (
@@ -80,11 +106,21 @@ function App() {
                                 Check for updates
                             </button>
                             {isUpdateAvailable && <button
-                                disabled={isBusy}
+                                disabled={isBusy || isUpdating}
                                 className="inline-flex items-center rounded-md bg-blue-600 px-4 py-2 text-sm font-medium text-white transition hover:bg-blue-700 focus:outline-none focus:ring-2 focus:ring-blue-500 disabled:cursor-not-allowed disabled:opacity-60"
                                 onClick={onUpdateStartClick}>Start update</button>}
+                            {isUpdating && <button
+                                className="inline-flex items-center rounded-md bg-gray-600 px-4 py-2 text-sm font-medium text-white transition hover:bg-gray-700 focus:outline-none focus:ring-2 focus:ring-gray-500"
+                                onClick={onUpdateCancelClick}>Cancel update</button>}
                         </div>
 
+                        {isUpdating && progress && (
+                            <p className="mt-3 text-sm text-gray-700">
+                                {progress.stage}
+                                {progress.total > 0 && ` ${Math.floor(progress.downloaded * 100 / progress.total)}%`}
+                            </p>
+                        )}
+
                         {isUpdateAvailable && releaseAsset && (<>
                         <p
                             id="updateStatus"
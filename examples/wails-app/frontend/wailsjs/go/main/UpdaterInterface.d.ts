@@ -4,10 +4,14 @@ import {releaserdto} from '../models';
 import {context} from '../models';
 import {updaterdto} from '../models';
 
+export function CancelUpdate():Promise<void>;
+
 export function CheckForUpdate():Promise<releaserdto.ReleaseAsset>;
 
 export function SetContext(arg1:context.Context):Promise<void>;
 
 export function StartUpdate():Promise<void>;
 
+export function UpdateProgress():Promise<updaterdto.JobProgress>;
+
 export function UpdaterStateGet():Promise<updaterdto.UpdaterState>;
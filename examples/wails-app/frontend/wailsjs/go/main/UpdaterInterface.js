@@ -2,6 +2,10 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function CancelUpdate() {
+  return window['go']['main']['UpdaterInterface']['CancelUpdate']();
+}
+
 export function CheckForUpdate() {
   return window['go']['main']['UpdaterInterface']['CheckForUpdate']();
 }
@@ -14,6 +18,10 @@ export function StartUpdate() {
   return window['go']['main']['UpdaterInterface']['StartUpdate']();
 }
 
+export function UpdateProgress() {
+  return window['go']['main']['UpdaterInterface']['UpdateProgress']();
+}
+
 export function UpdaterStateGet() {
   return window['go']['main']['UpdaterInterface']['UpdaterStateGet']();
 }
@@ -30,17 +30,38 @@ export namespace releaserdto {
 	    size_bytes: number;
 	    signature?: string;
 	    signature_type?: string;
+	    source?: string;
+	    changelog?: string;
+	    published_at?: string;
+	    release_url?: string;
 	}
 
 }
 
 export namespace updaterdto {
 	
+	export interface JobProgress {
+	    stage: string;
+	    downloaded: number;
+	    total: number;
+	    bytes_per_second: number;
+	    error?: string;
+	}
+	export interface ReleaseNote {
+	    version: string;
+	    published_at?: string;
+	    changelog: string;
+	    release_url?: string;
+	}
 	export interface UpdaterState {
 	    updater_last_time_checked_update?: string;
 	    updater_update_link?: releaserdto.ReleaseAsset;
 	    updater_changelog: string;
+	    updater_combined_changelog: string;
+	    updater_release_notes: ReleaseNote[];
 	    updater_released_at?: string;
+	    updater_release_url: string;
+	    updater_skipped_versions: string[];
 	    updater_check_interval: number;
 	    updater_log: string;
 	    updater_log_path: string;
@@ -3,12 +3,12 @@ package main
 import (
 	"context"
 	"errors"
-	"fmt"
-	"os"
+	"sync"
 
 	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
 	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
 	"github.com/joy-dx/relay/dto"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // UpdaterInterface struct
@@ -16,6 +16,8 @@ type UpdaterInterface struct {
 	ctx        context.Context
 	relay      dto.RelayInterface
 	updaterSvc updaterdto.UpdaterInterface
+	mu         sync.Mutex
+	job        *updaterdto.Job
 }
 
 // SetContext Allows for attaching Wails context for proper shutdown
@@ -31,19 +33,50 @@ func (a *UpdaterInterface) CheckForUpdate() (releaserdto.ReleaseAsset, error) {
 	return a.updaterSvc.CheckLatest(a.ctx)
 }
 
+// StartUpdate Starts downloading and performing the update in the background,
+// poll UpdateProgress to follow it. The app quits once the update is in place.
 func (a *UpdaterInterface) StartUpdate() error {
-	if a.updaterSvc.Status() != updaterdto.UPDATE_AVAILABLE {
-		return errors.New("no update available")
-	}
-	if downloadErr := a.updaterSvc.DownloadUpdate(a.ctx, nil); downloadErr != nil {
-		return fmt.Errorf("problem downloading latest version: %w", downloadErr)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.job != nil {
+		select {
+		case <-a.job.Done():
+		default:
+			return errors.New("update already in progress")
+		}
 	}
-	if updateErr := a.updaterSvc.PerformUpdate(a.ctx); updateErr != nil {
-		return fmt.Errorf("problem performing update: %w", updateErr)
+	if status := a.updaterSvc.Status(); status != updaterdto.UPDATE_AVAILABLE && status != updaterdto.DOWNLOADED {
+		return errors.New("no update available")
 	}
 
-	// Exit the app for update
-	os.Exit(0)
-
+	job := a.updaterSvc.StartUpdate(a.ctx)
+	a.job = job
+	go func() {
+		// Quit through Wails so the app shuts down cleanly for the helper
+		if job.Wait() == nil {
+			runtime.Quit(a.ctx)
+		}
+	}()
 	return nil
 }
+
+// CancelUpdate Stops a running update, removing anything partially downloaded
+func (a *UpdaterInterface) CancelUpdate() {
+	a.mu.Lock()
+	job := a.job
+	a.mu.Unlock()
+	if job != nil {
+		job.Cancel()
+		<-job.Done()
+	}
+}
+
+// UpdateProgress Progress of the last update started
+func (a *UpdaterInterface) UpdateProgress() updaterdto.JobProgress {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.job == nil {
+		return updaterdto.JobProgress{Stage: updaterdto.JOB_PENDING}
+	}
+	return a.job.Progress()
+}
@@ -15,6 +15,13 @@ const (
 	LoggerLevel      ConfigOption = "level"
 	LoggerType       ConfigOption = "type"
 
+	GithubBaseURL   ConfigOption = "base_url"
+	GithubCacheDir  ConfigOption = "cache_dir"
+	GithubOwner     ConfigOption = "owner"
+	GithubRepo      ConfigOption = "repo"
+	GithubTagPrefix ConfigOption = "tag_prefix"
+	GithubToken     ConfigOption = "token"
+
 	NetDomainBlacklist          ConfigOption = "domain_blacklist"
 	NetDomainWhitelist          ConfigOption = "domain_whitelist"
 	NetDownloadCallbackInterval ConfigOption = "download_callback_interval"
@@ -24,28 +31,49 @@ const (
 
 	RelaySinks ConfigOption = "relay_sinks"
 
-	ReleaserAllowAnyExtension  ConfigOption = "allow_any_extension"
-	ReleaserFilePattern        ConfigOption = "file_pattern"
-	ReleaserGenerateChecksums  ConfigOption = "generate_checksums"
-	ReleaserGenerateSignatures ConfigOption = "generate_signatures"
-	ReleaserOutputPath         ConfigOption = "output_path"
-	ReleaserTargetPath         ConfigOption = "target_path"
-	ReleaserPrivateKey         ConfigOption = "private_key"
-	ReleaserPrivateKeyPath     ConfigOption = "private_key_path"
-	ReleaserStrict             ConfigOption = "strict"
-	ReleaserRequireVersion     ConfigOption = "require_version"
-	ReleaserSummaryOutputType  ConfigOption = "summary_output_type"
-	ReleaserVersion            ConfigOption = "version"
-
-	UpdaterAllowDowngrade  ConfigOption = "allow_downgrade"
-	UpdaterAllowPrerelease ConfigOption = "allow_prerelease"
-	UpdaterArchitecture    ConfigOption = "architecture"
-	UpdaterCheckInterval   ConfigOption = "check_interval"
-	UpdaterCurrentVersion  ConfigOption = "current_version"
-	UpdaterLogPath         ConfigOption = "log_path"
-	UpdaterPlatform        ConfigOption = "platform"
-	UpdaterPublicKey       ConfigOption = "public_key"
-	UpdaterPublicKeyPath   ConfigOption = "public_key_path"
-	UpdaterTemporaryPath   ConfigOption = "temporary_path"
-	UpdaterVariant         ConfigOption = "variant"
+	ReleaserAllowAnyExtension   ConfigOption = "allow_any_extension"
+	ReleaserBundlePath          ConfigOption = "bundle_path"
+	ReleaserChangelogNotesPath  ConfigOption = "changelog_notes_path"
+	ReleaserChangelogOutputPath ConfigOption = "changelog_output_path"
+	ReleaserChangelogRepoPath   ConfigOption = "changelog_repo_path"
+	ReleaserDownloadPrefixes    ConfigOption = "download_prefixes"
+	ReleaserFilePattern         ConfigOption = "file_pattern"
+	ReleaserGenerateChecksums   ConfigOption = "generate_checksums"
+	ReleaserGenerateSignatures  ConfigOption = "generate_signatures"
+	ReleaserHistoryMaxAge       ConfigOption = "history_max_age"
+	ReleaserHistoryMaxReleases  ConfigOption = "history_max_releases"
+	ReleaserHistoryReplace      ConfigOption = "history_replace"
+	ReleaserOCIPassword         ConfigOption = "oci_password"
+	ReleaserOCIRepository       ConfigOption = "oci_repository"
+	ReleaserOCIUsername         ConfigOption = "oci_username"
+	ReleaserOutputPath          ConfigOption = "output_path"
+	ReleaserTargetPath          ConfigOption = "target_path"
+	ReleaserPrivateKey          ConfigOption = "private_key"
+	ReleaserPrivateKeyPath      ConfigOption = "private_key_path"
+	ReleaserStrict              ConfigOption = "strict"
+	ReleaserRequireVersion      ConfigOption = "require_version"
+	ReleaserSummaryOutputType   ConfigOption = "summary_output_type"
+	ReleaserVersion             ConfigOption = "version"
+
+	UpdaterAllowDowngrade    ConfigOption = "allow_downgrade"
+	UpdaterAllowPrerelease   ConfigOption = "allow_prerelease"
+	UpdaterArchitecture      ConfigOption = "architecture"
+	UpdaterCacheDir          ConfigOption = "cache_dir"
+	UpdaterCacheMaxBytes     ConfigOption = "cache_max_bytes"
+	UpdaterCheckInterval     ConfigOption = "check_interval"
+	UpdaterCurrentVersion    ConfigOption = "current_version"
+	UpdaterDownloadEngine    ConfigOption = "download_engine"
+	UpdaterDownloadRateLimit ConfigOption = "download_rate_limit"
+	UpdaterDownloadSegments  ConfigOption = "download_segments"
+	UpdaterHelperPath        ConfigOption = "helper_path"
+	UpdaterHelperChecksum    ConfigOption = "helper_checksum"
+	UpdaterInProcessFallback ConfigOption = "in_process_fallback"
+	UpdaterKeepPrevious      ConfigOption = "keep_previous_version"
+	UpdaterLogPath           ConfigOption = "log_path"
+	UpdaterPlatform          ConfigOption = "platform"
+	UpdaterPublicKey         ConfigOption = "public_key"
+	UpdaterPublicKeyPath     ConfigOption = "public_key_path"
+	UpdaterStatePath         ConfigOption = "state_path"
+	UpdaterTemporaryPath     ConfigOption = "temporary_path"
+	UpdaterVariant           ConfigOption = "variant"
 )
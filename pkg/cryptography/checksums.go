@@ -9,6 +9,7 @@ import (
 	"hash/crc32"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -69,3 +70,23 @@ func Sha256SumVerify(path string, checksum string) error {
 	}
 	return nil
 }
+
+// ParseChecksums reads a "checksums.txt" style listing, as written by
+// Sha256SumBatch, in to a map of base file name to lower case checksum.
+// Binary mode markers ("*name") and blank or comment lines are tolerated.
+func ParseChecksums(contents []byte) (map[string]string, error) {
+	checksums := make(map[string]string)
+	for idx, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("checksums line %d: expected \"<checksum>  <filename>\"", idx+1)
+		}
+		name := strings.TrimPrefix(strings.Join(fields[1:], " "), "*")
+		checksums[filepath.Base(name)] = strings.ToLower(fields[0])
+	}
+	return checksums, nil
+}
@@ -224,6 +224,14 @@ func classifyPEMBlock(block *pem.Block) *KeyInfo {
 		if info, err := detectX509PrivateEC(block.Bytes); err == nil {
 			return info
 		}
+	case "ECDSA DETACHED SIGNATURE":
+		// As written by ECDSASignFile
+		return &KeyInfo{
+			Format:    "X509",
+			Kind:      "Signature",
+			Algorithm: "ECDSA",
+			Detail:    block.Headers["Hash"],
+		}
 	case "ENCRYPTED PRIVATE KEY":
 		return &KeyInfo{
 			Format:    "X509",
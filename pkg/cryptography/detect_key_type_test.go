@@ -172,6 +172,19 @@ func TestDetectSignatureInformation_Golden(t *testing.T) {
 			}(),
 			want: wantKey{"X509", "Private", "Unknown", "encrypted"},
 		},
+		{
+			name: "x509_ecdsa_detached_signature",
+			data: func() []byte {
+				var buf bytes.Buffer
+				_ = pem.Encode(&buf, &pem.Block{
+					Type:    "ECDSA DETACHED SIGNATURE",
+					Headers: map[string]string{"Hash": "SHA-256"},
+					Bytes:   []byte{0x30, 0x06, 0x02, 0x01, 0x01, 0x02, 0x01, 0x01}, // dummy
+				})
+				return buf.Bytes()
+			}(),
+			want: wantKey{"X509", "Signature", "ECDSA", "SHA-256"},
+		},
 		// X.509 Public
 		{
 			name: "x509_public_rsa",
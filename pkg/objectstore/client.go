@@ -0,0 +1,185 @@
+package objectstore
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNotFound The object or bucket does not exist
+var ErrNotFound = errors.New("object not found")
+
+// maxObjectBytes Cap on listings and objects read in to memory
+const maxObjectBytes = 8 << 20
+
+// Object An entry of a bucket listing
+type Object struct {
+	Key  string
+	Size int64
+	ETag string
+}
+
+// Client Minimal S3 compatible client. Every call is made through a presigned
+// URL, so the same URLs can be handed to other downloaders as is.
+type Client struct {
+	cfg Config
+}
+
+func New(cfg Config) *Client {
+	return &Client{cfg: cfg}
+}
+
+func (c *Client) Config() Config {
+	return c.cfg
+}
+
+// ObjectURL returns the unsigned URL of key
+func (c *Client) ObjectURL(key string) (string, error) {
+	endpoint, err := url.Parse(strings.TrimRight(c.cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return "", fmt.Errorf("invalid endpoint %q", c.cfg.Endpoint)
+	}
+	if c.cfg.Bucket == "" {
+		return "", errors.New("no bucket configured")
+	}
+	key = strings.TrimLeft(key, "/")
+	if c.cfg.UsePathStyle {
+		endpoint.Path = endpoint.Path + "/" + c.cfg.Bucket
+		if key != "" {
+			endpoint.Path += "/" + key
+		}
+	} else {
+		endpoint.Host = c.cfg.Bucket + "." + endpoint.Host
+		endpoint.Path = endpoint.Path + "/" + key
+	}
+	return endpoint.String(), nil
+}
+
+// PresignGet returns a URL anyone can use to download key until PresignExpiry passes
+func (c *Client) PresignGet(key string) (string, error) {
+	objectURL, err := c.ObjectURL(key)
+	if err != nil {
+		return "", err
+	}
+	return c.presign(objectURL)
+}
+
+// Resign replaces the signature of a previously presigned URL, e.g. once it has expired
+func (c *Client) Resign(presignedURL string) (string, error) {
+	target, err := url.Parse(presignedURL)
+	if err != nil {
+		return "", err
+	}
+	query := target.Query()
+	for key := range query {
+		if strings.HasPrefix(key, "X-Amz-") {
+			query.Del(key)
+		}
+	}
+	target.RawQuery = query.Encode()
+	return c.presign(target.String())
+}
+
+// Get reads a small object, returning ErrNotFound when missing
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	presigned, err := c.PresignGet(key)
+	if err != nil {
+		return nil, err
+	}
+	return c.get(ctx, presigned)
+}
+
+// List returns every object under prefix, following continuation tokens
+func (c *Client) List(ctx context.Context, prefix string) ([]Object, error) {
+	bucketURL, err := c.ObjectURL("")
+	if err != nil {
+		return nil, err
+	}
+	var (
+		objects []Object
+		token   string
+	)
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		presigned, err := c.presign(bucketURL + "?" + query.Encode())
+		if err != nil {
+			return nil, err
+		}
+		body, err := c.get(ctx, presigned)
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", prefix, err)
+		}
+
+		var result struct {
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+			Contents              []struct {
+				Key  string `xml:"Key"`
+				Size int64  `xml:"Size"`
+				ETag string `xml:"ETag"`
+			} `xml:"Contents"`
+		}
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("list %s: %w", prefix, err)
+		}
+		for _, content := range result.Contents {
+			objects = append(objects, Object{Key: content.Key, Size: content.Size, ETag: strings.Trim(content.ETag, `"`)})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (c *Client) presign(rawURL string) (string, error) {
+	creds := Credentials{
+		AccessKeyID:     c.cfg.AccessKeyID,
+		SecretAccessKey: c.cfg.SecretAccessKey,
+		SessionToken:    c.cfg.SessionToken,
+	}
+	return Presign(http.MethodGet, rawURL, c.cfg.Region, creds, c.cfg.PresignExpiry, time.Now())
+}
+
+func (c *Client) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := c.cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(io.LimitReader(resp.Body, maxObjectBytes))
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	}
+	// S3 error bodies carry a code and message worth surfacing, e.g. SignatureDoesNotMatch
+	var apiErr struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if xml.Unmarshal(body, &apiErr) == nil && apiErr.Code != "" {
+		return nil, fmt.Errorf("%s: %s: %s", resp.Status, apiErr.Code, apiErr.Message)
+	}
+	return nil, errors.New(resp.Status)
+}
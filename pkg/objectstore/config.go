@@ -0,0 +1,69 @@
+package objectstore
+
+import (
+	"net/http"
+	"time"
+)
+
+// Config Connection settings for an S3 compatible store, e.g. AWS S3 or MinIO
+type Config struct {
+	// Endpoint Service URL, e.g. "https://s3.eu-west-1.amazonaws.com" or "http://localhost:9000"
+	Endpoint string `json:"endpoint" yaml:"endpoint" mapstructure:"endpoint"`
+	Region   string `json:"region" yaml:"region" mapstructure:"region"`
+	Bucket   string `json:"bucket" yaml:"bucket" mapstructure:"bucket"`
+	// UsePathStyle Address objects as endpoint/bucket/key rather than bucket.endpoint/key, as MinIO expects
+	UsePathStyle    bool   `json:"use_path_style" yaml:"use_path_style" mapstructure:"use_path_style"`
+	AccessKeyID     string `json:"-" yaml:"-" mapstructure:"access_key_id"`
+	SecretAccessKey string `json:"-" yaml:"-" mapstructure:"secret_access_key"`
+	// SessionToken Optional, for temporary credentials
+	SessionToken string `json:"-" yaml:"-" mapstructure:"session_token"`
+	// PresignExpiry How long presigned URLs stay valid
+	PresignExpiry time.Duration `json:"presign_expiry" yaml:"presign_expiry" mapstructure:"presign_expiry"`
+	// HTTPClient Used for listing and reading small objects, defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Region:        "us-east-1",
+		UsePathStyle:  true,
+		PresignExpiry: time.Hour,
+	}
+}
+
+func (c *Config) WithEndpoint(endpoint string) *Config {
+	c.Endpoint = endpoint
+	return c
+}
+
+func (c *Config) WithRegion(region string) *Config {
+	c.Region = region
+	return c
+}
+
+func (c *Config) WithBucket(bucket string) *Config {
+	c.Bucket = bucket
+	return c
+}
+
+func (c *Config) WithUsePathStyle(truthy bool) *Config {
+	c.UsePathStyle = truthy
+	return c
+}
+
+func (c *Config) WithCredentials(accessKeyID string, secretAccessKey string, sessionToken string) *Config {
+	c.AccessKeyID = accessKeyID
+	c.SecretAccessKey = secretAccessKey
+	c.SessionToken = sessionToken
+	return c
+}
+
+func (c *Config) WithPresignExpiry(expiry time.Duration) *Config {
+	c.PresignExpiry = expiry
+	return c
+}
+
+func (c *Config) WithHTTPClient(client *http.Client) *Config {
+	c.HTTPClient = client
+	return c
+}
@@ -0,0 +1,485 @@
+package ociregistry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound The tag, manifest or blob does not exist
+var ErrNotFound = errors.New("not found in registry")
+
+const (
+	// maxManifestBytes Cap on manifests and tag listings read in to memory
+	maxManifestBytes = 4 << 20
+	// maxBlobBytes Cap on blobs read in to memory, e.g. signatures
+	maxBlobBytes = 8 << 20
+)
+
+var manifestAccept = strings.Join([]string{MEDIA_TYPE_INDEX, MEDIA_TYPE_MANIFEST, MEDIA_TYPE_DOCKER_LIST, MEDIA_TYPE_DOCKER_MANIFEST}, ", ")
+
+// Client Minimal OCI distribution client for one repository, answering basic
+// and bearer token challenges as registries issue them
+type Client struct {
+	cfg           Config
+	mu            sync.Mutex
+	authorization string
+}
+
+func New(cfg Config) *Client {
+	return &Client{cfg: cfg}
+}
+
+func (c *Client) Config() Config {
+	return c.cfg
+}
+
+// BlobURL returns the registry URL of a blob, which may need credentials to fetch
+func (c *Client) BlobURL(digest string) string {
+	return c.repoURL("blobs", digest)
+}
+
+// Tags lists every tag of the repository, following pagination links
+func (c *Client) Tags(ctx context.Context) ([]string, error) {
+	var tags []string
+	next := c.repoURL("tags", "list") + "?n=1000"
+	for next != "" {
+		pageURL := next
+		resp, err := c.do(ctx, c.httpClient(), func() (*http.Request, error) {
+			return http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("list tags: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := statusError(resp)
+			resp.Body.Close()
+			return nil, fmt.Errorf("list tags: %w", err)
+		}
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(io.LimitReader(resp.Body, maxManifestBytes)).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("list tags: %w", err)
+		}
+		tags = append(tags, page.Tags...)
+		next = nextLink(pageURL, resp.Header.Get("Link"))
+	}
+	return tags, nil
+}
+
+// GetManifest fetches a manifest or index by tag or digest, returning its
+// contents, media type and digest. Digest references are verified.
+func (c *Client) GetManifest(ctx context.Context, reference string) ([]byte, string, string, error) {
+	resp, err := c.do(ctx, c.httpClient(), func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.repoURL("manifests", reference), nil)
+		if err == nil {
+			req.Header.Set("Accept", manifestAccept)
+		}
+		return req, err
+	})
+	if err != nil {
+		return nil, "", "", fmt.Errorf("get manifest %s: %w", reference, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", "", fmt.Errorf("get manifest %s: %w", reference, statusError(resp))
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestBytes))
+	if err != nil {
+		return nil, "", "", fmt.Errorf("get manifest %s: %w", reference, err)
+	}
+
+	digest := Digest(body)
+	if strings.Contains(reference, ":") && reference != digest {
+		return nil, "", "", fmt.Errorf("get manifest %s: content digest %s does not match", reference, digest)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "" || mediaType == "application/json" || mediaType == "application/octet-stream" {
+		var typed struct {
+			MediaType string `json:"mediaType"`
+		}
+		_ = json.Unmarshal(body, &typed)
+		mediaType = typed.MediaType
+	}
+	return body, mediaType, digest, nil
+}
+
+// PutManifest uploads a manifest or index under reference, a tag or its digest
+func (c *Client) PutManifest(ctx context.Context, reference string, mediaType string, body []byte) (Descriptor, error) {
+	resp, err := c.do(ctx, c.httpClient(), func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.repoURL("manifests", reference), bytes.NewReader(body))
+		if err == nil {
+			req.Header.Set("Content-Type", mediaType)
+		}
+		return req, err
+	})
+	if err != nil {
+		return Descriptor{}, fmt.Errorf("put manifest %s: %w", reference, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return Descriptor{}, fmt.Errorf("put manifest %s: %w", reference, statusError(resp))
+	}
+	return Descriptor{MediaType: mediaType, Digest: Digest(body), Size: int64(len(body))}, nil
+}
+
+// PushBlob uploads a file, skipping the upload when the registry already has it
+func (c *Client) PushBlob(ctx context.Context, path string, mediaType string) (Descriptor, error) {
+	source, err := os.Open(path)
+	if err != nil {
+		return Descriptor{}, err
+	}
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, source)
+	source.Close()
+	if err != nil {
+		return Descriptor{}, fmt.Errorf("hash %s: %w", path, err)
+	}
+	descriptor := Descriptor{MediaType: mediaType, Digest: "sha256:" + hex.EncodeToString(hasher.Sum(nil)), Size: size}
+	return descriptor, c.pushBlob(ctx, descriptor, func() (io.ReadCloser, error) {
+		return os.Open(path)
+	})
+}
+
+// PushBlobBytes uploads a small blob held in memory
+func (c *Client) PushBlobBytes(ctx context.Context, data []byte, mediaType string) (Descriptor, error) {
+	descriptor := Descriptor{MediaType: mediaType, Digest: Digest(data), Size: int64(len(data))}
+	return descriptor, c.pushBlob(ctx, descriptor, func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+}
+
+// FetchBlob reads a small blob, e.g. a signature, verifying its digest
+func (c *Client) FetchBlob(ctx context.Context, digest string) ([]byte, error) {
+	resp, err := c.do(ctx, c.httpClient(), func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, c.BlobURL(digest), nil)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetch blob %s: %w", digest, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch blob %s: %w", digest, statusError(resp))
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBlobBytes))
+	if err != nil {
+		return nil, fmt.Errorf("fetch blob %s: %w", digest, err)
+	}
+	if Digest(body) != digest {
+		return nil, fmt.Errorf("fetch blob %s: content digest does not match", digest)
+	}
+	return body, nil
+}
+
+// ResolveBlobURL returns the storage URL the registry redirects downloads of
+// a blob to, usually presigned, which needs no registry credentials. ok is
+// false when the registry serves blobs itself; read those with CopyBlob.
+func (c *Client) ResolveBlobURL(ctx context.Context, digest string) (string, bool, error) {
+	noRedirect := *c.httpClient()
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	blobURL := c.BlobURL(digest)
+	resp, err := c.do(ctx, &noRedirect, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, blobURL, nil)
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("resolve blob %s: %w", digest, err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		location, err := resp.Location()
+		if err != nil {
+			return "", false, fmt.Errorf("resolve blob %s: %w", digest, err)
+		}
+		return location.String(), true, nil
+	case resp.StatusCode == http.StatusOK:
+		return "", false, nil
+	}
+	return "", false, fmt.Errorf("resolve blob %s: %w", digest, statusError(resp))
+}
+
+// CopyBlob streams a blob to destination, verifying its digest
+func (c *Client) CopyBlob(ctx context.Context, digest string, destination string) error {
+	resp, err := c.do(ctx, c.httpClient(), func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, c.BlobURL(digest), nil)
+	})
+	if err != nil {
+		return fmt.Errorf("copy blob %s: %w", digest, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("copy blob %s: %w", digest, statusError(resp))
+	}
+
+	output, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	hasher := sha256.New()
+	_, copyErr := io.Copy(io.MultiWriter(output, hasher), resp.Body)
+	if closeErr := output.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr == nil && "sha256:"+hex.EncodeToString(hasher.Sum(nil)) != digest {
+		copyErr = errors.New("content digest does not match")
+	}
+	if copyErr != nil {
+		_ = os.Remove(destination)
+		return fmt.Errorf("copy blob %s: %w", digest, copyErr)
+	}
+	return nil
+}
+
+// Digest returns the sha256 digest of data in OCI form
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (c *Client) pushBlob(ctx context.Context, descriptor Descriptor, open func() (io.ReadCloser, error)) error {
+	head, err := c.do(ctx, c.httpClient(), func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodHead, c.BlobURL(descriptor.Digest), nil)
+	})
+	if err != nil {
+		return fmt.Errorf("push blob %s: %w", descriptor.Digest, err)
+	}
+	head.Body.Close()
+	if head.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	start, err := c.do(ctx, c.httpClient(), func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodPost, c.repoURL("blobs", "uploads")+"/", nil)
+	})
+	if err != nil {
+		return fmt.Errorf("push blob %s: %w", descriptor.Digest, err)
+	}
+	start.Body.Close()
+	if start.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("push blob %s: start upload: %w", descriptor.Digest, statusError(start))
+	}
+	location, err := start.Location()
+	if err != nil {
+		return fmt.Errorf("push blob %s: %w", descriptor.Digest, err)
+	}
+	query := location.Query()
+	query.Set("digest", descriptor.Digest)
+	location.RawQuery = query.Encode()
+
+	resp, err := c.do(ctx, c.httpClient(), func() (*http.Request, error) {
+		body, err := open()
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, location.String(), body)
+		if err != nil {
+			body.Close()
+			return nil, err
+		}
+		req.ContentLength = descriptor.Size
+		req.Header.Set("Content-Type", "application/octet-stream")
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("push blob %s: %w", descriptor.Digest, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("push blob %s: %w", descriptor.Digest, statusError(resp))
+	}
+	return nil
+}
+
+// do sends a request, answering an authentication challenge and retrying once
+func (c *Client) do(ctx context.Context, httpClient *http.Client, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		if authorization := c.currentAuthorization(); authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusUnauthorized || attempt > 0 {
+			return resp, nil
+		}
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if err := c.authorize(ctx, challenge); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// authorize answers a WWW-Authenticate challenge, fetching a bearer token from
+// the realm it names when asked to
+func (c *Client) authorize(ctx context.Context, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	switch scheme {
+	case "basic":
+		if c.cfg.Username == "" {
+			return errors.New("registry requires credentials")
+		}
+		c.setAuthorization("Basic " + base64.StdEncoding.EncodeToString([]byte(c.cfg.Username+":"+c.cfg.Password)))
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("unsupported registry authentication %q", challenge)
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("invalid token realm %q", params["realm"])
+	}
+	query := realm.Query()
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + c.cfg.Repository + ":pull"
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if c.cfg.Username != "" {
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch registry token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch registry token: %w", statusError(resp))
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestBytes)).Decode(&token); err != nil {
+		return fmt.Errorf("fetch registry token: %w", err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return errors.New("fetch registry token: empty token")
+	}
+	c.setAuthorization("Bearer " + token.Token)
+	return nil
+}
+
+func (c *Client) currentAuthorization() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.authorization
+}
+
+func (c *Client) setAuthorization(authorization string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.authorization = authorization
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.cfg.HTTPClient != nil {
+		return c.cfg.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c *Client) repoURL(parts ...string) string {
+	return strings.TrimRight(c.cfg.BaseURL, "/") + "/v2/" + strings.Trim(c.cfg.Repository, "/") + "/" + strings.Join(parts, "/")
+}
+
+// parseChallenge splits a WWW-Authenticate header in to its lower cased
+// scheme and parameters, allowing commas within quoted values
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := map[string]string{}
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimLeft(rest, ", ") {
+		key, value, found := strings.Cut(rest, "=")
+		if !found {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+			params[key] = value[1 : end+1]
+			rest = value[end+2:]
+			continue
+		}
+		value, rest, _ = strings.Cut(value, ",")
+		params[key] = strings.TrimSpace(value)
+	}
+	return strings.ToLower(scheme), params
+}
+
+// nextLink resolves the rel="next" target of a Link header against current
+func nextLink(current string, header string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, found := strings.Cut(link, ";")
+		if !found || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		base, err := url.Parse(current)
+		if err != nil {
+			return ""
+		}
+		next, err := base.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+		if err != nil {
+			return ""
+		}
+		return next.String()
+	}
+	return ""
+}
+
+// statusError describes an unexpected response, including the registry's
+// error code when it sent one
+func statusError(resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	var apiErr struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(body, &apiErr) == nil && len(apiErr.Errors) > 0 {
+		return fmt.Errorf("%s: %s: %s", resp.Status, apiErr.Errors[0].Code, apiErr.Errors[0].Message)
+	}
+	return errors.New(resp.Status)
+}
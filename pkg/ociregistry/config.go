@@ -0,0 +1,66 @@
+package ociregistry
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Config Connection settings for one repository of an OCI distribution registry
+type Config struct {
+	// BaseURL Registry URL, e.g. "https://ghcr.io" or "http://localhost:5000"
+	BaseURL string `json:"base_url" yaml:"base_url" mapstructure:"base_url"`
+	// Repository Name within the registry, e.g. "joy-dx/app-example"
+	Repository string `json:"repository" yaml:"repository" mapstructure:"repository"`
+	// Username Optional, sent as basic auth to the registry or its token service
+	Username string `json:"-" yaml:"-" mapstructure:"username"`
+	// Password Optional password or access token for Username
+	Password string `json:"-" yaml:"-" mapstructure:"password"`
+	// HTTPClient Defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+func DefaultConfig() Config {
+	return Config{}
+}
+
+// ParseReference fills BaseURL and Repository from a reference such as
+// "registry.example.com/team/app", assuming https without a scheme
+func (c *Config) ParseReference(reference string) error {
+	if !strings.Contains(reference, "://") {
+		reference = "https://" + reference
+	}
+	parsed, err := url.Parse(reference)
+	if err != nil {
+		return fmt.Errorf("parse registry reference: %w", err)
+	}
+	repository := strings.Trim(parsed.Path, "/")
+	if parsed.Host == "" || repository == "" {
+		return fmt.Errorf("registry reference %q needs a host and repository", reference)
+	}
+	c.BaseURL = parsed.Scheme + "://" + parsed.Host
+	c.Repository = repository
+	return nil
+}
+
+func (c *Config) WithBaseURL(url string) *Config {
+	c.BaseURL = url
+	return c
+}
+
+func (c *Config) WithRepository(repository string) *Config {
+	c.Repository = repository
+	return c
+}
+
+func (c *Config) WithCredentials(username string, password string) *Config {
+	c.Username = username
+	c.Password = password
+	return c
+}
+
+func (c *Config) WithHTTPClient(client *http.Client) *Config {
+	c.HTTPClient = client
+	return c
+}
@@ -0,0 +1,90 @@
+package ociregistry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+// Artefact One platform build of a release to publish
+type Artefact struct {
+	Path string
+	// Name Defaults to the base name of Path
+	Name          string
+	Platform      string
+	Arch          string
+	Variant       string
+	Version       string
+	Signature     string
+	SignatureType string
+}
+
+// PushArtefact uploads an artefact, and its signature when set, as an OCI
+// artefact manifest. The returned descriptor is ready to list in an index.
+func (c *Client) PushArtefact(ctx context.Context, artefact Artefact) (Descriptor, error) {
+	name := artefact.Name
+	if name == "" {
+		name = filepath.Base(artefact.Path)
+	}
+	layer, err := c.PushBlob(ctx, artefact.Path, MEDIA_TYPE_ARTEFACT)
+	if err != nil {
+		return Descriptor{}, err
+	}
+	layer.Annotations = map[string]string{ANNOTATION_TITLE: name}
+	layers := []Descriptor{layer}
+
+	if artefact.Signature != "" {
+		signatureLayer, err := c.PushBlobBytes(ctx, []byte(artefact.Signature), MEDIA_TYPE_SIGNATURE)
+		if err != nil {
+			return Descriptor{}, err
+		}
+		signatureLayer.Annotations = map[string]string{
+			ANNOTATION_TITLE:          name + ".asc",
+			ANNOTATION_SIGNATURE_TYPE: artefact.SignatureType,
+		}
+		layers = append(layers, signatureLayer)
+	}
+
+	config, err := c.PushBlobBytes(ctx, []byte("{}"), MEDIA_TYPE_EMPTY)
+	if err != nil {
+		return Descriptor{}, err
+	}
+	annotations := map[string]string{ANNOTATION_VERSION: artefact.Version}
+	if artefact.Variant != "" {
+		annotations[ANNOTATION_VARIANT] = artefact.Variant
+	}
+	body, err := json.Marshal(Manifest{
+		SchemaVersion: 2,
+		MediaType:     MEDIA_TYPE_MANIFEST,
+		ArtifactType:  ARTIFACT_TYPE,
+		Config:        config,
+		Layers:        layers,
+		Annotations:   annotations,
+	})
+	if err != nil {
+		return Descriptor{}, err
+	}
+	descriptor, err := c.PutManifest(ctx, Digest(body), MEDIA_TYPE_MANIFEST, body)
+	if err != nil {
+		return Descriptor{}, fmt.Errorf("publish %s: %w", name, err)
+	}
+	descriptor.ArtifactType = ARTIFACT_TYPE
+	descriptor.Annotations = annotations
+	descriptor.Platform = &Platform{OS: artefact.Platform, Architecture: artefact.Arch}
+	return descriptor, nil
+}
+
+// PushIndex tags an index of previously pushed artefact manifests
+func (c *Client) PushIndex(ctx context.Context, tag string, manifests []Descriptor, annotations map[string]string) (Descriptor, error) {
+	body, err := json.Marshal(Index{
+		SchemaVersion: 2,
+		MediaType:     MEDIA_TYPE_INDEX,
+		Manifests:     manifests,
+		Annotations:   annotations,
+	})
+	if err != nil {
+		return Descriptor{}, err
+	}
+	return c.PutManifest(ctx, tag, MEDIA_TYPE_INDEX, body)
+}
@@ -0,0 +1,76 @@
+package ociregistry
+
+import "strings"
+
+const (
+	MEDIA_TYPE_INDEX           = "application/vnd.oci.image.index.v1+json"
+	MEDIA_TYPE_MANIFEST        = "application/vnd.oci.image.manifest.v1+json"
+	MEDIA_TYPE_DOCKER_LIST     = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MEDIA_TYPE_DOCKER_MANIFEST = "application/vnd.docker.distribution.manifest.v2+json"
+	// MEDIA_TYPE_EMPTY Config blob of artefacts that have no config, always "{}"
+	MEDIA_TYPE_EMPTY = "application/vnd.oci.empty.v1+json"
+	// ARTIFACT_TYPE Marks manifests holding one platform build of a release
+	ARTIFACT_TYPE        = "application/vnd.joy-dx.gophorth.release.v1"
+	MEDIA_TYPE_ARTEFACT  = "application/vnd.joy-dx.gophorth.artefact.v1"
+	MEDIA_TYPE_SIGNATURE = "application/vnd.joy-dx.gophorth.signature.v1"
+
+	ANNOTATION_CREATED        = "org.opencontainers.image.created"
+	ANNOTATION_DESCRIPTION    = "org.opencontainers.image.description"
+	ANNOTATION_TITLE          = "org.opencontainers.image.title"
+	ANNOTATION_URL            = "org.opencontainers.image.url"
+	ANNOTATION_VERSION        = "org.opencontainers.image.version"
+	ANNOTATION_VARIANT        = "dev.joy-dx.gophorth.variant"
+	ANNOTATION_SIGNATURE_TYPE = "dev.joy-dx.gophorth.signature_type"
+)
+
+// Descriptor Points at a blob or manifest by digest
+type Descriptor struct {
+	MediaType    string            `json:"mediaType"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	Platform     *Platform         `json:"platform,omitempty"`
+}
+
+// Platform OS and CPU a manifest was built for. Variant is the CPU variant,
+// e.g. "v7", app variants are carried by ANNOTATION_VARIANT instead.
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// Manifest An OCI image manifest
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Index An OCI image index, one manifest per platform
+type Index struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	Manifests     []Descriptor      `json:"manifests"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// IsIndex reports whether mediaType lists per platform manifests
+func IsIndex(mediaType string) bool {
+	return mediaType == MEDIA_TYPE_INDEX || mediaType == MEDIA_TYPE_DOCKER_LIST
+}
+
+// TagForVersion converts a semantic version to a valid tag, as "+" is not
+// allowed in tags
+func TagForVersion(version string) string {
+	return strings.ReplaceAll(version, "+", "_")
+}
+
+// VersionFromTag reverses TagForVersion
+func VersionFromTag(tag string) string {
+	return strings.ReplaceAll(tag, "_", "+")
+}
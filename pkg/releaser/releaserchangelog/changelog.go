@@ -0,0 +1,163 @@
+package releaserchangelog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Conventional commit types listed in the changelog, other types only appear
+// when they are breaking changes
+const (
+	TYPE_FEAT = "feat"
+	TYPE_FIX  = "fix"
+	TYPE_PERF = "perf"
+)
+
+// CHANGELOG_TITLE First line of a changelog file written by PrependRelease
+const CHANGELOG_TITLE = "# Changelog"
+
+// Entry A conventional commit worth listing in the changelog
+type Entry struct {
+	Type        string
+	Scope       string
+	Description string
+	Breaking    bool
+	// BreakingNote Text of a "BREAKING CHANGE:" footer, if any
+	BreakingNote string
+	Hash         string
+}
+
+// headerPattern matches "type(scope)!: description"
+var headerPattern = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^()\r\n]*)\))?(!)?: (.+)$`)
+
+// ParseCommit reads the conventional commit header and breaking change footer
+// of a commit message. Reports false for messages not following the convention.
+func ParseCommit(commit Commit) (Entry, bool) {
+	lines := strings.Split(strings.TrimSpace(commit.Message), "\n")
+	groups := headerPattern.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if groups == nil {
+		return Entry{}, false
+	}
+	entry := Entry{
+		Type:        strings.ToLower(groups[1]),
+		Scope:       strings.TrimSpace(groups[2]),
+		Description: strings.TrimSpace(groups[4]),
+		Breaking:    groups[3] == "!",
+		Hash:        commit.Hash,
+	}
+	for idx, line := range lines[1:] {
+		note, found := strings.CutPrefix(line, "BREAKING CHANGE:")
+		if !found {
+			note, found = strings.CutPrefix(line, "BREAKING-CHANGE:")
+		}
+		if !found {
+			continue
+		}
+		// The note runs on to the following lines until a blank line
+		noteLines := []string{strings.TrimSpace(note)}
+		for _, next := range lines[idx+2:] {
+			if strings.TrimSpace(next) == "" {
+				break
+			}
+			noteLines = append(noteLines, strings.TrimSpace(next))
+		}
+		entry.Breaking = true
+		entry.BreakingNote = strings.TrimSpace(strings.Join(noteLines, " "))
+		break
+	}
+	return entry, true
+}
+
+// Parse keeps the commits that follow the convention, in the order given
+func Parse(commits []Commit) []Entry {
+	entries := make([]Entry, 0, len(commits))
+	for _, commit := range commits {
+		if entry, ok := ParseCommit(commit); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Render groups entries in to Markdown sections, breaking changes first.
+// Empty when no entry is worth listing.
+func Render(entries []Entry) string {
+	sections := []struct {
+		title   string
+		include func(Entry) bool
+		text    func(Entry) string
+	}{
+		{
+			title:   "Breaking Changes",
+			include: func(e Entry) bool { return e.Breaking },
+			text: func(e Entry) string {
+				if e.BreakingNote != "" {
+					return e.BreakingNote
+				}
+				return e.Description
+			},
+		},
+		{title: "Features", include: func(e Entry) bool { return e.Type == TYPE_FEAT }},
+		{title: "Bug Fixes", include: func(e Entry) bool { return e.Type == TYPE_FIX }},
+		{title: "Performance Improvements", include: func(e Entry) bool { return e.Type == TYPE_PERF }},
+	}
+
+	var blocks []string
+	for _, section := range sections {
+		var b strings.Builder
+		for _, entry := range entries {
+			if !section.include(entry) {
+				continue
+			}
+			text := entry.Description
+			if section.text != nil {
+				text = section.text(entry)
+			}
+			b.WriteString("\n- ")
+			if entry.Scope != "" {
+				b.WriteString("**" + entry.Scope + ":** ")
+			}
+			b.WriteString(text)
+			if entry.Hash != "" {
+				b.WriteString(" (" + shortHash(entry.Hash) + ")")
+			}
+		}
+		if b.Len() > 0 {
+			blocks = append(blocks, "### "+section.title+"\n"+b.String())
+		}
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// PrependRelease adds the notes of a release to the top of a changelog file's
+// contents, replacing an earlier section for the same version
+func PrependRelease(existing string, version string, releasedAt time.Time, notes string) string {
+	heading := fmt.Sprintf("## %s (%s)", version, releasedAt.Format("2006-01-02"))
+
+	var kept []string
+	skipping := false
+	for _, line := range strings.Split(strings.TrimPrefix(strings.TrimSpace(existing), CHANGELOG_TITLE), "\n") {
+		if strings.HasPrefix(line, "## ") {
+			title := strings.TrimPrefix(line, "## ")
+			skipping = title == version || strings.HasPrefix(title, version+" ")
+		}
+		if !skipping {
+			kept = append(kept, line)
+		}
+	}
+
+	contents := CHANGELOG_TITLE + "\n\n" + heading + "\n\n" + strings.TrimSpace(notes) + "\n"
+	if rest := strings.TrimSpace(strings.Join(kept, "\n")); rest != "" {
+		contents += "\n" + rest + "\n"
+	}
+	return contents
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,132 @@
+package releaserchangelog
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+func TestParseCommit(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    Entry
+		wantOK  bool
+	}{
+		{name: "feature", message: "feat: add bundles", want: Entry{Type: "feat", Description: "add bundles"}, wantOK: true},
+		{name: "scoped fix", message: "fix(updater): resume downloads\n\nLonger body.", want: Entry{Type: "fix", Scope: "updater", Description: "resume downloads"}, wantOK: true},
+		{name: "bang breaking", message: "refactor(api)!: drop v1 routes", want: Entry{Type: "refactor", Scope: "api", Description: "drop v1 routes", Breaking: true}, wantOK: true},
+		{
+			name:    "footer breaking",
+			message: "perf: stream manifests\n\nBREAKING CHANGE: manifests must be\nUTF-8 encoded\n\nRefs: #12",
+			want:    Entry{Type: "perf", Description: "stream manifests", Breaking: true, BreakingNote: "manifests must be UTF-8 encoded"},
+			wantOK:  true,
+		},
+		{name: "not conventional", message: "Merge pull request #4", wantOK: false},
+		{name: "missing space", message: "fix:typo", wantOK: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := ParseCommit(Commit{Message: tc.message})
+			if ok != tc.wantOK {
+				t.Fatalf("ok=%v want %v", ok, tc.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %+v want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	entries := Parse([]Commit{
+		{Hash: "aaaaaaaaaa", Message: "feat(ui)!: new settings page"},
+		{Hash: "bbbbbbbbbb", Message: "fix: crash on start"},
+		{Hash: "cccccccccc", Message: "chore: bump deps"},
+		{Hash: "dddddddddd", Message: "perf: faster checks"},
+	})
+	want := "### Breaking Changes\n\n- **ui:** new settings page (aaaaaaa)" +
+		"\n\n### Features\n\n- **ui:** new settings page (aaaaaaa)" +
+		"\n\n### Bug Fixes\n\n- crash on start (bbbbbbb)" +
+		"\n\n### Performance Improvements\n\n- faster checks (ddddddd)"
+	if got := Render(entries); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if got := Render(Parse([]Commit{{Message: "docs: typo"}})); got != "" {
+		t.Fatalf("expected no sections, got %q", got)
+	}
+}
+
+func TestPrependRelease(t *testing.T) {
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	first := PrependRelease("", "1.0.0", day, "### Features\n\n- one")
+	second := PrependRelease(first, "1.1.0", day.AddDate(0, 0, 1), "### Bug Fixes\n\n- two")
+	rebuilt := PrependRelease(second, "1.1.0", day.AddDate(0, 0, 2), "### Bug Fixes\n\n- three")
+	want := "# Changelog\n\n## 1.1.0 (2026-05-03)\n\n### Bug Fixes\n\n- three\n\n## 1.0.0 (2026-05-01)\n\n### Features\n\n- one\n"
+	if rebuilt != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", rebuilt, want)
+	}
+}
+
+func TestReadCommitsSincePreviousTag(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, output)
+		}
+	}
+	commit := func(message string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo, "file.txt"), []byte(message), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git("add", "file.txt")
+		git("commit", "-q", "-m", message)
+	}
+	git("init", "-q")
+	commit("feat: first")
+	git("tag", "v1.0.0")
+	commit("fix: second\n\nWith a body.")
+	commit("feat: third")
+	git("tag", "v1.1.0")
+
+	opts := DefaultGitOptions()
+	opts.WithRepoPath(repo)
+	ctx := context.Background()
+	for _, tc := range []struct {
+		version *semver.Version
+		want    string
+	}{
+		{version: semver.MustParse("1.1.0"), want: "v1.0.0"},
+		{version: nil, want: "v1.0.0"},
+		{version: semver.MustParse("1.0.0"), want: ""},
+	} {
+		if got, err := PreviousTag(ctx, opts, tc.version); err != nil || got != tc.want {
+			t.Fatalf("previous tag of %v = %q, %v want %q", tc.version, got, err, tc.want)
+		}
+	}
+
+	commits, err := ReadCommits(ctx, opts, "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, c := range commits {
+		messages = append(messages, c.Message)
+	}
+	if want := []string{"feat: third", "fix: second\n\nWith a body."}; !reflect.DeepEqual(messages, want) {
+		t.Fatalf("messages=%q want %q", messages, want)
+	}
+}
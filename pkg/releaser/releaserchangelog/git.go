@@ -0,0 +1,118 @@
+package releaserchangelog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Commit A commit message read from the repository
+type Commit struct {
+	Hash    string
+	Message string
+}
+
+// GitOptions Where and how to read the repository history
+type GitOptions struct {
+	// GitBinary Path or name of the git binary
+	GitBinary string
+	// RepoPath Working tree of the repository
+	RepoPath string
+}
+
+func DefaultGitOptions() GitOptions {
+	return GitOptions{
+		GitBinary: "git",
+		RepoPath:  ".",
+	}
+}
+
+func (o *GitOptions) WithGitBinary(path string) *GitOptions {
+	o.GitBinary = path
+	return o
+}
+
+func (o *GitOptions) WithRepoPath(path string) *GitOptions {
+	o.RepoPath = path
+	return o
+}
+
+// PreviousTag finds the tag a release of version follows: the highest
+// semantic version tag reachable from HEAD that is lower than version. With no
+// version, tags pointing at HEAD are skipped instead. Empty when there is none.
+func PreviousTag(ctx context.Context, opts GitOptions, version *semver.Version) (string, error) {
+	output, err := runGit(ctx, opts, "tag", "--merged", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	skip := map[string]bool{}
+	if version == nil {
+		headTags, headErr := runGit(ctx, opts, "tag", "--points-at", "HEAD")
+		if headErr != nil {
+			return "", headErr
+		}
+		for _, tag := range strings.Fields(headTags) {
+			skip[tag] = true
+		}
+	}
+
+	var (
+		previous        string
+		previousVersion *semver.Version
+	)
+	for _, tag := range strings.Fields(output) {
+		tagVersion, parseErr := semver.NewVersion(tag)
+		if parseErr != nil || skip[tag] {
+			continue
+		}
+		if version != nil && !tagVersion.LessThan(version) {
+			continue
+		}
+		if previousVersion == nil || tagVersion.GreaterThan(previousVersion) {
+			previous, previousVersion = tag, tagVersion
+		}
+	}
+	return previous, nil
+}
+
+// ReadCommits lists the commits after since up to HEAD, newest first, leaving
+// out merges. An empty since reads the whole history.
+func ReadCommits(ctx context.Context, opts GitOptions, since string) ([]Commit, error) {
+	revisions := "HEAD"
+	if since != "" {
+		revisions = since + "..HEAD"
+	}
+	// Unit and record separators keep multi-line messages intact
+	output, err := runGit(ctx, opts, "log", "--no-merges", "--format=%H%x1f%B%x1e", revisions, "--")
+	if err != nil {
+		return nil, err
+	}
+	var commits []Commit
+	for _, record := range strings.Split(output, "\x1e") {
+		hash, message, found := strings.Cut(strings.TrimSpace(record), "\x1f")
+		if !found {
+			continue
+		}
+		commits = append(commits, Commit{Hash: hash, Message: strings.TrimSpace(message)})
+	}
+	return commits, nil
+}
+
+func runGit(ctx context.Context, opts GitOptions, args ...string) (string, error) {
+	gitBinary := opts.GitBinary
+	if gitBinary == "" {
+		gitBinary = "git"
+	}
+	cmd := exec.CommandContext(ctx, gitBinary, append([]string{"-C", opts.RepoPath}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
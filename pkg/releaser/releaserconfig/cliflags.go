@@ -17,6 +17,17 @@ func CobraAndViper(cmd *cobra.Command) {
 	configBuilder.AddStringParam(options.ReleaserFilePattern, "app-example-{platform}-{arch}", "name the published app to be processed starts with")
 	configBuilder.AddStringParam(options.ReleaserPrivateKey, "", "Contains ASCII encode EDCSA or PGP public key")
 	configBuilder.AddStringParam(options.ReleaserPrivateKeyPath, "./cmd/assets/private-pgp.key", "Path to EDCSA or PGP public key")
+	configBuilder.AddStringParam(options.ReleaserBundlePath, "", "Optional offline bundle to write, the extension (.tar.gz, .zip) picks the format")
+	configBuilder.AddStringParam(options.ReleaserChangelogRepoPath, "", "Optional git working tree to generate the changelog from conventional commits")
+	configBuilder.AddStringParam(options.ReleaserChangelogNotesPath, "", "Optional hand-written release notes, used in place of the generated changelog")
+	configBuilder.AddStringParam(options.ReleaserChangelogOutputPath, "", "Optional CHANGELOG.md to add the release notes to")
+	configBuilder.AddStringParam(options.ReleaserOCIRepository, "", "Optional OCI registry repository to publish the release to, e.g. registry.example.com/team/app")
+	configBuilder.AddStringParam(options.ReleaserOCIUsername, "", "Username for the OCI registry")
+	configBuilder.AddStringParam(options.ReleaserOCIPassword, "", "Password or access token for the OCI registry")
+	configBuilder.AddIntParam(options.ReleaserHistoryMaxReleases, 0, "Releases kept in the releases.json history index, 0 keeps all")
+	configBuilder.AddDurationParam(options.ReleaserHistoryMaxAge, 0, "Drops releases published longer ago from the releases.json history index, 0 keeps all")
+	configBuilder.AddBoolParam(options.ReleaserHistoryReplace, false, "Replaces the releases.json entry of a version already listed instead of keeping the published one")
+	configBuilder.AddStringSliceParam(options.ReleaserDownloadPrefixes, []string{}, "Additional download prefixes used to generate mirror URLs, tried in order")
 	configBuilder.AddBoolParam(options.ReleaserAllowAnyExtension, false, "Allows a file extension after the pattern (\".zip\", \".tar.gz\", etc.)")
 	configBuilder.AddBoolParam(options.ReleaserStrict, false, "If true, non-matching files cause an error. If false, they are skipped.")
 	configBuilder.AddBoolParam(options.ReleaserRequireVersion, false, "If true, {version} is treated as required when used in the pattern.")
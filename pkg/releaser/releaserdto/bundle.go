@@ -0,0 +1,23 @@
+package releaserdto
+
+// Offline bundles are a single tar.gz, or zip, archive holding everything needed
+// to update without network access. Entries sit at the archive root:
+//
+//	manifest.json       BundleManifest with the release summary
+//	manifest.json.asc   Detached signature of manifest.json, when signing
+//	checksums.txt       SHA-256 listing of the artefacts
+//	<artefact>          One file per platform / architecture / variant
+//	<artefact>.asc      Detached signature of the artefact, when signing
+const (
+	BUNDLE_FORMAT_VERSION     = 1
+	BUNDLE_MANIFEST           = "manifest.json"
+	BUNDLE_MANIFEST_SIGNATURE = "manifest.json.asc"
+	BUNDLE_CHECKSUMS          = "checksums.txt"
+)
+
+// BundleManifest Index of an offline bundle
+type BundleManifest struct {
+	// Format Layout version, bumped on incompatible changes
+	Format  int            `json:"format"`
+	Release ReleaseSummary `json:"release"`
+}
@@ -0,0 +1,88 @@
+package releaserdto
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// RELEASE_HISTORY Name of the release history index written next to the latest manifest
+const RELEASE_HISTORY = "releases.json"
+
+// ReleaseHistory Index of published releases, newest first, letting check
+// clients offer changelogs for every version an update skips
+type ReleaseHistory struct {
+	Releases []ReleaseSummary `json:"releases"`
+}
+
+// ErrReleaseInHistory The version is already listed, its published entry is kept
+var ErrReleaseInHistory = errors.New("release already in the history")
+
+// Add records a release. An entry for the same version is only replaced when
+// replace is set, otherwise it is kept and ErrReleaseInHistory returned, so a
+// re-run cannot silently rewrite what was published. Reports whether an entry
+// was replaced.
+func (h *ReleaseHistory) Add(summary ReleaseSummary, replace bool) (bool, error) {
+	for idx, existing := range h.Releases {
+		if !sameVersion(existing.Version, summary.Version) {
+			continue
+		}
+		if !replace {
+			return false, fmt.Errorf("%s: %w", summary.Version, ErrReleaseInHistory)
+		}
+		h.Releases[idx] = summary
+		h.sort()
+		return true, nil
+	}
+	h.Releases = append(h.Releases, summary)
+	h.sort()
+	return false, nil
+}
+
+// Prune drops releases beyond the newest maxReleases and those published more
+// than maxAge before now, zero disabling either limit. The newest release is
+// always kept. Returns the dropped releases.
+func (h *ReleaseHistory) Prune(maxReleases int, maxAge time.Duration, now time.Time) []ReleaseSummary {
+	var kept, dropped []ReleaseSummary
+	for idx, summary := range h.Releases {
+		tooMany := maxReleases > 0 && len(kept) >= maxReleases
+		tooOld := maxAge > 0 && summary.PublishedAt != nil && now.Sub(*summary.PublishedAt) > maxAge
+		if idx > 0 && (tooMany || tooOld) {
+			dropped = append(dropped, summary)
+			continue
+		}
+		kept = append(kept, summary)
+	}
+	h.Releases = kept
+	return dropped
+}
+
+// sort orders releases highest version first, those without a semantic
+// version last by publish date
+func (h *ReleaseHistory) sort() {
+	sort.SliceStable(h.Releases, func(i, j int) bool {
+		vi, errI := semver.NewVersion(h.Releases[i].Version)
+		vj, errJ := semver.NewVersion(h.Releases[j].Version)
+		switch {
+		case errI == nil && errJ == nil:
+			return vi.GreaterThan(vj)
+		case errI == nil || errJ == nil:
+			return errI == nil
+		}
+		pi, pj := h.Releases[i].PublishedAt, h.Releases[j].PublishedAt
+		return pi != nil && (pj == nil || pi.After(*pj))
+	})
+}
+
+// sameVersion compares semantically when possible, so "v1.2.0" matches "1.2.0"
+func sameVersion(a string, b string) bool {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return va.Equal(vb)
+}
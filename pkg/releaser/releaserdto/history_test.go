@@ -0,0 +1,99 @@
+package releaserdto
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReleaseHistory_AddAndPrune(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	release := func(version string, age time.Duration, changelog string) ReleaseSummary {
+		publishedAt := now.Add(-age)
+		return ReleaseSummary{Version: version, PublishedAt: &publishedAt, Changelog: changelog}
+	}
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name         string
+		existing     []ReleaseSummary
+		add          ReleaseSummary
+		maxReleases  int
+		maxAge       time.Duration
+		wantVersions []string
+		replace      bool
+		wantReplaced bool
+		wantErr      error
+		wantDropped  int
+	}{
+		{
+			name:         "appends newest first",
+			existing:     []ReleaseSummary{release("1.1.0", 10*day, ""), release("1.0.0", 20*day, "")},
+			add:          release("1.2.0", 0, ""),
+			wantVersions: []string{"1.2.0", "1.1.0", "1.0.0"},
+		},
+		{
+			name:         "published version kept",
+			existing:     []ReleaseSummary{release("1.1.0", 10*day, "old"), release("1.0.0", 20*day, "")},
+			add:          release("v1.1.0", 0, "new"),
+			wantVersions: []string{"1.1.0", "1.0.0"},
+			wantErr:      ErrReleaseInHistory,
+		},
+		{
+			name:         "rebuilt version replaces its entry when asked",
+			existing:     []ReleaseSummary{release("1.1.0", 10*day, "old"), release("1.0.0", 20*day, "")},
+			add:          release("v1.1.0", 0, "new"),
+			replace:      true,
+			wantVersions: []string{"v1.1.0", "1.0.0"},
+			wantReplaced: true,
+		},
+		{
+			name:         "out of order release keeps version order",
+			existing:     []ReleaseSummary{release("2.0.0", 10*day, ""), release("1.0.0", 20*day, "")},
+			add:          release("1.0.1", 0, ""),
+			wantVersions: []string{"2.0.0", "1.0.1", "1.0.0"},
+		},
+		{
+			name:         "pruned by count",
+			existing:     []ReleaseSummary{release("1.1.0", 10*day, ""), release("1.0.0", 20*day, "")},
+			add:          release("1.2.0", 0, ""),
+			maxReleases:  2,
+			wantVersions: []string{"1.2.0", "1.1.0"},
+			wantDropped:  1,
+		},
+		{
+			name:         "pruned by age",
+			existing:     []ReleaseSummary{release("1.1.0", 10*day, ""), release("1.0.0", 40*day, "")},
+			add:          release("1.2.0", 0, ""),
+			maxAge:       30 * day,
+			wantVersions: []string{"1.2.0", "1.1.0"},
+			wantDropped:  1,
+		},
+		{
+			name:         "newest kept regardless of age",
+			existing:     []ReleaseSummary{release("1.0.0", 90*day, "")},
+			add:          release("1.1.0", 60*day, ""),
+			maxAge:       30 * day,
+			wantVersions: []string{"1.1.0"},
+			wantDropped:  1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			history := ReleaseHistory{Releases: append([]ReleaseSummary{}, tc.existing...)}
+			replaced, err := history.Add(tc.add, tc.replace)
+			if replaced != tc.wantReplaced || !errors.Is(err, tc.wantErr) {
+				t.Fatalf("replaced=%v err=%v want %v %v", replaced, err, tc.wantReplaced, tc.wantErr)
+			}
+			dropped := history.Prune(tc.maxReleases, tc.maxAge, now)
+			var versions []string
+			for _, summary := range history.Releases {
+				versions = append(versions, summary.Version)
+			}
+			if !reflect.DeepEqual(versions, tc.wantVersions) || len(dropped) != tc.wantDropped {
+				t.Fatalf("versions=%v dropped=%d want %v %d", versions, len(dropped), tc.wantVersions, tc.wantDropped)
+			}
+		})
+	}
+}
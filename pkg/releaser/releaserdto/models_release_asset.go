@@ -1,16 +1,24 @@
 package releaserdto
 
+import "time"
+
 type ReleaseAsset struct {
-	ArtefactName  string `json:"artefact_name"`
-	Platform      string `json:"platform"` // e.g. "linux", "darwin"
-	Arch          string `json:"arch"`     // e.g. "amd64", "arm64"
-	Variant       string `json:"variant"`  // e.g. "webkit2_41", "standard"
-	Version       string `json:"version"`
-	DownloadURL   string `json:"download_url"`        // direct link to binary/archive
-	Checksum      string `json:"checksum"`            // optional integrity hash (e.g. SHA256)
-	SizeBytes     int64  `json:"size_bytes"`          // optional for display/use in updater
-	Signature     string `json:"signature,omitempty"` // optional detached signature (for verification)
-	SignatureType string `json:"signature_type,omitempty"`
+	ArtefactName  string   `json:"artefact_name"`
+	Platform      string   `json:"platform"` // e.g. "linux", "darwin"
+	Arch          string   `json:"arch"`     // e.g. "amd64", "arm64"
+	Variant       string   `json:"variant"`  // e.g. "webkit2_41", "standard"
+	Version       string   `json:"version"`
+	DownloadURL   string   `json:"download_url"`        // direct link to binary/archive
+	Mirrors       []string `json:"mirrors,omitempty"`   // optional fallback links, tried in order after DownloadURL
+	Checksum      string   `json:"checksum"`            // optional integrity hash (e.g. SHA256)
+	SizeBytes     int64    `json:"size_bytes"`          // optional for display/use in updater
+	Signature     string   `json:"signature,omitempty"` // optional detached signature (for verification)
+	SignatureType string   `json:"signature_type,omitempty"`
+	Source        string   `json:"source,omitempty"` // optional, the check client that found the asset
+	// Release notes and links, filled in by check clients from the release the asset belongs to
+	Changelog   string     `json:"changelog,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	ReleaseURL  string     `json:"release_url,omitempty"`
 }
 
 func (l *ReleaseAsset) WithArch(arch string) *ReleaseAsset {
@@ -43,6 +51,25 @@ func (l *ReleaseAsset) WithDownloadURL(url string) *ReleaseAsset {
 	return l
 }
 
+func (l *ReleaseAsset) WithMirrors(urls []string) *ReleaseAsset {
+	l.Mirrors = urls
+	return l
+}
+
+// DownloadURLs returns DownloadURL followed by the mirrors, skipping blanks and duplicates
+func (l *ReleaseAsset) DownloadURLs() []string {
+	urls := make([]string, 0, len(l.Mirrors)+1)
+	seen := make(map[string]bool, len(l.Mirrors)+1)
+	for _, url := range append([]string{l.DownloadURL}, l.Mirrors...) {
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+		urls = append(urls, url)
+	}
+	return urls
+}
+
 func (l *ReleaseAsset) WithChecksum(checksum string) *ReleaseAsset {
 	l.Checksum = checksum
 	return l
@@ -58,7 +85,42 @@ func (l *ReleaseAsset) WithSignature(signature string) *ReleaseAsset {
 	return l
 }
 
+func (l *ReleaseAsset) WithSource(source string) *ReleaseAsset {
+	l.Source = source
+	return l
+}
+
 func (l *ReleaseAsset) WithSignatureType(sigType string) *ReleaseAsset {
 	l.SignatureType = sigType
 	return l
 }
+
+func (l *ReleaseAsset) WithChangelog(changelog string) *ReleaseAsset {
+	l.Changelog = changelog
+	return l
+}
+
+func (l *ReleaseAsset) WithPublishedAt(publishedAt *time.Time) *ReleaseAsset {
+	l.PublishedAt = publishedAt
+	return l
+}
+
+func (l *ReleaseAsset) WithReleaseURL(url string) *ReleaseAsset {
+	l.ReleaseURL = url
+	return l
+}
+
+// WithReleaseSummary fills in release notes and links missing from the asset
+// with those of the summary it was listed in
+func (l *ReleaseAsset) WithReleaseSummary(summary ReleaseSummary) *ReleaseAsset {
+	if l.Changelog == "" {
+		l.Changelog = summary.Changelog
+	}
+	if l.PublishedAt == nil {
+		l.PublishedAt = summary.PublishedAt
+	}
+	if l.ReleaseURL == "" {
+		l.ReleaseURL = summary.ReleaseURL
+	}
+	return l
+}
@@ -2,6 +2,7 @@ package releaserdto
 
 import (
 	"context"
+	"time"
 
 	netDTO "github.com/joy-dx/gonetic/dto"
 	"github.com/joy-dx/relay/dto"
@@ -11,9 +12,29 @@ type ProcessReleasesFuncType func(ctx context.Context, config AgentCfg) error
 
 // ReleaserConfig Service configuration struct
 type ReleaserConfig struct {
-	NetSvc         netDTO.NetInterface
-	Relay          dto.RelayInterface
-	DownloadPrefix string `json:"download_prefix" yaml:"download_prefix"`
+	NetSvc netDTO.NetInterface
+	Relay  dto.RelayInterface
+	// BundlePath Optional offline bundle to write, the extension (.tar.gz, .zip) picks the format
+	BundlePath string `json:"bundle_path" yaml:"bundle_path" mapstructure:"bundle_path"`
+	// ChangelogRepoPath Optional git working tree to generate the changelog from conventional commits
+	ChangelogRepoPath string `json:"changelog_repo_path" yaml:"changelog_repo_path" mapstructure:"changelog_repo_path"`
+	// ChangelogNotesPath Optional hand-written release notes, used in place of the generated changelog
+	ChangelogNotesPath string `json:"changelog_notes_path" yaml:"changelog_notes_path" mapstructure:"changelog_notes_path"`
+	// ChangelogOutputPath Optional CHANGELOG.md to add the release notes to
+	ChangelogOutputPath string `json:"changelog_output_path" yaml:"changelog_output_path" mapstructure:"changelog_output_path"`
+	DownloadPrefix      string `json:"download_prefix" yaml:"download_prefix"`
+	// DownloadPrefixes Additional prefixes, e.g. an origin behind a CDN, used to generate mirror URLs in order
+	DownloadPrefixes []string `json:"download_prefixes" yaml:"download_prefixes" mapstructure:"download_prefixes"`
+	// HistoryMaxReleases Releases kept in the history index, 0 keeps all
+	HistoryMaxReleases int `json:"history_max_releases" yaml:"history_max_releases" mapstructure:"history_max_releases"`
+	// HistoryMaxAge Drops releases published longer ago from the history index, 0 keeps all
+	HistoryMaxAge time.Duration `json:"history_max_age" yaml:"history_max_age" mapstructure:"history_max_age"`
+	// HistoryReplace Replaces the history entry of a version already listed, kept as published otherwise
+	HistoryReplace bool `json:"history_replace" yaml:"history_replace" mapstructure:"history_replace"`
+	// OCIRepository Optional registry repository to publish to, e.g. "registry.example.com/team/app"
+	OCIRepository string `json:"oci_repository" yaml:"oci_repository" mapstructure:"oci_repository"`
+	OCIUsername   string `json:"oci_username" yaml:"oci_username" mapstructure:"oci_username"`
+	OCIPassword   string `json:"-" yaml:"-" mapstructure:"oci_password"`
 	// OutputPath FS Path where generated artefacts will be saved
 	OutputPath          string `json:"output_path" yaml:"output_path" mapstructure:"output_path"`
 	ProcessReleasesFunc ProcessReleasesFuncType
@@ -53,11 +74,36 @@ func (c *ReleaserConfig) WithAllowAnyExtension(truthy bool) *ReleaserConfig {
 	return c
 }
 
+func (c *ReleaserConfig) WithBundlePath(path string) *ReleaserConfig {
+	c.BundlePath = path
+	return c
+}
+
+func (c *ReleaserConfig) WithChangelogRepoPath(path string) *ReleaserConfig {
+	c.ChangelogRepoPath = path
+	return c
+}
+
+func (c *ReleaserConfig) WithChangelogNotesPath(path string) *ReleaserConfig {
+	c.ChangelogNotesPath = path
+	return c
+}
+
+func (c *ReleaserConfig) WithChangelogOutputPath(path string) *ReleaserConfig {
+	c.ChangelogOutputPath = path
+	return c
+}
+
 func (c *ReleaserConfig) WithDownloadPrefix(prefix string) *ReleaserConfig {
 	c.DownloadPrefix = prefix
 	return c
 }
 
+func (c *ReleaserConfig) WithDownloadPrefixes(prefixes []string) *ReleaserConfig {
+	c.DownloadPrefixes = prefixes
+	return c
+}
+
 func (c *ReleaserConfig) WithGenerateChecksums(truthy bool) *ReleaserConfig {
 	c.GenerateChecksums = truthy
 	return c
@@ -68,6 +114,32 @@ func (c *ReleaserConfig) WithGenerateSignatures(truthy bool) *ReleaserConfig {
 	return c
 }
 
+func (c *ReleaserConfig) WithHistoryMaxReleases(count int) *ReleaserConfig {
+	c.HistoryMaxReleases = count
+	return c
+}
+
+func (c *ReleaserConfig) WithHistoryMaxAge(age time.Duration) *ReleaserConfig {
+	c.HistoryMaxAge = age
+	return c
+}
+
+func (c *ReleaserConfig) WithHistoryReplace(truthy bool) *ReleaserConfig {
+	c.HistoryReplace = truthy
+	return c
+}
+
+func (c *ReleaserConfig) WithOCIRepository(repository string) *ReleaserConfig {
+	c.OCIRepository = repository
+	return c
+}
+
+func (c *ReleaserConfig) WithOCICredentials(username string, password string) *ReleaserConfig {
+	c.OCIUsername = username
+	c.OCIPassword = password
+	return c
+}
+
 func (c *ReleaserConfig) WithOutputPath(path string) *ReleaserConfig {
 	c.OutputPath = path
 	return c
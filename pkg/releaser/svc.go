@@ -54,9 +54,20 @@ func (s *ReleaserSvc) GenerateReleaseSummary(ctx context.Context) (releaserdto.R
 		return releaserdto.ReleaseSummary{}, errors.New("no releases found")
 	}
 
-	if s.cfg.DownloadPrefix != "" {
+	if s.cfg.DownloadPrefix != "" || len(s.cfg.DownloadPrefixes) > 0 {
+		prefixes := append([]string{s.cfg.DownloadPrefix}, s.cfg.DownloadPrefixes...)
 		for idx := range releasesFound {
-			releasesFound[idx].DownloadURL = s.cfg.DownloadPrefix + releasesFound[idx].ArtefactName
+			var urls []string
+			for _, prefix := range prefixes {
+				if prefix != "" {
+					urls = append(urls, prefix+releasesFound[idx].ArtefactName)
+				}
+			}
+			if len(urls) == 0 {
+				continue
+			}
+			releasesFound[idx].DownloadURL = urls[0]
+			releasesFound[idx].Mirrors = urls[1:]
 		}
 	}
 
@@ -122,9 +133,17 @@ func (s *ReleaserSvc) GenerateReleaseSummary(ctx context.Context) (releaserdto.R
 		}
 	}
 
+	changelog, err := s.GenerateChangelog(ctx)
+	if err != nil {
+		return releaserdto.ReleaseSummary{}, fmt.Errorf("problem generating changelog: %w", err)
+	}
+	s.changelog = changelog
+
 	now := time.Now()
+	s.releasedAt = &now
 	releaseSummary := releaserdto.ReleaseSummary{
 		Assets:      releasesFound,
+		Changelog:   changelog,
 		PublishedAt: &now,
 		Version:     s.cfg.Version,
 	}
@@ -148,5 +167,31 @@ func (s *ReleaserSvc) GenerateReleaseSummary(ctx context.Context) (releaserdto.R
 		}
 	}
 
+	if releaseSummary.Version != "" {
+		if _, historyErr := s.UpdateHistory(releaseSummary); historyErr != nil {
+			return releaserdto.ReleaseSummary{}, fmt.Errorf("problem writing release history: %w", historyErr)
+		}
+	} else {
+		s.relay.Warn(RlyReleaserLog{Msg: "release has no version, not recorded in the release history"})
+	}
+
+	if s.cfg.ChangelogOutputPath != "" && changelog != "" {
+		if writeErr := s.writeChangelogFile(releaseSummary.Version, now, changelog); writeErr != nil {
+			return releaserdto.ReleaseSummary{}, fmt.Errorf("problem writing changelog: %w", writeErr)
+		}
+	}
+
+	if s.cfg.BundlePath != "" {
+		if _, bundleErr := s.GenerateBundle(ctx, releaseSummary); bundleErr != nil {
+			return releaserdto.ReleaseSummary{}, fmt.Errorf("problem writing bundle: %w", bundleErr)
+		}
+	}
+
+	if s.cfg.OCIRepository != "" {
+		if _, publishErr := s.PublishOCI(ctx, releaseSummary); publishErr != nil {
+			return releaserdto.ReleaseSummary{}, fmt.Errorf("problem publishing to registry: %w", publishErr)
+		}
+	}
+
 	return releaseSummary, nil
 }
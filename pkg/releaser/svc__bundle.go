@@ -0,0 +1,103 @@
+package releaser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/joy-dx/gophorth/pkg/archive"
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+	"github.com/joy-dx/gophorth/pkg/file"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+)
+
+// GenerateBundle writes an offline bundle of the release to BundlePath. The
+// manifest is signed with the release key when signatures are enabled, so the
+// bundle can be trusted wherever it is copied to.
+func (s *ReleaserSvc) GenerateBundle(ctx context.Context, summary releaserdto.ReleaseSummary) (string, error) {
+	if s.cfg.BundlePath == "" {
+		return "", errors.New("no bundle path configured")
+	}
+	bundlePath := os.ExpandEnv(s.cfg.BundlePath)
+
+	stagingDir, err := os.MkdirTemp("", "gophorth-bundle-")
+	if err != nil {
+		return "", fmt.Errorf("bundle staging: %w", err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	manifestPath := filepath.Join(stagingDir, releaserdto.BUNDLE_MANIFEST)
+	manifest := releaserdto.BundleManifest{
+		Format:  releaserdto.BUNDLE_FORMAT_VERSION,
+		Release: summary,
+	}
+	if err := file.StructToIndentedJSONFile(manifest, manifestPath); err != nil {
+		return "", fmt.Errorf("bundle manifest: %w", err)
+	}
+	fileList := []string{manifestPath}
+
+	if s.cfg.GenerateSignatures && s.binarySigningMethod != "" {
+		signature, err := s.signFile(manifestPath)
+		if err != nil {
+			return "", fmt.Errorf("sign bundle manifest: %w", err)
+		}
+		signaturePath := filepath.Join(stagingDir, releaserdto.BUNDLE_MANIFEST_SIGNATURE)
+		if err := file.BytesToFile([]byte(signature), signaturePath); err != nil {
+			return "", fmt.Errorf("bundle manifest signature: %w", err)
+		}
+		fileList = append(fileList, signaturePath)
+	} else {
+		s.relay.Warn(RlyReleaserLog{Msg: "bundle manifest is unsigned, updaters with a public key will reject it"})
+	}
+
+	var checksumsBody []byte
+	for _, asset := range summary.Assets {
+		artefactPath := filepath.Join(os.ExpandEnv(s.cfg.TargetPath), asset.ArtefactName)
+		checksum := asset.Checksum
+		if checksum == "" {
+			if checksum, err = cryptography.Sha256SumFile(artefactPath); err != nil {
+				return "", fmt.Errorf("bundle checksums: %w", err)
+			}
+		}
+		checksumsBody = fmt.Appendf(checksumsBody, "%s  %s\n", checksum, asset.ArtefactName)
+		fileList = append(fileList, artefactPath)
+		if asset.Signature == "" {
+			continue
+		}
+		signaturePath := filepath.Join(stagingDir, asset.ArtefactName+".asc")
+		if err := file.BytesToFile([]byte(asset.Signature), signaturePath); err != nil {
+			return "", fmt.Errorf("bundle artefact signature: %w", err)
+		}
+		fileList = append(fileList, signaturePath)
+	}
+
+	checksumsPath := filepath.Join(stagingDir, releaserdto.BUNDLE_CHECKSUMS)
+	if err := file.BytesToFile(checksumsBody, checksumsPath); err != nil {
+		return "", fmt.Errorf("bundle checksums: %w", err)
+	}
+	fileList = append(fileList, checksumsPath)
+
+	compressOpts := archive.DefaultCompressOptions()
+	compressOpts.Destination = bundlePath
+	compressOpts.PreservePermissions = true
+	compressOpts.FileList = fileList
+	if err := archive.Compress(ctx, compressOpts); err != nil {
+		_ = os.Remove(bundlePath)
+		return "", fmt.Errorf("write bundle: %w", err)
+	}
+	s.relay.Info(RlyReleaserLog{Msg: fmt.Sprintf("wrote offline bundle with %d artefacts to: %s", len(summary.Assets), bundlePath)})
+	return bundlePath, nil
+}
+
+// signFile creates an ASCII armored detached signature using the loaded release key
+func (s *ReleaserSvc) signFile(path string) (string, error) {
+	switch s.binarySigningMethod {
+	case "PGP":
+		return cryptography.PGPSignFile(s.pgpEntity, path)
+	case "X509":
+		return cryptography.ECDSASignFile(s.ecdsaKey, path)
+	}
+	return "", fmt.Errorf("unsupported signing method %q", s.binarySigningMethod)
+}
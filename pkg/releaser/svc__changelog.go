@@ -0,0 +1,62 @@
+package releaser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joy-dx/gophorth/pkg/file"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserchangelog"
+)
+
+// GenerateChangelog returns the release notes: the ChangelogNotesPath file when
+// set, otherwise the conventional commits in ChangelogRepoPath since the
+// previous release tag. Empty when neither is configured.
+func (s *ReleaserSvc) GenerateChangelog(ctx context.Context) (string, error) {
+	if s.cfg.ChangelogNotesPath != "" {
+		notesPath := os.ExpandEnv(s.cfg.ChangelogNotesPath)
+		notes, err := file.ToBytes(notesPath)
+		if err != nil {
+			return "", fmt.Errorf("read release notes: %w", err)
+		}
+		s.relay.Info(RlyReleaserLog{Msg: fmt.Sprintf("using release notes from: %s", notesPath)})
+		return strings.TrimSpace(string(notes)), nil
+	}
+	if s.cfg.ChangelogRepoPath == "" {
+		return "", nil
+	}
+
+	gitOpts := releaserchangelog.DefaultGitOptions()
+	gitOpts.WithRepoPath(os.ExpandEnv(s.cfg.ChangelogRepoPath))
+	previousTag, err := releaserchangelog.PreviousTag(ctx, gitOpts, s.version)
+	if err != nil {
+		return "", err
+	}
+	commits, err := releaserchangelog.ReadCommits(ctx, gitOpts, previousTag)
+	if err != nil {
+		return "", err
+	}
+	entries := releaserchangelog.Parse(commits)
+	since := previousTag
+	if since == "" {
+		since = "the first commit"
+	}
+	s.relay.Info(RlyReleaserLog{Msg: fmt.Sprintf("generated changelog from %d conventional commits of %d since %s", len(entries), len(commits), since)})
+	return releaserchangelog.Render(entries), nil
+}
+
+// writeChangelogFile adds the release notes to the top of ChangelogOutputPath
+func (s *ReleaserSvc) writeChangelogFile(version string, releasedAt time.Time, notes string) error {
+	changelogPath := os.ExpandEnv(s.cfg.ChangelogOutputPath)
+	var existing []byte
+	if exists, _ := file.PathExists(changelogPath); exists {
+		var err error
+		if existing, err = file.ToBytes(changelogPath); err != nil {
+			return err
+		}
+	}
+	s.relay.Info(RlyReleaserLog{Msg: fmt.Sprintf("adding release notes to: %s", changelogPath)})
+	return os.WriteFile(changelogPath, []byte(releaserchangelog.PrependRelease(string(existing), version, releasedAt, notes)), 0644)
+}
@@ -0,0 +1,47 @@
+package releaser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/joy-dx/gophorth/pkg/file"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+)
+
+// UpdateHistory adds the release to the history index in OutputPath, keeping
+// the releases already listed there and pruning by HistoryMaxReleases and
+// HistoryMaxAge. A version already listed keeps its entry unless HistoryReplace
+// is set. Returns the index as written.
+func (s *ReleaserSvc) UpdateHistory(summary releaserdto.ReleaseSummary) (releaserdto.ReleaseHistory, error) {
+	var history releaserdto.ReleaseHistory
+	if summary.Version == "" {
+		return history, errors.New("release has no version to record in the history")
+	}
+	historyPath := os.ExpandEnv(s.cfg.OutputPath + "/" + releaserdto.RELEASE_HISTORY)
+	if exists, _ := file.PathExists(historyPath); exists {
+		if err := file.FileToStruct(historyPath, &history); err != nil {
+			return history, fmt.Errorf("read release history: %w", err)
+		}
+	}
+
+	replaced, addErr := history.Add(summary, s.cfg.HistoryReplace)
+	switch {
+	case errors.Is(addErr, releaserdto.ErrReleaseInHistory):
+		s.relay.Warn(RlyReleaserLog{Msg: fmt.Sprintf("release %s is already in the history, keeping the published entry. Set HistoryReplace to replace it", summary.Version)})
+	case addErr != nil:
+		return history, addErr
+	case replaced:
+		s.relay.Info(RlyReleaserLog{Msg: fmt.Sprintf("replaced release %s in the history", summary.Version)})
+	}
+	for _, dropped := range history.Prune(s.cfg.HistoryMaxReleases, s.cfg.HistoryMaxAge, time.Now()) {
+		s.relay.Info(RlyReleaserLog{Msg: fmt.Sprintf("pruned release %s from the history", dropped.Version)})
+	}
+
+	s.relay.Info(RlyReleaserLog{Msg: fmt.Sprintf("writing history of %d releases to: %s", len(history.Releases), historyPath)})
+	if err := file.StructToIndentedJSONFile(history, historyPath); err != nil {
+		return history, fmt.Errorf("write release history: %w", err)
+	}
+	return history, nil
+}
@@ -0,0 +1,73 @@
+package releaser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/joy-dx/gophorth/pkg/ociregistry"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+)
+
+// PublishOCI pushes each asset of the release, with its signature, to
+// OCIRepository as an OCI artefact and tags an index of them with the
+// release version. Returns the index digest.
+func (s *ReleaserSvc) PublishOCI(ctx context.Context, summary releaserdto.ReleaseSummary) (string, error) {
+	if s.cfg.OCIRepository == "" {
+		return "", errors.New("no oci repository configured")
+	}
+	if summary.Version == "" {
+		return "", errors.New("release has no version to tag")
+	}
+	registryCfg := ociregistry.DefaultConfig()
+	if err := registryCfg.ParseReference(os.ExpandEnv(s.cfg.OCIRepository)); err != nil {
+		return "", err
+	}
+	registryCfg.WithCredentials(s.cfg.OCIUsername, s.cfg.OCIPassword)
+	registry := ociregistry.New(registryCfg)
+
+	manifests := make([]ociregistry.Descriptor, 0, len(summary.Assets))
+	for _, asset := range summary.Assets {
+		version := asset.Version
+		if version == "" {
+			version = summary.Version
+		}
+		descriptor, err := registry.PushArtefact(ctx, ociregistry.Artefact{
+			Path:          os.ExpandEnv(s.cfg.TargetPath + "/" + asset.ArtefactName),
+			Name:          asset.ArtefactName,
+			Platform:      asset.Platform,
+			Arch:          asset.Arch,
+			Variant:       asset.Variant,
+			Version:       version,
+			Signature:     asset.Signature,
+			SignatureType: asset.SignatureType,
+		})
+		if err != nil {
+			return "", err
+		}
+		s.relay.Debug(RlyReleaserLog{Msg: fmt.Sprintf("pushed %s as %s", asset.ArtefactName, descriptor.Digest)})
+		manifests = append(manifests, descriptor)
+	}
+
+	tag := ociregistry.TagForVersion(summary.Version)
+	annotations := map[string]string{
+		ociregistry.ANNOTATION_VERSION: summary.Version,
+	}
+	if summary.PublishedAt != nil {
+		annotations[ociregistry.ANNOTATION_CREATED] = summary.PublishedAt.UTC().Format(time.RFC3339)
+	}
+	if summary.Changelog != "" {
+		annotations[ociregistry.ANNOTATION_DESCRIPTION] = summary.Changelog
+	}
+	if summary.ReleaseURL != "" {
+		annotations[ociregistry.ANNOTATION_URL] = summary.ReleaseURL
+	}
+	index, err := registry.PushIndex(ctx, tag, manifests, annotations)
+	if err != nil {
+		return "", err
+	}
+	s.relay.Info(RlyReleaserLog{Msg: fmt.Sprintf("published %d artefacts to %s:%s", len(manifests), s.cfg.OCIRepository, tag)})
+	return index.Digest, nil
+}
@@ -59,8 +59,8 @@ func (s *ReleaserSvc) ScanDir() ([]releaserdto.ReleaseAsset, error) {
 			continue
 		}
 
-		matches := re.FindStringSubmatch(name)
-		if matches == nil {
+		g, ok := stringz.MatchReverseTemplate(re, name)
+		if !ok {
 			if s.cfg.Strict {
 				return nil, fmt.Errorf("file %q does not match pattern %q", name, s.cfg.FilePattern)
 			}
@@ -72,15 +72,6 @@ func (s *ReleaserSvc) ScanDir() ([]releaserdto.ReleaseAsset, error) {
 			return nil, fmt.Errorf("stat %q: %w", name, err)
 		}
 
-		groupNames := re.SubexpNames()
-		g := make(map[string]string, len(groupNames))
-		for i, n := range groupNames {
-			if i == 0 || n == "" {
-				continue
-			}
-			g[n] = matches[i]
-		}
-
 		fullPath := filepath.Join(s.cfg.TargetPath, name)
 
 		checksum, checksumErr := cryptography.Sha256SumFile(fullPath)
@@ -93,17 +84,15 @@ func (s *ReleaserSvc) ScanDir() ([]releaserdto.ReleaseAsset, error) {
 		if s.version != nil {
 			version = s.version.String()
 		}
-		foundVersion := trimLeadingDash(g["version"])
-		if foundVersion != "" {
-			version = foundVersion
+		if g["version"] != "" {
+			version = g["version"]
 		}
 
-		variant := strings.TrimLeft(g["variant"], "/-_")
 		out = append(out, releaserdto.ReleaseAsset{
 			ArtefactName: filepath.Base(fullPath),
 			Platform:     g["platform"],
 			Arch:         g["arch"],
-			Variant:      variant,
+			Variant:      g["variant"],
 			Version:      version,
 			SizeBytes:    info.Size(),
 			Checksum:     checksum,
@@ -113,13 +102,6 @@ func (s *ReleaserSvc) ScanDir() ([]releaserdto.ReleaseAsset, error) {
 	return out, nil
 }
 
-func trimLeadingDash(s string) string {
-	if strings.HasPrefix(s, "-") {
-		return s[1:]
-	}
-	return s
-}
-
 // compileReverseTemplate turns a template string into a regex with named groups.
 // Supported placeholders: {platform}, {arch}, {variant}, {version}.
 //   - {variant} is optional and includes its leading "-" when present
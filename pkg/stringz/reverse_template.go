@@ -87,3 +87,24 @@ func CompileReverseTemplate(opts ReverseTemplateOptions) (*regexp.Regexp, error)
 	}
 	return re, nil
 }
+
+// MatchReverseTemplate applies a compiled reverse template to name, returning
+// the captured placeholders by name. Leading separators are trimmed from
+// {variant} and {version} so "-webkit241" and "-1.2.3" become "webkit241" and "1.2.3".
+func MatchReverseTemplate(re *regexp.Regexp, name string) (map[string]string, bool) {
+	matches := re.FindStringSubmatch(name)
+	if matches == nil {
+		return nil, false
+	}
+	groupNames := re.SubexpNames()
+	groups := make(map[string]string, len(groupNames))
+	for i, n := range groupNames {
+		if i == 0 || n == "" {
+			continue
+		}
+		groups[n] = matches[i]
+	}
+	groups["variant"] = strings.TrimLeft(groups["variant"], "/-_")
+	groups["version"] = strings.TrimPrefix(groups["version"], "-")
+	return groups, true
+}
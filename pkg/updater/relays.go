@@ -38,17 +38,31 @@ const RELAY_UPDATER_NEW_VERSION dto.EventRef = "updater.new_version"
 
 type RlyNewVersion struct {
 	ReleasedAt *time.Time `json:"released_at"`
+	ReleaseURL string     `json:"release_url"`
+	Source     string     `json:"source"`
 	Version    string     `json:"version"`
 }
 
 func (e RlyNewVersion) ToSlog() []slog.Attr {
-	return []slog.Attr{
-		slog.String("released_at", e.ReleasedAt.Format(time.RFC3339)),
+	attrs := []slog.Attr{
 		slog.String("version", e.Version),
 	}
+	if e.ReleasedAt != nil {
+		attrs = append(attrs, slog.String("released_at", e.ReleasedAt.Format(time.RFC3339)))
+	}
+	if e.ReleaseURL != "" {
+		attrs = append(attrs, slog.String("release_url", e.ReleaseURL))
+	}
+	if e.Source != "" {
+		attrs = append(attrs, slog.String("source", e.Source))
+	}
+	return attrs
 }
 
 func (e RlyNewVersion) Message() string {
+	if e.ReleasedAt == nil {
+		return fmt.Sprintf("app version %s available", e.Version)
+	}
 	return fmt.Sprintf("app version %s available, released on %s", e.Version, e.ReleasedAt.Format(time.RFC3339))
 }
 
@@ -59,3 +73,38 @@ func (e RlyNewVersion) RelayChannel() dto.EventChannel {
 func (e RlyNewVersion) RelayType() dto.EventRef {
 	return RELAY_UPDATER_NEW_VERSION
 }
+
+const RELAY_UPDATER_DOWNLOAD_PROGRESS dto.EventRef = "updater.download_progress"
+
+type RlyDownloadProgress struct {
+	URL            string  `json:"url"`
+	Downloaded     int64   `json:"downloaded"`
+	Total          int64   `json:"total"`
+	BytesPerSecond float64 `json:"bytes_per_second"`
+	Paused         bool    `json:"paused"`
+}
+
+func (e RlyDownloadProgress) ToSlog() []slog.Attr {
+	return []slog.Attr{
+		slog.String("url", e.URL),
+		slog.Int64("downloaded", e.Downloaded),
+		slog.Int64("total", e.Total),
+		slog.Float64("bytes_per_second", e.BytesPerSecond),
+		slog.Bool("paused", e.Paused),
+	}
+}
+
+func (e RlyDownloadProgress) Message() string {
+	if e.Paused {
+		return fmt.Sprintf("download paused at %d bytes: %s", e.Downloaded, e.URL)
+	}
+	return fmt.Sprintf("downloaded %d of %d bytes at %.0f B/s: %s", e.Downloaded, e.Total, e.BytesPerSecond, e.URL)
+}
+
+func (e RlyDownloadProgress) RelayChannel() dto.EventChannel {
+	return RELAY_UPDATER_CHANNEL
+}
+
+func (e RlyDownloadProgress) RelayType() dto.EventRef {
+	return RELAY_UPDATER_DOWNLOAD_PROGRESS
+}
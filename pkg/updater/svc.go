@@ -1,7 +1,6 @@
 package updater
 
 import (
-	"bytes"
 	"context"
 	"crypto/ecdsa"
 	"errors"
@@ -10,14 +9,14 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/ProtonMail/go-crypto/openpgp"
 	netDTO "github.com/joy-dx/gonetic/dto"
-	"github.com/joy-dx/gophorth/pkg/cryptography"
 	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
-	"github.com/joy-dx/gophorth/pkg/updater/updatercopier"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdownload"
 	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
 	"github.com/joy-dx/relay/dto"
 )
@@ -26,6 +25,8 @@ type UpdaterSvc struct {
 	relay  dto.RelayInterface
 	netSvc netDTO.NetInterface
 	cfg    *updaterdto.UpdaterConfig
+	// mu Guards the fields State reports, as jobs write them in the background
+	mu     sync.RWMutex
 	status updaterdto.UpdateStatus
 	// State information to be populated about possible update
 	updateLog     string
@@ -37,20 +38,68 @@ type UpdaterSvc struct {
 	releaseURL    string
 	version       *semver.Version
 	contextUpdate *releaserdto.ReleaseAsset
+	migrations    []updaterdto.MigrationResult
+	// releaseNotes Notes of every version between the running one and the update, newest first
+	releaseNotes      []updaterdto.ReleaseNote
+	combinedChangelog string
+	// downloadSource URL the current artefact was downloaded from
+	downloadSource string
+	engine         *updaterdownload.Engine
+	// engineOnce Creates engine on first use when the service was built without one
+	engineOnce sync.Once
+	// skippedVersions Versions the user chose not to install
+	skippedVersions []string
 }
 
 func (s *UpdaterSvc) CheckLatest(ctx context.Context) (releaserdto.ReleaseAsset, error) {
+	result, err := s.checkRemote(ctx)
+	if err != nil {
+		return releaserdto.ReleaseAsset{}, err
+	}
+	remoteUpdate := result.update
+
+	s.mu.Lock()
+	s.changelog = remoteUpdate.Changelog
+	s.releasedAt = remoteUpdate.PublishedAt
+	s.releaseURL = remoteUpdate.ReleaseURL
+	s.releaseNotes = result.releaseNotes
+	s.combinedChangelog = formatReleaseNotes(result.releaseNotes)
+	s.status = result.status
+	s.contextUpdate = &remoteUpdate
+	s.mu.Unlock()
+
+	if result.status == updaterdto.UPDATE_AVAILABLE {
+		s.relay.Info(RlyNewVersion{
+			ReleasedAt: remoteUpdate.PublishedAt,
+			ReleaseURL: remoteUpdate.ReleaseURL,
+			Source:     remoteUpdate.Source,
+			Version:    result.version.String(),
+		})
+	}
+	return remoteUpdate, nil
+}
+
+// checkResult What checkRemote found, for CheckLatest to apply
+type checkResult struct {
+	update       releaserdto.ReleaseAsset
+	version      *semver.Version
+	status       updaterdto.UpdateStatus
+	releaseNotes []updaterdto.ReleaseNote
+}
 
-	if s.status == updaterdto.INOPERATIVE {
-		return releaserdto.ReleaseAsset{}, errors.New("update service is inoperative, check startup logs for more information")
+// checkRemote asks the check client for the latest release and works out the
+// resulting status without changing any service state
+func (s *UpdaterSvc) checkRemote(ctx context.Context) (checkResult, error) {
+	if s.Status() == updaterdto.INOPERATIVE {
+		return checkResult{}, errors.New("update service is inoperative, check startup logs for more information")
 	}
 
 	if s.cfg.CheckClient == nil {
-		return releaserdto.ReleaseAsset{}, errors.New("no check client configured")
+		return checkResult{}, errors.New("no check client configured")
 	}
 	remoteUpdate, err := s.cfg.CheckClient.CheckUpdate(ctx, s.cfg)
 	if err != nil {
-		return releaserdto.ReleaseAsset{}, fmt.Errorf("check client: %w", err)
+		return checkResult{}, fmt.Errorf("check client: %w", err)
 	}
 
 	remoteSemVer, err := semver.NewVersion(remoteUpdate.Version)
@@ -58,20 +107,50 @@ func (s *UpdaterSvc) CheckLatest(ctx context.Context) (releaserdto.ReleaseAsset,
 		log.Fatal(fmt.Errorf("problem parsing latest version: %w", err))
 	}
 	s.relay.Debug(RlyUpdaterLog{Msg: fmt.Sprintf("current version / remote version: %s / %s", s.version, remoteSemVer.String())})
-	if remoteSemVer.GreaterThan(s.version) {
-		s.status = updaterdto.UPDATE_AVAILABLE
-	} else {
-		s.status = updaterdto.UP_TO_DATE
+	result := checkResult{update: remoteUpdate, version: remoteSemVer, status: updaterdto.UP_TO_DATE}
+	if remoteSemVer.GreaterThan(s.version) && s.isSkipped(remoteSemVer) {
+		s.relay.Debug(RlyUpdaterLog{Msg: fmt.Sprintf("version %s was skipped", remoteSemVer.String())})
+	} else if remoteSemVer.GreaterThan(s.version) {
+		result.status = updaterdto.UPDATE_AVAILABLE
+		result.releaseNotes = s.collectReleaseNotes(ctx, remoteUpdate, remoteSemVer)
 	}
-
-	s.contextUpdate = &remoteUpdate
-	return remoteUpdate, nil
+	return result, nil
 }
 
 func (s *UpdaterSvc) DownloadUpdate(ctx context.Context, link *releaserdto.ReleaseAsset) error {
 	if link != nil {
+		s.mu.Lock()
 		s.contextUpdate = link
+		s.mu.Unlock()
+	}
+
+	updaterdto.ReportJobStage(ctx, updaterdto.JOB_DOWNLOADING)
+	downloadDestination, err := s.fetchArtefact(ctx)
+	if err != nil {
+		return err
 	}
+	updaterdto.ReportJobStage(ctx, updaterdto.JOB_VERIFYING)
+
+	_, signatureOutcome, err := s.verifySignature(downloadDestination)
+	if err != nil {
+		return err
+	}
+	s.relay.Debug(RlyUpdaterLog{Msg: signatureOutcome})
+
+	if _, err := s.runVerifiers(downloadDestination); err != nil {
+		return err
+	}
+	s.setStatus(updaterdto.DOWNLOADED)
+	return nil
+}
+
+// fetchArtefact downloads the context update in to the temporary path and
+// returns where it was written
+func (s *UpdaterSvc) fetchArtefact(ctx context.Context) (string, error) {
+	if s.contextUpdate == nil {
+		return "", errors.New("no update selected, run CheckLatest first")
+	}
+	s.setDownloadSource("")
 
 	var downloadDestination string
 	if s.cfg.DownloadFunc != nil {
@@ -82,65 +161,86 @@ func (s *UpdaterSvc) DownloadUpdate(ctx context.Context, link *releaserdto.Relea
 		}
 		downloadPath, downloadErr := s.cfg.DownloadFunc(ctx, &agentConfig)
 		if downloadErr != nil {
-			return downloadErr
+			return "", downloadErr
 		}
 		downloadDestination = downloadPath
 
 	} else {
-		if s.contextUpdate.DownloadURL == "" {
-			return errors.New("no download url configured")
-		}
-		downloadCfg := netDTO.DownloadFileConfig{
-			Blocking:          true,
-			Checksum:          s.contextUpdate.Checksum,
-			URL:               s.contextUpdate.DownloadURL,
-			DestinationFolder: s.cfg.TemporaryPath,
+		if signer, ok := s.cfg.CheckClient.(updaterdto.DownloadURLSignerInterface); ok {
+			// Sign a copy, State may be reading the update meanwhile
+			signed := *s.contextUpdate
+			if signErr := signer.SignDownloadURLs(ctx, &signed); signErr != nil {
+				return "", fmt.Errorf("sign download urls: %w", signErr)
+			}
+			s.mu.Lock()
+			s.contextUpdate = &signed
+			s.mu.Unlock()
 		}
-		downloadPath, downloadErr := s.netSvc.DownloadFile(ctx, &downloadCfg)
+		downloadPath, downloadErr := s.downloadWithFailover(ctx)
 		if downloadErr != nil {
-			return downloadErr
+			return "", downloadErr
 		}
 		downloadDestination = downloadPath
 	}
+	s.mu.Lock()
 	s.contextUpdate.WithArtefactName(downloadDestination)
+	s.mu.Unlock()
 
 	// Ensure the download is executable
 	if modErr := os.Chmod(downloadDestination, 0770); modErr != nil {
-		return modErr
+		return "", modErr
 	}
+	return downloadDestination, nil
+}
 
-	if s.contextUpdate.Signature != "" {
-		keyInfo, err := cryptography.DetectSignatureInformation([]byte(s.contextUpdate.Signature))
-		if err != nil {
-			return fmt.Errorf("could not detect key information from link signature: %w", err)
-		}
-		switch keyInfo.Format {
-		case "PGP":
-			if s.pgpEntity == nil {
-				s.relay.Debug(RlyUpdaterLog{Msg: "pgp signature provided but no local handler"})
-			} else {
-				signatureAsBuffer := bytes.NewBufferString(s.contextUpdate.Signature)
-				if verifyErr := cryptography.PGPVerifyFile(s.pgpEntity, downloadDestination, *signatureAsBuffer); verifyErr != nil {
-					return fmt.Errorf("could not verify signature: %w", verifyErr)
-				}
-			}
-		case "X509":
-			if s.ecdsaKey == nil {
-				s.relay.Debug(RlyUpdaterLog{Msg: "X509 signature provided but no local handler"})
-			} else {
-				//(s.pgpEntity, downloadDestination, *signatureAsBuffer)
-				if verifyErr := cryptography.ECDSAVerifyFile(s.ecdsaKey, downloadDestination, s.contextUpdate.Signature); verifyErr != nil {
-					return fmt.Errorf("could not verify signature: %w", verifyErr)
-				}
-			}
+func (s *UpdaterSvc) PerformUpdate(ctx context.Context) error {
+	s.setStatus(updaterdto.IN_PROGRESS)
+	updaterdto.ReportJobStage(ctx, updaterdto.JOB_INSTALLING)
+	if err := s.prepareArtefact(ctx); err != nil {
+		return err
+	}
+	// Last point an update can be abandoned, the helper cannot be stopped once started
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.cfg.KeepPreviousVersion {
+		if err := s.keepPreviousVersion(); err != nil {
+			return err
 		}
 	}
-	s.status = updaterdto.DOWNLOADED
+	return s.applyArtefact(ctx)
+}
+
+// applyArtefact hands the prepared artefact to the update helper, or replaces
+// the app in-process when no helper exists and the fallback is enabled
+func (s *UpdaterSvc) applyArtefact(ctx context.Context) error {
+	// Get the helper ready and validate everything is ready before proceeding
+	helperPath, err := s.resolveHelper(ctx)
+	if errors.Is(err, updaterdto.ErrNoHelper) && s.cfg.InProcessFallback {
+		s.relay.Info(RlyUpdaterLog{Msg: "no update helper available, replacing in-process"})
+		return s.performInProcessUpdate()
+	}
+	if err != nil {
+		return err
+	}
+	s.relay.Info(RlyUpdaterLog{Msg: fmt.Sprintf("using helper: %s", helperPath)})
+
+	s.relay.Info(RlyUpdaterLog{Msg: fmt.Sprintf("starting update. replacing %s with %s", s.updateTarget, s.contextUpdate.ArtefactName)})
+	cmd := exec.Command(helperPath, s.updateTarget, s.contextUpdate.ArtefactName, s.cfg.LogPath)
+	cmd.Dir = filepath.Dir(s.cfg.TemporaryPath)
+	if startErr := cmd.Start(); startErr != nil {
+		return fmt.Errorf("couldn't start update helper: %w", startErr)
+	}
+
 	return nil
 }
 
-func (s *UpdaterSvc) PerformUpdate(ctx context.Context) error {
-	s.status = updaterdto.IN_PROGRESS
+// prepareArtefact runs the optional PrepareFunc, e.g. unpacking an archive, and
+// checks an artefact is ready to be swapped in
+func (s *UpdaterSvc) prepareArtefact(ctx context.Context) error {
+	if s.contextUpdate == nil {
+		return errors.New("no update selected, run CheckLatest first")
+	}
 	if s.cfg.PrepareFunc != nil {
 		updaterAgent := updaterdto.UpdaterAgentCfg{
 			NetSvc:        s.netSvc,
@@ -155,20 +255,5 @@ func (s *UpdaterSvc) PerformUpdate(ctx context.Context) error {
 	if s.contextUpdate.ArtefactName == "" {
 		return errors.New("no artefact path configured")
 	}
-
-	// Get the helper ready and validate everything is ready before proceeding
-	helperPath, err := updatercopier.ExtractHelper(s.cfg.TemporaryPath)
-	if err != nil {
-		return err
-	}
-	s.relay.Info(RlyUpdaterLog{Msg: fmt.Sprintf("extracted helper to: %s", helperPath)})
-
-	s.relay.Info(RlyUpdaterLog{Msg: fmt.Sprintf("starting update. replacing %s with %s", s.updateTarget, s.contextUpdate.ArtefactName)})
-	cmd := exec.Command(helperPath, s.updateTarget, s.contextUpdate.ArtefactName, s.cfg.LogPath)
-	cmd.Dir = filepath.Dir(s.cfg.TemporaryPath)
-	if startErr := cmd.Start(); startErr != nil {
-		return fmt.Errorf("couldn't start update helper: %w", startErr)
-	}
-
 	return nil
 }
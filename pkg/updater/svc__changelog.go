@@ -0,0 +1,95 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+// collectReleaseNotes gathers the notes of every release after the running
+// version up to latest, when the check client can list past releases. Without
+// a history only the notes of latest itself are kept.
+func (s *UpdaterSvc) collectReleaseNotes(ctx context.Context, latest releaserdto.ReleaseAsset, latestVersion *semver.Version) []updaterdto.ReleaseNote {
+	notes := []updaterdto.ReleaseNote{{
+		Version:     latest.Version,
+		PublishedAt: latest.PublishedAt,
+		Changelog:   latest.Changelog,
+		ReleaseURL:  latest.ReleaseURL,
+	}}
+	if history, ok := s.cfg.CheckClient.(updaterdto.ReleaseHistoryInterface); ok {
+		past, err := history.ReleaseHistory(ctx, s.cfg)
+		if err != nil {
+			s.relay.Debug(RlyUpdaterLog{Msg: fmt.Sprintf("could not list past releases, only showing %s notes: %s", latest.Version, err.Error())})
+		} else {
+			notes = append(notes, past...)
+		}
+	}
+	return skippedReleaseNotes(notes, s.version, latestVersion, s.cfg.AllowPrerelease)
+}
+
+// skippedReleaseNotes keeps the notes of versions after current up to and
+// including target, newest first. Prereleases other than target are dropped
+// unless allowed, and the first note listed for a version wins.
+func skippedReleaseNotes(notes []updaterdto.ReleaseNote, current *semver.Version, target *semver.Version, allowPrerelease bool) []updaterdto.ReleaseNote {
+	type versionedNote struct {
+		note    updaterdto.ReleaseNote
+		version *semver.Version
+	}
+	seen := make(map[string]bool, len(notes))
+	kept := make([]versionedNote, 0, len(notes))
+	for _, note := range notes {
+		version, err := semver.NewVersion(note.Version)
+		if err != nil {
+			continue
+		}
+		if current != nil && !version.GreaterThan(current) {
+			continue
+		}
+		if version.GreaterThan(target) {
+			continue
+		}
+		if version.Prerelease() != "" && !allowPrerelease && !version.Equal(target) {
+			continue
+		}
+		if seen[version.String()] {
+			continue
+		}
+		seen[version.String()] = true
+		kept = append(kept, versionedNote{note: note, version: version})
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].version.GreaterThan(kept[j].version)
+	})
+
+	skipped := make([]updaterdto.ReleaseNote, 0, len(kept))
+	for _, candidate := range kept {
+		skipped = append(skipped, candidate.note)
+	}
+	return skipped
+}
+
+// formatReleaseNotes joins notes in to one markdown changelog with a heading,
+// and the release date when known, per version
+func formatReleaseNotes(notes []updaterdto.ReleaseNote) string {
+	var b strings.Builder
+	for idx, note := range notes {
+		if idx > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString("## " + note.Version)
+		if note.PublishedAt != nil {
+			b.WriteString(" (" + note.PublishedAt.Format("2006-01-02") + ")")
+		}
+		changelog := strings.TrimSpace(note.Changelog)
+		if changelog == "" {
+			changelog = "No release notes."
+		}
+		b.WriteString("\n\n" + changelog)
+	}
+	return b.String()
+}
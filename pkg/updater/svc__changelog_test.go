@@ -0,0 +1,55 @@
+package updater
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+func TestSkippedReleaseNotes(t *testing.T) {
+	published := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	notes := []updaterdto.ReleaseNote{
+		{Version: "v1.9.0", Changelog: "latest notes", PublishedAt: &published},
+		{Version: "1.9.0", Changelog: "history copy"},
+		{Version: "1.2.0", Changelog: "running"},
+		{Version: "1.5.0", Changelog: "middle"},
+		{Version: "1.8.0-rc.1", Changelog: "candidate"},
+		{Version: "2.0.0", Changelog: "beyond target"},
+		{Version: "1.1.0", Changelog: "older"},
+		{Version: "nightly", Changelog: "not semver"},
+	}
+
+	tests := []struct {
+		name            string
+		current         string
+		target          string
+		allowPrerelease bool
+		wantVersions    []string
+	}{
+		{name: "skipped stable versions", current: "1.2.0", target: "1.9.0", wantVersions: []string{"v1.9.0", "1.5.0"}},
+		{name: "prereleases allowed", current: "1.2.0", target: "1.9.0", allowPrerelease: true, wantVersions: []string{"v1.9.0", "1.8.0-rc.1", "1.5.0"}},
+		{name: "prerelease target kept", current: "1.5.0", target: "1.8.0-rc.1", wantVersions: []string{"1.8.0-rc.1"}},
+		{name: "adjacent versions", current: "1.5.0", target: "1.9.0", wantVersions: []string{"v1.9.0"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := skippedReleaseNotes(notes, semver.MustParse(tc.current), semver.MustParse(tc.target), tc.allowPrerelease)
+			var versions []string
+			for _, note := range got {
+				versions = append(versions, note.Version)
+			}
+			if !reflect.DeepEqual(versions, tc.wantVersions) {
+				t.Fatalf("versions=%v want %v", versions, tc.wantVersions)
+			}
+		})
+	}
+
+	combined := formatReleaseNotes(skippedReleaseNotes(notes, semver.MustParse("1.2.0"), semver.MustParse("1.9.0"), false))
+	want := "## v1.9.0 (2026-03-04)\n\nlatest notes\n\n## 1.5.0\n\nmiddle"
+	if combined != want {
+		t.Fatalf("combined changelog:\n%s\nwant:\n%s", combined, want)
+	}
+}
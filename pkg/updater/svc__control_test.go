@@ -0,0 +1,122 @@
+package updater
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updatercontrol"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/relay"
+)
+
+// TestUpdaterSvc_ControlServer Drives the updater over the control API while a
+// download job runs, run with -race to catch state left unguarded
+func TestUpdaterSvc_ControlServer(t *testing.T) {
+	artefact := make([]byte, 64<<10)
+	sum := sha256.Sum256(artefact)
+	release := make(chan struct{})
+	artefactServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(artefact)))
+		_, _ = w.Write(artefact[:len(artefact)/2])
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+			_, _ = w.Write(artefact[len(artefact)/2:])
+		case <-r.Context().Done():
+		}
+	}))
+	defer artefactServer.Close()
+
+	cfg := updaterdto.DefaultUpdaterSvcConfig()
+	cfg.WithTemporaryPath(t.TempDir()).
+		WithDownloadEngine(true).
+		WithCheckClient(fakeCheckClient{asset: releaserdto.ReleaseAsset{
+			Version:     "2.0.0",
+			DownloadURL: artefactServer.URL + "/app-example",
+			Checksum:    hex.EncodeToString(sum[:]),
+		}})
+	svc := &UpdaterSvc{
+		cfg:     &cfg,
+		relay:   &relay.RelaySvc{},
+		status:  updaterdto.INITIAL,
+		version: semver.MustParse("1.0.0"),
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "updater.sock")
+	serverCfg := updatercontrol.DefaultServerConfig()
+	serverCfg.WithSocketPath(socketPath)
+	server := updatercontrol.NewServer(serverCfg, svc)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = server.ListenAndServe(ctx)
+	}()
+	client := updatercontrol.NewClient(socketPath)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := client.State(ctx); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("control server never came up")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := client.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Download(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The job holds the pending update, nothing may swap it out meanwhile
+	if _, err := client.Check(ctx); !errors.Is(err, updatercontrol.ErrJobRunning) {
+		t.Fatalf("check err=%v want %v", err, updatercontrol.ErrJobRunning)
+	}
+	if _, err := client.Skip(ctx, ""); !errors.Is(err, updatercontrol.ErrJobRunning) {
+		t.Fatalf("skip err=%v want %v", err, updatercontrol.ErrJobRunning)
+	}
+	if _, err := client.Rollback(ctx); !errors.Is(err, updatercontrol.ErrJobRunning) {
+		t.Fatalf("rollback err=%v want %v", err, updatercontrol.ErrJobRunning)
+	}
+	for i := 0; i < 20; i++ {
+		if _, err := client.State(ctx); err != nil {
+			t.Fatal(err)
+		}
+		_ = svc.State()
+	}
+	close(release)
+
+	deadline = time.Now().Add(10 * time.Second)
+	for {
+		progress, err := client.Job(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if progress.Stage == updaterdto.JOB_COMPLETE {
+			break
+		}
+		if progress.Stage == updaterdto.JOB_FAILED || time.Now().After(deadline) {
+			t.Fatalf("download job stage=%s error=%s", progress.Stage, progress.Error)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	state, err := client.State(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status != updaterdto.DOWNLOADED || state.UpdateLink == nil || state.UpdateLink.ArtefactName == "" {
+		t.Fatalf("status=%s link=%+v", state.Status, state.UpdateLink)
+	}
+}
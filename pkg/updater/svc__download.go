@@ -0,0 +1,251 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	netDTO "github.com/joy-dx/gonetic/dto"
+	"github.com/joy-dx/gonetic/utils"
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+	"github.com/joy-dx/gophorth/pkg/updater/updatercache"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdownload"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+// DownloadSource returns the URL, out of DownloadURL and the mirrors, the
+// current artefact was downloaded from
+func (s *UpdaterSvc) DownloadSource() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.downloadSource
+}
+
+func (s *UpdaterSvc) setDownloadSource(source string) {
+	s.mu.Lock()
+	s.downloadSource = source
+	s.mu.Unlock()
+}
+
+// downloadWithFailover tries DownloadURL then each mirror in order, moving on
+// after network errors or checksum mismatches. Every source is written to the
+// same file name, taken from the first URL, so later steps see one artefact.
+func (s *UpdaterSvc) downloadWithFailover(ctx context.Context) (string, error) {
+	urls := s.contextUpdate.DownloadURLs()
+	if len(urls) == 0 {
+		return "", errors.New("no download url configured")
+	}
+	outputFileName, err := utils.FilenameFromUrl(urls[0])
+	if err != nil {
+		return "", fmt.Errorf("derive artefact name: %w", err)
+	}
+
+	destination := filepath.Join(s.cfg.TemporaryPath, outputFileName)
+
+	artefactCache := s.artefactCache()
+	if artefactCache != nil {
+		hit, cacheErr := artefactCache.Get(s.contextUpdate.Checksum, destination)
+		if cacheErr != nil {
+			s.relay.Warn(RlyUpdaterLog{Msg: fmt.Sprintf("download cache unavailable: %s", cacheErr.Error())})
+		} else if hit {
+			// artefactCache only returns a cache for checksums that make a valid key
+			key, _ := updatercache.Key(s.contextUpdate.Checksum)
+			cachedPath := artefactCache.Path(key)
+			s.setDownloadSource(cachedPath)
+			s.relay.Info(RlyUpdaterLog{Msg: fmt.Sprintf("using cached artefact: %s", cachedPath)})
+			return destination, nil
+		}
+	}
+
+	var downloadErrs []error
+	for idx, url := range urls {
+		downloadPath, downloadErr := s.downloadFrom(ctx, url, outputFileName)
+		if downloadErr == nil {
+			if artefactCache != nil {
+				if cacheErr := artefactCache.Put(s.contextUpdate.Checksum, downloadPath); cacheErr != nil {
+					s.relay.Warn(RlyUpdaterLog{Msg: fmt.Sprintf("could not cache artefact: %s", cacheErr.Error())})
+				}
+			}
+			s.setDownloadSource(url)
+			if idx > 0 {
+				s.relay.Info(RlyUpdaterLog{Msg: fmt.Sprintf("downloaded from mirror %d: %s", idx, url)})
+			} else {
+				s.relay.Debug(RlyUpdaterLog{Msg: fmt.Sprintf("downloaded from %s", url)})
+			}
+			return downloadPath, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
+		downloadErrs = append(downloadErrs, fmt.Errorf("%s: %w", url, downloadErr))
+		// Never leave a corrupt or partial artefact for the next source to be
+		// confused with, or for the download engine to resume from
+		_ = os.Remove(destination)
+		_ = os.Remove(updaterdownload.PartPath(destination))
+		_ = os.Remove(updaterdownload.ValidatorPath(destination))
+		if idx < len(urls)-1 {
+			s.relay.Warn(RlyUpdaterLog{Msg: fmt.Sprintf("download from %s failed, trying next mirror: %s", url, downloadErr.Error())})
+		}
+	}
+	return "", fmt.Errorf("all download sources failed: %w", errors.Join(downloadErrs...))
+}
+
+// downloadFrom fetches a single source in to the temporary path, through the
+// download engine when enabled or segmented downloads are requested
+func (s *UpdaterSvc) downloadFrom(ctx context.Context, url string, outputFileName string) (string, error) {
+	if strings.HasPrefix(url, "file://") {
+		return s.copyLocalArtefact(url, outputFileName)
+	}
+	header := s.downloadHeaders(url)
+	// The net service cannot send headers for a single download, so
+	// authenticated downloads always go through the engine
+	if !s.cfg.DownloadEngine && s.cfg.DownloadSegments <= 1 && len(header) == 0 {
+		downloadCfg := netDTO.DownloadFileConfig{
+			Blocking:          true,
+			Checksum:          s.contextUpdate.Checksum,
+			URL:               url,
+			DestinationFolder: s.cfg.TemporaryPath,
+			OutputFileName:    outputFileName,
+		}
+		return s.netSvc.DownloadFile(ctx, &downloadCfg)
+	}
+
+	request := updaterdownload.Request{
+		URL:         url,
+		Destination: filepath.Join(s.cfg.TemporaryPath, outputFileName),
+		Checksum:    s.contextUpdate.Checksum,
+		Header:      header,
+	}
+	err := s.downloadEngine().Download(ctx, request, func(progress updaterdownload.Progress) {
+		updaterdto.ReportJobDownload(ctx, progress.Downloaded, progress.Total, progress.BytesPerSecond)
+		s.relay.Info(RlyDownloadProgress{
+			URL:            progress.URL,
+			Downloaded:     progress.Downloaded,
+			Total:          progress.Total,
+			BytesPerSecond: progress.BytesPerSecond,
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+	return request.Destination, nil
+}
+
+// downloadHeaders returns what the check client wants sent when downloading
+// from url, e.g. the token of a private release
+func (s *UpdaterSvc) downloadHeaders(url string) http.Header {
+	if authoriser, ok := s.cfg.CheckClient.(updaterdto.DownloadHeadersInterface); ok {
+		return authoriser.DownloadHeaders(url)
+	}
+	return nil
+}
+
+// copyLocalArtefact copies a file:// source, e.g. from an offline bundle, in to
+// the temporary path, verifying the checksum as a network download would
+func (s *UpdaterSvc) copyLocalArtefact(rawURL string, outputFileName string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("parse local artefact url: %w", err)
+	}
+	sourcePath := filepath.FromSlash(parsed.Path)
+	if filepath.VolumeName(strings.TrimPrefix(parsed.Path, "/")) != "" {
+		// file:///C:/path on Windows
+		sourcePath = filepath.FromSlash(strings.TrimPrefix(parsed.Path, "/"))
+	}
+
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return "", fmt.Errorf("open local artefact: %w", err)
+	}
+	defer source.Close()
+
+	if err := os.MkdirAll(s.cfg.TemporaryPath, 0755); err != nil {
+		return "", err
+	}
+	destinationPath := filepath.Join(s.cfg.TemporaryPath, outputFileName)
+	destination, err := os.Create(destinationPath)
+	if err != nil {
+		return "", fmt.Errorf("create artefact: %w", err)
+	}
+	_, copyErr := io.Copy(destination, source)
+	if closeErr := destination.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr != nil {
+		return "", fmt.Errorf("copy local artefact: %w", copyErr)
+	}
+
+	if s.contextUpdate.Checksum != "" {
+		if err := cryptography.Sha256SumVerify(destinationPath, strings.ToLower(s.contextUpdate.Checksum)); err != nil {
+			return "", fmt.Errorf("local artefact: %w", err)
+		}
+	}
+	return destinationPath, nil
+}
+
+// SetDownloadRateLimit changes the download engine limit, including for a
+// download in progress. 0 removes the limit.
+func (s *UpdaterSvc) SetDownloadRateLimit(bytesPerSecond int64) {
+	s.cfg.WithDownloadRateLimit(bytesPerSecond)
+	s.downloadEngine().SetRateLimit(bytesPerSecond)
+}
+
+// PauseDownload stops the download engine reading from the network until ResumeDownload
+func (s *UpdaterSvc) PauseDownload() {
+	s.downloadEngine().Pause()
+	s.relay.Info(RlyDownloadProgress{URL: s.downloadURL(), Paused: true})
+}
+
+func (s *UpdaterSvc) ResumeDownload() {
+	s.downloadEngine().Resume()
+	s.relay.Debug(RlyUpdaterLog{Msg: "download resumed"})
+}
+
+func (s *UpdaterSvc) DownloadPaused() bool {
+	return s.downloadEngine().Paused()
+}
+
+func (s *UpdaterSvc) downloadEngine() *updaterdownload.Engine {
+	// Pause and rate limit calls can race the download job for the first use
+	s.engineOnce.Do(func() {
+		if s.engine == nil {
+			s.engine = newDownloadEngine(s.cfg)
+		}
+	})
+	return s.engine
+}
+
+func (s *UpdaterSvc) downloadURL() string {
+	if s.contextUpdate == nil {
+		return ""
+	}
+	return s.contextUpdate.DownloadURL
+}
+
+// artefactCache returns the shared download cache, nil when disabled or the
+// release publishes no checksum to key it by
+func (s *UpdaterSvc) artefactCache() *updatercache.Cache {
+	if s.cfg.CacheDir == "" || s.contextUpdate.Checksum == "" {
+		return nil
+	}
+	if _, err := updatercache.Key(s.contextUpdate.Checksum); err != nil {
+		return nil
+	}
+	return updatercache.New(s.cfg.CacheDir, s.cfg.CacheMaxBytes)
+}
+
+func newDownloadEngine(cfg *updaterdto.UpdaterConfig) *updaterdownload.Engine {
+	engineCfg := updaterdownload.DefaultEngineConfig()
+	engineCfg.WithRateLimit(cfg.DownloadRateLimit)
+	if cfg.DownloadSegments > 1 {
+		engineCfg.WithSegments(cfg.DownloadSegments)
+	}
+	return updaterdownload.New(engineCfg)
+}
@@ -0,0 +1,303 @@
+package updater
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/joy-dx/gonetic"
+	"github.com/joy-dx/gonetic/config"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdownload"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/relay"
+)
+
+func TestUpdaterSvc_DownloadWithFailover(t *testing.T) {
+	artefact := []byte("v2")
+	sum := sha256.Sum256(artefact)
+	checksum := hex.EncodeToString(sum[:])
+
+	serve := func(body []byte, status int) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			_, _ = w.Write(body)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	healthy := serve(artefact, http.StatusOK)
+	unavailable := serve(nil, http.StatusServiceUnavailable)
+	corrupt := serve([]byte("tampered"), http.StatusOK)
+	offline := serve(nil, http.StatusOK)
+	offline.Close()
+	// Drops the connection part way, leaving a partial download behind
+	truncated := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "64")
+		_, _ = w.Write([]byte("tamp"))
+	}))
+	t.Cleanup(truncated.Close)
+
+	netCfg := config.DefaultNetSvcConfig()
+	netCfg.WithRelay(&relay.RelaySvc{})
+	netSvc := gonetic.ProvideNetSvc(&netCfg)
+
+	tests := []struct {
+		name       string
+		primary    string
+		mirrors    []string
+		wantSource string
+		wantErr    bool
+	}{
+		{
+			name:       "primary healthy",
+			primary:    healthy.URL,
+			mirrors:    []string{unavailable.URL},
+			wantSource: healthy.URL,
+		},
+		{
+			name:       "fails over on network error",
+			primary:    offline.URL,
+			mirrors:    []string{healthy.URL},
+			wantSource: healthy.URL,
+		},
+		{
+			name:       "fails over on bad status and checksum mismatch",
+			primary:    unavailable.URL,
+			mirrors:    []string{corrupt.URL, healthy.URL},
+			wantSource: healthy.URL,
+		},
+		{
+			name:       "fails over after a partial download",
+			primary:    truncated.URL,
+			mirrors:    []string{healthy.URL},
+			wantSource: healthy.URL,
+		},
+		{
+			name:    "all sources failing",
+			primary: unavailable.URL,
+			mirrors: []string{corrupt.URL, truncated.URL},
+			wantErr: true,
+		},
+	}
+
+	for _, useEngine := range []bool{false, true} {
+		for _, tc := range tests {
+			t.Run(fmt.Sprintf("%s engine=%v", tc.name, useEngine), func(t *testing.T) {
+				cfg := updaterdto.DefaultUpdaterSvcConfig()
+				cfg.WithTemporaryPath(t.TempDir()).WithDownloadEngine(useEngine)
+				svc := &UpdaterSvc{
+					cfg:    &cfg,
+					relay:  &relay.RelaySvc{},
+					netSvc: netSvc,
+					contextUpdate: &releaserdto.ReleaseAsset{
+						DownloadURL: tc.primary + "/app-example",
+						Checksum:    checksum,
+					},
+				}
+				mirrors := make([]string, 0, len(tc.mirrors))
+				for _, mirror := range tc.mirrors {
+					mirrors = append(mirrors, mirror+"/mirrored/app-example")
+				}
+				svc.contextUpdate.WithMirrors(mirrors)
+
+				artefactPath, err := svc.fetchArtefact(context.Background())
+				if (err != nil) != tc.wantErr {
+					t.Fatalf("err=%v wantErr=%v", err, tc.wantErr)
+				}
+				// Nothing a failed source wrote may be left for the next attempt to resume
+				leftovers, globErr := filepath.Glob(filepath.Join(cfg.TemporaryPath, "*.part"))
+				if globErr != nil || len(leftovers) != 0 {
+					t.Fatalf("leftovers=%v err=%v", leftovers, globErr)
+				}
+				if tc.wantErr {
+					return
+				}
+				if got := svc.DownloadSource(); !strings.HasPrefix(got, tc.wantSource) {
+					t.Fatalf("source=%s want %s", got, tc.wantSource)
+				}
+				contents, err := os.ReadFile(artefactPath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(contents) != string(artefact) {
+					t.Fatalf("artefact=%q want %q", contents, artefact)
+				}
+			})
+		}
+	}
+}
+
+func TestUpdaterSvc_DownloadFromCache(t *testing.T) {
+	artefact := []byte("v2")
+	sum := sha256.Sum256(artefact)
+	checksum := hex.EncodeToString(sum[:])
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write(artefact)
+	}))
+	defer server.Close()
+
+	netCfg := config.DefaultNetSvcConfig()
+	netCfg.WithRelay(&relay.RelaySvc{})
+	netSvc := gonetic.ProvideNetSvc(&netCfg)
+	cacheDir := t.TempDir()
+
+	// Two updaters sharing a cache, as two apps on the same machine would, the
+	// second given the checksum in another accepted form
+	for idx, releaseChecksum := range []string{checksum, "sha256:" + strings.ToUpper(checksum)} {
+		cfg := updaterdto.DefaultUpdaterSvcConfig()
+		cfg.WithTemporaryPath(t.TempDir()).WithCacheDir(cacheDir)
+		svc := &UpdaterSvc{
+			cfg:    &cfg,
+			relay:  &relay.RelaySvc{},
+			netSvc: netSvc,
+			contextUpdate: &releaserdto.ReleaseAsset{
+				DownloadURL: server.URL + "/app-example",
+				Checksum:    releaseChecksum,
+			},
+		}
+		artefactPath, err := svc.fetchArtefact(context.Background())
+		if err != nil {
+			t.Fatalf("fetch %d: %v", idx, err)
+		}
+		contents, err := os.ReadFile(artefactPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(contents) != string(artefact) {
+			t.Fatalf("artefact=%q want %q", contents, artefact)
+		}
+		if idx == 1 {
+			if _, err := os.Stat(svc.DownloadSource()); err != nil || !strings.HasPrefix(svc.DownloadSource(), cacheDir) {
+				t.Fatalf("source=%s want cache entry: %v", svc.DownloadSource(), err)
+			}
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("requests=%d want 1", requests)
+	}
+}
+
+func TestUpdaterSvc_DownloadLocalArtefact(t *testing.T) {
+	artefact := []byte("v2")
+	sum := sha256.Sum256(artefact)
+	checksum := hex.EncodeToString(sum[:])
+
+	sourcePath := filepath.Join(t.TempDir(), "app-example")
+	if err := os.WriteFile(sourcePath, artefact, 0755); err != nil {
+		t.Fatal(err)
+	}
+	sourceURL := (&url.URL{Scheme: "file", Path: filepath.ToSlash(sourcePath)}).String()
+
+	for _, tc := range []struct {
+		name     string
+		checksum string
+		wantErr  bool
+	}{
+		{name: "matching checksum", checksum: checksum},
+		{name: "checksum mismatch", checksum: strings.Repeat("0", 64), wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := updaterdto.DefaultUpdaterSvcConfig()
+			cfg.WithTemporaryPath(t.TempDir())
+			svc := &UpdaterSvc{
+				cfg:   &cfg,
+				relay: &relay.RelaySvc{},
+				contextUpdate: &releaserdto.ReleaseAsset{
+					DownloadURL: sourceURL,
+					Checksum:    tc.checksum,
+				},
+			}
+			artefactPath, err := svc.fetchArtefact(context.Background())
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err=%v wantErr=%v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if artefactPath != filepath.Join(cfg.TemporaryPath, "app-example") {
+				t.Fatalf("artefact=%s", artefactPath)
+			}
+			if svc.DownloadSource() != sourceURL {
+				t.Fatalf("source=%s want %s", svc.DownloadSource(), sourceURL)
+			}
+		})
+	}
+}
+
+func TestUpdaterSvc_DownloadEngineShared(t *testing.T) {
+	cfg := updaterdto.DefaultUpdaterSvcConfig()
+	svc := &UpdaterSvc{cfg: &cfg, relay: &relay.RelaySvc{}}
+
+	// Controls called while the download job starts must all reach one engine
+	engines := make([]*updaterdownload.Engine, 8)
+	var wg sync.WaitGroup
+	for idx := range engines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			engines[idx] = svc.downloadEngine()
+		}()
+	}
+	wg.Wait()
+	svc.PauseDownload()
+	for _, engine := range engines {
+		if engine != engines[0] || !engine.Paused() {
+			t.Fatal("download controls reached a different engine")
+		}
+	}
+}
+
+// authorisingCheckClient A check client whose downloads need credentials
+type authorisingCheckClient struct {
+	fakeCheckClient
+}
+
+func (c authorisingCheckClient) DownloadHeaders(rawURL string) http.Header {
+	header := http.Header{}
+	header.Set("Authorization", "token secret")
+	return header
+}
+
+func TestUpdaterSvc_DownloadWithHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("v2"))
+	}))
+	defer server.Close()
+
+	netCfg := config.DefaultNetSvcConfig()
+	netCfg.WithRelay(&relay.RelaySvc{})
+	cfg := updaterdto.DefaultUpdaterSvcConfig()
+	// The engine is not enabled, the net service cannot send the credentials
+	cfg.WithTemporaryPath(t.TempDir()).WithCheckClient(authorisingCheckClient{})
+	svc := &UpdaterSvc{
+		cfg:           &cfg,
+		relay:         &relay.RelaySvc{},
+		netSvc:        gonetic.ProvideNetSvc(&netCfg),
+		contextUpdate: &releaserdto.ReleaseAsset{DownloadURL: server.URL + "/app-example"},
+	}
+
+	artefactPath, err := svc.fetchArtefact(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contents, readErr := os.ReadFile(artefactPath); readErr != nil || string(contents) != "v2" {
+		t.Fatalf("artefact=%q err=%v", contents, readErr)
+	}
+}
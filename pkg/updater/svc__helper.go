@@ -0,0 +1,117 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/joy-dx/gophorth/pkg/updater/updatercopier"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterswap"
+)
+
+// resolveHelper returns the update helper to execute: HelperPath, then
+// HelperFunc, then the embedded one. updaterdto.ErrNoHelper is returned when none exist.
+// The embedded helper is always verified, app supplied helpers when HelperChecksum is set.
+func (s *UpdaterSvc) resolveHelper(ctx context.Context) (string, error) {
+	helperPath, err := s.appSuppliedHelper(ctx)
+	if err != nil {
+		return "", err
+	}
+	if helperPath == "" {
+		return updatercopier.ExtractHelper(s.cfg.TemporaryPath)
+	}
+	if s.cfg.HelperChecksum != "" {
+		if err := updatercopier.VerifyHelper(helperPath, s.cfg.HelperChecksum); err != nil {
+			return "", err
+		}
+	}
+	return helperPath, nil
+}
+
+// appSuppliedHelper returns the helper provided via HelperPath or, failing
+// that, HelperFunc, if any
+func (s *UpdaterSvc) appSuppliedHelper(ctx context.Context) (string, error) {
+	if s.cfg.HelperPath != "" {
+		if _, err := os.Stat(s.cfg.HelperPath); err != nil {
+			return "", fmt.Errorf("configured helper: %w", err)
+		}
+		return s.cfg.HelperPath, nil
+	}
+	if s.cfg.HelperFunc != nil {
+		updaterAgent := updaterdto.UpdaterAgentCfg{
+			NetSvc:        s.netSvc,
+			UpdaterCfg:    *s.cfg,
+			VersionUpdate: s.contextUpdate,
+		}
+		helperPath, err := s.cfg.HelperFunc(ctx, &updaterAgent)
+		if err != nil {
+			return "", fmt.Errorf("helper func: %w", err)
+		}
+		return helperPath, nil
+	}
+	return "", nil
+}
+
+// performInProcessUpdate replaces the update target from within the running
+// process, following the same stage, swap, launch and rollback steps as the
+// helper. Used when no helper exists for the platform.
+func (s *UpdaterSvc) performInProcessUpdate() error {
+	var logFile *os.File
+	if s.cfg.LogPath != "" {
+		if createdLog, err := os.Create(s.cfg.LogPath); err == nil {
+			logFile = createdLog
+			defer logFile.Close()
+		}
+	}
+	logf := func(format string, args ...interface{}) {
+		msg := fmt.Sprintf(format, args...)
+		s.relay.Debug(RlyUpdaterLog{Msg: msg})
+		if logFile != nil {
+			_, _ = fmt.Fprintf(logFile, "%s: %s\n", time.Now().Format("2006-01-02 15:04:05"), msg)
+		}
+	}
+
+	logf("In-process update starting. old=%s new=%s", s.updateTarget, s.contextUpdate.ArtefactName)
+	swapper := updaterswap.New(s.updateTarget, logf)
+	if err := swapper.Stage(s.contextUpdate.ArtefactName); err != nil {
+		return fmt.Errorf("stage update: %w", err)
+	}
+	if err := swapper.Swap(); err != nil {
+		if rollbackErr := swapper.Rollback(); rollbackErr != nil {
+			logf("Failed to discard staged replacement: %v", rollbackErr)
+		}
+		return fmt.Errorf("swap update: %w", err)
+	}
+
+	// Hand the target over before launching, as the helper does, so the new
+	// app's recovery leaves the backup alone
+	if err := swapper.Release(); err != nil {
+		logf("Failed to release swap journal: %v", err)
+	}
+	if err := launchCommand(s.updateTarget).Start(); err != nil {
+		logf("Launch failed: %v. Rolling back to backup.", err)
+		if rollbackErr := swapper.Rollback(); rollbackErr != nil {
+			logf("Failed to restore backup: %v", rollbackErr)
+		}
+		return fmt.Errorf("launch updated app: %w", err)
+	}
+	logf("New binary launched successfully.")
+	if err := swapper.Commit(); err != nil {
+		logf("Failed to commit swap: %v", err)
+	}
+	return nil
+}
+
+// launchCommand starts the app the way the helper does, through "open -n" for
+// a .app bundle on macOS and directly otherwise
+func launchCommand(path string) *exec.Cmd {
+	if runtime.GOOS == "darwin" && filepath.Ext(path) == ".app" {
+		return exec.Command("open", "-n", path)
+	}
+	return exec.Command(path)
+}
@@ -0,0 +1,165 @@
+package updater
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updatercopier"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterswap"
+	"github.com/joy-dx/relay"
+)
+
+func TestUpdaterSvc_ResolveHelper(t *testing.T) {
+	dir := t.TempDir()
+	helperPath := filepath.Join(dir, "update-helper")
+	builtPath := filepath.Join(dir, "built-helper")
+	for _, path := range []string{helperPath, builtPath} {
+		if err := os.WriteFile(path, []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sum := sha256.Sum256([]byte("#!/bin/sh\nexit 0\n"))
+	built := func(ctx context.Context, cfg *updaterdto.UpdaterAgentCfg) (string, error) {
+		return builtPath, nil
+	}
+	buildFailed := func(ctx context.Context, cfg *updaterdto.UpdaterAgentCfg) (string, error) {
+		return "", errors.New("no toolchain")
+	}
+
+	tests := []struct {
+		name           string
+		helperPath     string
+		helperFunc     updaterdto.HelperFuncType
+		helperChecksum string
+		wantPath       string
+		wantEmbedded   bool
+		wantErr        error
+	}{
+		{name: "helper path over helper func", helperPath: helperPath, helperFunc: buildFailed, wantPath: helperPath},
+		{name: "helper func", helperFunc: built, wantPath: builtPath},
+		{name: "helper func failing", helperFunc: buildFailed, wantErr: errors.New("helper func")},
+		{name: "missing helper path", helperPath: filepath.Join(dir, "missing"), helperFunc: built, wantErr: os.ErrNotExist},
+		{name: "helper checksum matching", helperPath: helperPath, helperChecksum: hex.EncodeToString(sum[:]), wantPath: helperPath},
+		{name: "helper checksum mismatch", helperPath: helperPath, helperChecksum: strings.Repeat("0", 64), wantErr: updaterdto.ErrHelperIntegrity},
+		{name: "embedded helper when none supplied", wantEmbedded: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := updaterdto.DefaultUpdaterSvcConfig()
+			cfg.WithTemporaryPath(t.TempDir()).
+				WithHelperPath(tc.helperPath).
+				WithHelperFunc(tc.helperFunc).
+				WithHelperChecksum(tc.helperChecksum)
+			svc := &UpdaterSvc{
+				cfg:           &cfg,
+				relay:         &relay.RelaySvc{},
+				contextUpdate: &releaserdto.ReleaseAsset{},
+			}
+
+			got, err := svc.resolveHelper(context.Background())
+			if tc.wantEmbedded {
+				if !updatercopier.HasEmbeddedHelper() {
+					// Without one, the app must configure a helper or the in-process fallback
+					if !errors.Is(err, updaterdto.ErrNoHelper) {
+						t.Fatalf("err=%v want %v", err, updaterdto.ErrNoHelper)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.HasPrefix(filepath.Base(filepath.Dir(got)), updatercopier.HelperDirPrefix) || !strings.HasPrefix(got, cfg.TemporaryPath) {
+					t.Fatalf("helper=%s want one extracted to %s", got, cfg.TemporaryPath)
+				}
+				return
+			}
+			switch {
+			case tc.wantErr == nil && err != nil:
+				t.Fatal(err)
+			case tc.wantErr != nil && err == nil:
+				t.Fatalf("helper=%s want error %v", got, tc.wantErr)
+			case tc.wantErr != nil && !errors.Is(err, tc.wantErr) && !strings.Contains(err.Error(), tc.wantErr.Error()):
+				t.Fatalf("err=%v want %v", err, tc.wantErr)
+			}
+			if got != tc.wantPath {
+				t.Fatalf("helper=%s want %s", got, tc.wantPath)
+			}
+		})
+	}
+}
+
+func TestUpdaterSvc_PerformInProcessUpdate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("launches shell scripts")
+	}
+	tests := []struct {
+		name string
+		// replacementMode Mode of the new version, not executable makes the launch fail
+		replacementMode os.FileMode
+		wantTarget      string
+		wantErr         bool
+	}{
+		{name: "swaps and launches the new version", replacementMode: 0755, wantTarget: "#!/bin/sh\n# v2\nexit 0\n"},
+		{name: "launch failure restores the previous version", replacementMode: 0644, wantTarget: "#!/bin/sh\n# v1\nexit 0\n", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			target := filepath.Join(dir, "app", "app-example")
+			replacement := filepath.Join(dir, "tmp", "app-example-2.0.0")
+			for path, contents := range map[string]string{
+				target:      "#!/bin/sh\n# v1\nexit 0\n",
+				replacement: "#!/bin/sh\n# v2\nexit 0\n",
+			} {
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(contents), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := os.Chmod(replacement, tc.replacementMode); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := updaterdto.DefaultUpdaterSvcConfig()
+			cfg.WithTemporaryPath(filepath.Join(dir, "tmp")).
+				WithUpdateLogPath(filepath.Join(dir, "update.log"))
+			svc := &UpdaterSvc{
+				cfg:           &cfg,
+				relay:         &relay.RelaySvc{},
+				updateTarget:  target,
+				contextUpdate: &releaserdto.ReleaseAsset{ArtefactName: replacement},
+			}
+			err := svc.performInProcessUpdate()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err=%v wantErr=%v", err, tc.wantErr)
+			}
+
+			contents, readErr := os.ReadFile(target)
+			if readErr != nil {
+				t.Fatal(readErr)
+			}
+			if string(contents) != tc.wantTarget {
+				t.Fatalf("target=%q want %q", contents, tc.wantTarget)
+			}
+			for _, leftover := range []string{updaterswap.JournalPath(target), updaterswap.BackupPath(target), updaterswap.StagedPath(target)} {
+				if _, statErr := os.Lstat(leftover); !os.IsNotExist(statErr) {
+					t.Fatalf("expected %s to be removed, err=%v", leftover, statErr)
+				}
+			}
+			if log, logErr := os.ReadFile(cfg.LogPath); logErr != nil || !strings.Contains(string(log), "In-process update starting") {
+				t.Fatalf("update log=%q err=%v", log, logErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package updater
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joy-dx/gonetic/utils"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdownload"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+// StartDownload runs DownloadUpdate in the background. Cancelling the job
+// removes the partial download rather than keeping it to resume.
+func (s *UpdaterSvc) StartDownload(ctx context.Context, link *releaserdto.ReleaseAsset) *updaterdto.Job {
+	return updaterdto.StartJob(ctx, func(ctx context.Context) error {
+		return s.DownloadUpdate(ctx, link)
+	}, s.discardDownload)
+}
+
+// StartUpdate downloads the update, unless already downloaded, and performs it
+// in the background. Once the job completes the app should quit so the helper
+// can replace it.
+func (s *UpdaterSvc) StartUpdate(ctx context.Context) *updaterdto.Job {
+	return updaterdto.StartJob(ctx, func(ctx context.Context) error {
+		if s.Status() != updaterdto.DOWNLOADED {
+			if err := s.DownloadUpdate(ctx, nil); err != nil {
+				return err
+			}
+		}
+		return s.PerformUpdate(ctx)
+	}, s.discardDownload)
+}
+
+// discardDownload removes the files of an abandoned download and offers the
+// update again
+func (s *UpdaterSvc) discardDownload() {
+	if s.contextUpdate == nil {
+		return
+	}
+	s.removeDownload()
+	s.setStatus(updaterdto.UPDATE_AVAILABLE)
+	s.relay.Info(RlyUpdaterLog{Msg: "update cancelled"})
+}
+
+// removeDownload removes the artefact of the context update, with the .part
+// file the download engine keeps for resuming
+func (s *UpdaterSvc) removeDownload() {
+	var paths []string
+	if urls := s.contextUpdate.DownloadURLs(); len(urls) > 0 {
+		if outputFileName, err := utils.FilenameFromUrl(urls[0]); err == nil {
+			destination := filepath.Join(s.cfg.TemporaryPath, outputFileName)
+			paths = append(paths, destination, updaterdownload.PartPath(destination), updaterdownload.ValidatorPath(destination))
+		}
+	}
+	// A custom DownloadFunc may have saved elsewhere in the temporary path
+	if temporaryPath, err := filepath.Abs(s.cfg.TemporaryPath); err == nil && strings.HasPrefix(s.contextUpdate.ArtefactName, temporaryPath+string(os.PathSeparator)) {
+		paths = append(paths, s.contextUpdate.ArtefactName)
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err == nil {
+			s.relay.Debug(RlyUpdaterLog{Msg: "removed abandoned download: " + path})
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package updater
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdownload"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/relay"
+)
+
+func TestUpdaterSvc_StartDownload(t *testing.T) {
+	artefact := make([]byte, 64<<10)
+	sum := sha256.Sum256(artefact)
+	checksum := hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(artefact)))
+		if r.URL.Path == "/complete/app-example" {
+			_, _ = w.Write(artefact)
+			return
+		}
+		// Send half, then stall until the client gives up
+		_, _ = w.Write(artefact[:len(artefact)/2])
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		path      string
+		cancel    bool
+		wantStage updaterdto.JobStage
+	}{
+		{name: "completes", path: "/complete/app-example", wantStage: updaterdto.JOB_COMPLETE},
+		{name: "cancelled mid download", path: "/stall/app-example", cancel: true, wantStage: updaterdto.JOB_CANCELLED},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			temporaryPath := t.TempDir()
+			cfg := updaterdto.DefaultUpdaterSvcConfig()
+			cfg.WithTemporaryPath(temporaryPath).WithDownloadEngine(true)
+			svc := &UpdaterSvc{
+				cfg:    &cfg,
+				relay:  &relay.RelaySvc{},
+				status: updaterdto.UPDATE_AVAILABLE,
+				contextUpdate: &releaserdto.ReleaseAsset{
+					DownloadURL: server.URL + tc.path,
+					Checksum:    checksum,
+				},
+			}
+
+			job := svc.StartDownload(context.Background(), nil)
+			t.Cleanup(job.Cancel)
+			if tc.cancel {
+				// Cancel once the partial file shows the download is under way
+				part := updaterdownload.PartPath(filepath.Join(temporaryPath, "app-example"))
+				deadline := time.Now().Add(5 * time.Second)
+				for {
+					if _, statErr := os.Stat(part); statErr == nil {
+						break
+					}
+					if time.Now().After(deadline) {
+						t.Fatal("download never started")
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+				job.Cancel()
+			}
+			select {
+			case <-job.Done():
+			case <-time.After(10 * time.Second):
+				t.Fatal("job did not finish")
+			}
+
+			err := job.Wait()
+			if progress := job.Progress(); progress.Stage != tc.wantStage {
+				t.Fatalf("stage=%s err=%v want %s", progress.Stage, err, tc.wantStage)
+			}
+			if !tc.cancel {
+				if err != nil || svc.Status() != updaterdto.DOWNLOADED {
+					t.Fatalf("err=%v status=%s", err, svc.Status())
+				}
+				return
+			}
+			entries, readErr := os.ReadDir(temporaryPath)
+			if readErr != nil {
+				t.Fatal(readErr)
+			}
+			if len(entries) != 0 || svc.Status() != updaterdto.UPDATE_AVAILABLE {
+				t.Fatalf("left %d files behind, status=%s", len(entries), svc.Status())
+			}
+		})
+	}
+}
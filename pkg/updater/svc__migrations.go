@@ -0,0 +1,153 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+// Migrations returns the outcome of migrations attempted during Hydrate
+func (s *UpdaterSvc) Migrations() []updaterdto.MigrationResult {
+	return s.migrations
+}
+
+// runMigrations compares the current version with the one recorded by the
+// previous run and, when it is higher, runs matching migrations in registration
+// order. Moving to a lower version, e.g. after Rollback, runs none. Each
+// migration runs once, tracked by name in persistent state. When a migration
+// fails, it and the migrations already completed in this run are rolled back in
+// reverse order and the previous version stays recorded, so the whole set is
+// retried on the next launch.
+func (s *UpdaterSvc) runMigrations(ctx context.Context) error {
+	if s.cfg.StatePath == "" {
+		if len(s.cfg.Migrations) > 0 {
+			s.relay.Warn(RlyUpdaterLog{Msg: "migrations registered but no state path configured, skipping"})
+		}
+		return nil
+	}
+	if s.version == nil {
+		return nil
+	}
+
+	state, err := s.loadPersistentState()
+	if err != nil {
+		return err
+	}
+	currentVersion := s.version.String()
+	lastRunVersion := state.LastRunVersion
+	if lastRunVersion == "" {
+		if s.Status() != updaterdto.COMPLETE {
+			// First run of a fresh install, nothing to migrate
+			state.LastRunVersion = currentVersion
+			return s.savePersistentState(state)
+		}
+		// Updated from a version that kept no state, treated as older than any release
+		lastRunVersion = "0.0.0"
+	}
+
+	previousVersion, err := semver.NewVersion(lastRunVersion)
+	if err != nil {
+		return fmt.Errorf("parse previously run version: %w", err)
+	}
+	switch previousVersion.Compare(s.version) {
+	case 0:
+		return nil
+	case 1:
+		s.relay.Info(RlyUpdaterLog{Msg: fmt.Sprintf("moved back from %s to %s, migrations skipped", previousVersion.String(), currentVersion)})
+		state.LastRunVersion = currentVersion
+		return s.savePersistentState(state)
+	}
+	migrationCtx := updaterdto.MigrationContext{
+		FromVersion: previousVersion.String(),
+		ToVersion:   currentVersion,
+	}
+	s.relay.Debug(RlyUpdaterLog{Msg: fmt.Sprintf("version changed since last run: %s -> %s", migrationCtx.FromVersion, migrationCtx.ToVersion)})
+
+	var ran []updaterdto.Migration
+	var results []updaterdto.MigrationResult
+	defer func() {
+		s.migrations = results
+	}()
+	for _, migration := range s.cfg.Migrations {
+		if _, done := state.CompletedMigrations[migration.Name]; done {
+			continue
+		}
+		matches, matchErr := migrationMatches(migration, previousVersion, s.version)
+		if matchErr != nil {
+			return fmt.Errorf("migration %s: %w", migration.Name, matchErr)
+		}
+		if !matches {
+			continue
+		}
+
+		s.relay.Info(RlyUpdaterLog{Msg: fmt.Sprintf("running migration %s", migration.Name)})
+		ran = append(ran, migration)
+		results = append(results, updaterdto.MigrationResult{
+			Name:        migration.Name,
+			FromVersion: migrationCtx.FromVersion,
+			ToVersion:   migrationCtx.ToVersion,
+		})
+		if upErr := migration.Up(ctx, migrationCtx); upErr != nil {
+			results[len(results)-1].Error = upErr.Error()
+			s.rollbackMigrations(ctx, migrationCtx, ran, results)
+			return fmt.Errorf("migration %s: %w", migration.Name, upErr)
+		}
+		completedAt := time.Now()
+		results[len(results)-1].CompletedAt = &completedAt
+		state.CompletedMigrations[migration.Name] = completedAt
+	}
+
+	state.LastRunVersion = currentVersion
+	return s.savePersistentState(state)
+}
+
+// rollbackMigrations runs the Down hooks of migrations from the current run in
+// reverse order, marking the matching results as rolled back
+func (s *UpdaterSvc) rollbackMigrations(ctx context.Context, migrationCtx updaterdto.MigrationContext, ran []updaterdto.Migration, results []updaterdto.MigrationResult) {
+	for i := len(ran) - 1; i >= 0; i-- {
+		results[i].CompletedAt = nil
+		if ran[i].Down == nil {
+			s.relay.Warn(RlyUpdaterLog{Msg: fmt.Sprintf("migration %s has no rollback hook", ran[i].Name)})
+			continue
+		}
+		if downErr := ran[i].Down(ctx, migrationCtx); downErr != nil {
+			s.relay.Warn(RlyUpdaterLog{Msg: fmt.Sprintf("rollback of migration %s failed: %s", ran[i].Name, downErr.Error())})
+			continue
+		}
+		results[i].RolledBack = true
+	}
+}
+
+// migrationMatches reports whether a migration applies to an update from
+// previous to current. The update must cross in to the To range, so previous
+// must not already satisfy it.
+func migrationMatches(migration updaterdto.Migration, previous *semver.Version, current *semver.Version) (bool, error) {
+	if migration.Up == nil {
+		return false, errors.New("no up hook")
+	}
+	for _, check := range []struct {
+		constraint string
+		version    *semver.Version
+		want       bool
+	}{
+		{migration.From, previous, true},
+		{migration.To, current, true},
+		{migration.To, previous, false},
+	} {
+		if check.constraint == "" {
+			continue
+		}
+		constraint, err := semver.NewConstraint(check.constraint)
+		if err != nil {
+			return false, fmt.Errorf("parse constraint %q: %w", check.constraint, err)
+		}
+		if constraint.Check(check.version) != check.want {
+			return false, nil
+		}
+	}
+	return true, nil
+}
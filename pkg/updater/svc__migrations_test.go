@@ -0,0 +1,141 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/relay"
+)
+
+func TestUpdaterSvc_RunMigrations(t *testing.T) {
+	tests := []struct {
+		name string
+		// runs Versions hydrated in order, sharing one state file
+		runs []string
+		// updated The first run finishes an update, as the update log shows
+		updated      bool
+		failing      string
+		wantUp       []string
+		wantDown     []string
+		wantLastRun  string
+		wantFailures int
+	}{
+		{
+			name:        "first run records version only",
+			runs:        []string{"1.0.0"},
+			wantLastRun: "1.0.0",
+		},
+		{
+			name:        "update from a version that kept no state",
+			runs:        []string{"2.0.0"},
+			updated:     true,
+			wantUp:      []string{"config-v2", "always"},
+			wantLastRun: "2.0.0",
+		},
+		{
+			name:        "downgrade runs nothing and records the lower version",
+			runs:        []string{"2.1.0", "2.0.0"},
+			wantLastRun: "2.0.0",
+		},
+		{
+			name:        "rollback and update again runs nothing twice",
+			runs:        []string{"1.5.0", "2.0.0", "1.5.0", "2.0.0"},
+			wantUp:      []string{"config-v2", "always"},
+			wantLastRun: "2.0.0",
+		},
+		{
+			name:        "major update runs matching migrations in order",
+			runs:        []string{"1.5.0", "2.1.0"},
+			wantUp:      []string{"config-v2", "always"},
+			wantLastRun: "2.1.0",
+		},
+		{
+			name:        "migrations run exactly once",
+			runs:        []string{"1.5.0", "2.0.0", "2.0.0", "2.1.0"},
+			wantUp:      []string{"config-v2", "always"},
+			wantLastRun: "2.1.0",
+		},
+		{
+			name:        "non matching range is skipped",
+			runs:        []string{"2.0.0", "2.1.0"},
+			wantUp:      []string{"always"},
+			wantLastRun: "2.1.0",
+		},
+		{
+			name:         "failure rolls back the run and retries next launch",
+			runs:         []string{"1.5.0", "2.0.0"},
+			failing:      "always",
+			wantUp:       []string{"config-v2", "always"},
+			wantDown:     []string{"always", "config-v2"},
+			wantLastRun:  "1.5.0",
+			wantFailures: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			statePath := filepath.Join(t.TempDir(), "state.json")
+			var gotUp, gotDown []string
+			migration := func(name string, from string, to string) updaterdto.Migration {
+				return updaterdto.Migration{
+					Name: name,
+					From: from,
+					To:   to,
+					Up: func(ctx context.Context, migration updaterdto.MigrationContext) error {
+						gotUp = append(gotUp, name)
+						if name == tc.failing {
+							return errors.New("failed")
+						}
+						return nil
+					},
+					Down: func(ctx context.Context, migration updaterdto.MigrationContext) error {
+						gotDown = append(gotDown, name)
+						return nil
+					},
+				}
+			}
+
+			var svc *UpdaterSvc
+			failures := 0
+			for i, version := range tc.runs {
+				cfg := updaterdto.DefaultUpdaterSvcConfig()
+				cfg.WithStatePath(statePath).
+					WithMigration(migration("config-v2", "<2.0.0", ">=2.0.0")).
+					WithMigration(migration("always", "", ""))
+				svc = &UpdaterSvc{
+					cfg:     &cfg,
+					relay:   &relay.RelaySvc{},
+					version: semver.MustParse(version),
+				}
+				if i == 0 && tc.updated {
+					svc.status = updaterdto.COMPLETE
+				}
+				if err := svc.runMigrations(context.Background()); err != nil {
+					failures++
+				}
+			}
+
+			if failures != tc.wantFailures {
+				t.Fatalf("failures=%d want %d", failures, tc.wantFailures)
+			}
+			if !reflect.DeepEqual(gotUp, tc.wantUp) {
+				t.Fatalf("up=%v want %v", gotUp, tc.wantUp)
+			}
+			if !reflect.DeepEqual(gotDown, tc.wantDown) {
+				t.Fatalf("down=%v want %v", gotDown, tc.wantDown)
+			}
+			state, err := svc.loadPersistentState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state.LastRunVersion != tc.wantLastRun {
+				t.Fatalf("last run=%s want %s", state.LastRunVersion, tc.wantLastRun)
+			}
+		})
+	}
+}
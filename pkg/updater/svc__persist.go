@@ -0,0 +1,54 @@
+package updater
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+// loadPersistentState reads the state kept at StatePath. A missing file
+// returns an empty state.
+func (s *UpdaterSvc) loadPersistentState() (*updaterdto.PersistentState, error) {
+	state := &updaterdto.PersistentState{
+		CompletedMigrations: map[string]time.Time{},
+	}
+	contents, err := os.ReadFile(s.cfg.StatePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("read updater state: %w", err)
+	}
+	if err := json.Unmarshal(contents, state); err != nil {
+		return nil, fmt.Errorf("parse updater state: %w", err)
+	}
+	if state.CompletedMigrations == nil {
+		state.CompletedMigrations = map[string]time.Time{}
+	}
+	return state, nil
+}
+
+// savePersistentState writes state to StatePath via a temporary file and
+// rename so a crash never leaves it half written
+func (s *UpdaterSvc) savePersistentState(state *updaterdto.PersistentState) error {
+	contents, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.cfg.StatePath), 0700); err != nil {
+		return fmt.Errorf("create updater state directory: %w", err)
+	}
+	tmpPath := s.cfg.StatePath + ".tmp"
+	if err := os.WriteFile(tmpPath, contents, 0600); err != nil {
+		return fmt.Errorf("write updater state: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.cfg.StatePath); err != nil {
+		return fmt.Errorf("commit updater state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterswap"
+)
+
+// keepPreviousVersion copies the running version aside, recording its version
+// in persistent state, so Rollback can restore it once the update is in place
+func (s *UpdaterSvc) keepPreviousVersion() error {
+	previousPath, err := updaterswap.KeepPrevious(s.updateTarget)
+	if err != nil {
+		return fmt.Errorf("keep previous version: %w", err)
+	}
+	s.relay.Debug(RlyUpdaterLog{Msg: fmt.Sprintf("kept previous version at %s", previousPath)})
+	if s.cfg.StatePath == "" || s.version == nil {
+		return nil
+	}
+	state, err := s.loadPersistentState()
+	if err != nil {
+		return err
+	}
+	state.PreviousVersion = s.version.String()
+	return s.savePersistentState(state)
+}
+
+// Rollback reinstates the copy kept by UpdaterConfig.KeepPreviousVersion,
+// swapping it in through the update helper like any update, and skips the
+// running version so it is not offered again. As after PerformUpdate, the app
+// should quit so the helper can replace it.
+func (s *UpdaterSvc) Rollback(ctx context.Context) error {
+	if s.updateTarget == "" {
+		return errors.New("update target unknown, run Hydrate first")
+	}
+	previousPath := updaterswap.PreviousPath(s.updateTarget)
+	if _, err := os.Stat(previousPath); err != nil {
+		return updaterdto.ErrNoPreviousVersion
+	}
+
+	previousVersion := "unknown"
+	if s.cfg.StatePath != "" {
+		if state, err := s.loadPersistentState(); err == nil && state.PreviousVersion != "" {
+			previousVersion = state.PreviousVersion
+		}
+	}
+	if s.version != nil {
+		if err := s.SkipVersion(s.version.String()); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	s.status = updaterdto.IN_PROGRESS
+	s.contextUpdate = &releaserdto.ReleaseAsset{
+		Version:      previousVersion,
+		ArtefactName: previousPath,
+	}
+	s.mu.Unlock()
+	s.relay.Info(RlyUpdaterLog{Msg: fmt.Sprintf("rolling back to version %s", previousVersion)})
+	return s.applyArtefact(ctx)
+}
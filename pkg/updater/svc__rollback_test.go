@@ -0,0 +1,62 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterswap"
+	"github.com/joy-dx/relay"
+)
+
+func TestUpdaterSvc_Rollback(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "app", "app-example")
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("v1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	helperPath := filepath.Join(dir, "update-helper")
+	if err := os.WriteFile(helperPath, []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	newSvc := func(version string) *UpdaterSvc {
+		cfg := updaterdto.DefaultUpdaterSvcConfig()
+		cfg.WithTemporaryPath(filepath.Join(dir, "tmp")).
+			WithStatePath(filepath.Join(dir, "state.json")).
+			WithHelperPath(helperPath)
+		return &UpdaterSvc{
+			cfg:          &cfg,
+			relay:        &relay.RelaySvc{},
+			version:      semver.MustParse(version),
+			updateTarget: target,
+		}
+	}
+
+	if err := newSvc("1.0.0").Rollback(context.Background()); !errors.Is(err, updaterdto.ErrNoPreviousVersion) {
+		t.Fatalf("err=%v want %v", err, updaterdto.ErrNoPreviousVersion)
+	}
+
+	// Version 1.0.0 keeps itself aside while updating to 2.0.0
+	if err := newSvc("1.0.0").keepPreviousVersion(); err != nil {
+		t.Fatal(err)
+	}
+	svc := newSvc("2.0.0")
+	if err := svc.Rollback(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	previousPath := updaterswap.PreviousPath(target)
+	if link := svc.UpdateLink(); link.ArtefactName != previousPath || link.Version != "1.0.0" {
+		t.Fatalf("rollback artefact=%s version=%s", link.ArtefactName, link.Version)
+	}
+	if got := svc.State().SkippedVersions; len(got) != 1 || got[0] != "2.0.0" {
+		t.Fatalf("skipped=%v want [2.0.0]", got)
+	}
+}
@@ -1 +1,55 @@
 package updater
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+)
+
+// verifySignature checks the artefact against the release signature, reporting
+// whether it was verified along with a description of the outcome. Signatures
+// without a matching local key are skipped rather than failed, as before.
+func (s *UpdaterSvc) verifySignature(artefactPath string) (bool, string, error) {
+	if s.contextUpdate.Signature == "" {
+		return false, "no signature published, skipped", nil
+	}
+	keyInfo, err := cryptography.DetectSignatureInformation([]byte(s.contextUpdate.Signature))
+	if err != nil {
+		return false, "", fmt.Errorf("could not detect key information from link signature: %w", err)
+	}
+	switch keyInfo.Format {
+	case "PGP":
+		if s.pgpEntity == nil {
+			return false, "pgp signature provided but no local handler", nil
+		}
+		signatureAsBuffer := bytes.NewBufferString(s.contextUpdate.Signature)
+		if verifyErr := cryptography.PGPVerifyFile(s.pgpEntity, artefactPath, *signatureAsBuffer); verifyErr != nil {
+			return false, "", fmt.Errorf("could not verify signature: %w", verifyErr)
+		}
+		return true, "pgp signature verified", nil
+	case "X509":
+		if s.ecdsaKey == nil {
+			return false, "X509 signature provided but no local handler", nil
+		}
+		if verifyErr := cryptography.ECDSAVerifyFile(s.ecdsaKey, artefactPath, s.contextUpdate.Signature); verifyErr != nil {
+			return false, "", fmt.Errorf("could not verify signature: %w", verifyErr)
+		}
+		return true, "X509 signature verified", nil
+	}
+	return false, fmt.Sprintf("unsupported signature format %s, skipped", keyInfo.Format), nil
+}
+
+// runVerifiers runs the configured Verifiers against the artefact, stopping at
+// the first failure. The refs of the verifiers that passed are returned.
+func (s *UpdaterSvc) runVerifiers(artefactPath string) ([]string, error) {
+	passed := make([]string, 0, len(s.cfg.Verifiers))
+	for _, verifier := range s.cfg.Verifiers {
+		if err := verifier.Verify(artefactPath); err != nil {
+			return passed, fmt.Errorf("verifier %s: %w", verifier.GetRef(), err)
+		}
+		s.relay.Debug(RlyUpdaterLog{Msg: fmt.Sprintf("verifier %s passed", verifier.GetRef())})
+		passed = append(passed, verifier.GetRef())
+	}
+	return passed, nil
+}
@@ -0,0 +1,178 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+	"github.com/joy-dx/gophorth/pkg/updater/updatercopier"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterswap"
+)
+
+// helperPreflightTimeout Upper bound on a helper answering the preflight argument
+const helperPreflightTimeout = 10 * time.Second
+
+// SimulateUpdate rehearses the full update pipeline: check, download, signature
+// and verifier checks, PrepareFunc and helper preflight. It stops short of the
+// swap so nothing outside TemporaryPath is changed. When no newer version exists
+// the latest release is used so the pipeline can still be exercised.
+//
+// The report is always returned, the error is that of the first failed step.
+// The selected update and download source are put back afterwards, and no new
+// version event is emitted, so a simulation never changes what State reports.
+func (s *UpdaterSvc) SimulateUpdate(ctx context.Context) (*updaterdto.SimulationReport, error) {
+	s.mu.RLock()
+	previousUpdate, previousSource := s.contextUpdate, s.downloadSource
+	s.mu.RUnlock()
+	defer func() {
+		s.mu.Lock()
+		s.contextUpdate, s.downloadSource = previousUpdate, previousSource
+		s.mu.Unlock()
+	}()
+
+	report := &updaterdto.SimulationReport{
+		StartedAt:   time.Now(),
+		Target:      s.updateTarget,
+		StagedPath:  updaterswap.StagedPath(s.updateTarget),
+		BackupPath:  updaterswap.BackupPath(s.updateTarget),
+		JournalPath: updaterswap.JournalPath(s.updateTarget),
+	}
+	if s.version != nil {
+		report.CurrentVersion = s.version.String()
+	}
+	defer func() {
+		report.FinishedAt = time.Now()
+	}()
+
+	steps := []struct {
+		name updaterdto.SimulationStepName
+		run  func() (updaterdto.SimulationStepStatus, string, error)
+	}{
+		{updaterdto.SIMULATION_STEP_CHECK, func() (updaterdto.SimulationStepStatus, string, error) {
+			result, err := s.checkRemote(ctx)
+			if err != nil {
+				return updaterdto.SIMULATION_FAILED, "", err
+			}
+			asset := result.update
+			report.Asset = &asset
+			report.UpdateAvailable = result.status == updaterdto.UPDATE_AVAILABLE
+			// The download and prepare steps work on the selected update
+			simulated := result.update
+			s.mu.Lock()
+			s.contextUpdate = &simulated
+			s.mu.Unlock()
+			if !report.UpdateAvailable {
+				return updaterdto.SIMULATION_PASSED, fmt.Sprintf("already up to date, simulating with %s", asset.Version), nil
+			}
+			return updaterdto.SIMULATION_PASSED, fmt.Sprintf("update available: %s -> %s", report.CurrentVersion, asset.Version), nil
+		}},
+		{updaterdto.SIMULATION_STEP_DOWNLOAD, func() (updaterdto.SimulationStepStatus, string, error) {
+			artefactPath, err := s.fetchArtefact(ctx)
+			if err != nil {
+				return updaterdto.SIMULATION_FAILED, "", err
+			}
+			report.ArtefactPath = artefactPath
+			report.DownloadSource = s.downloadSource
+			if checksum, checksumErr := cryptography.Sha256SumFile(artefactPath); checksumErr == nil {
+				report.ArtefactChecksum = checksum
+			}
+			detail := fmt.Sprintf("downloaded to %s", artefactPath)
+			if report.DownloadSource != "" {
+				detail += fmt.Sprintf(" from %s", report.DownloadSource)
+			}
+			if s.contextUpdate.Checksum == "" {
+				return updaterdto.SIMULATION_PASSED, detail + ", no checksum published", nil
+			}
+			return updaterdto.SIMULATION_PASSED, detail + ", checksum verified", nil
+		}},
+		{updaterdto.SIMULATION_STEP_SIGNATURE, func() (updaterdto.SimulationStepStatus, string, error) {
+			verified, outcome, err := s.verifySignature(report.ArtefactPath)
+			if err != nil {
+				return updaterdto.SIMULATION_FAILED, "", err
+			}
+			if !verified {
+				return updaterdto.SIMULATION_SKIPPED, outcome, nil
+			}
+			return updaterdto.SIMULATION_PASSED, outcome, nil
+		}},
+		{updaterdto.SIMULATION_STEP_VERIFIERS, func() (updaterdto.SimulationStepStatus, string, error) {
+			if len(s.cfg.Verifiers) == 0 {
+				return updaterdto.SIMULATION_SKIPPED, "no verifiers configured", nil
+			}
+			passed, err := s.runVerifiers(report.ArtefactPath)
+			if err != nil {
+				return updaterdto.SIMULATION_FAILED, fmt.Sprintf("passed: %s", strings.Join(passed, ", ")), err
+			}
+			return updaterdto.SIMULATION_PASSED, fmt.Sprintf("passed: %s", strings.Join(passed, ", ")), nil
+		}},
+		{updaterdto.SIMULATION_STEP_PREPARE, func() (updaterdto.SimulationStepStatus, string, error) {
+			if err := s.prepareArtefact(ctx); err != nil {
+				return updaterdto.SIMULATION_FAILED, "", err
+			}
+			report.ArtefactPath = s.contextUpdate.ArtefactName
+			if s.cfg.PrepareFunc == nil {
+				return updaterdto.SIMULATION_SKIPPED, fmt.Sprintf("no prepare func, artefact used as is: %s", report.ArtefactPath), nil
+			}
+			return updaterdto.SIMULATION_PASSED, fmt.Sprintf("prepared artefact: %s", report.ArtefactPath), nil
+		}},
+		{updaterdto.SIMULATION_STEP_HELPER, func() (updaterdto.SimulationStepStatus, string, error) {
+			return s.simulateHelper(ctx, report)
+		}},
+		{updaterdto.SIMULATION_STEP_SWAP, func() (updaterdto.SimulationStepStatus, string, error) {
+			if err := updaterswap.Preflight(s.updateTarget); err != nil {
+				return updaterdto.SIMULATION_FAILED, "", err
+			}
+			return updaterdto.SIMULATION_SKIPPED, fmt.Sprintf("would replace %s with %s, keeping the previous version at %s", report.Target, report.ArtefactPath, report.BackupPath), nil
+		}},
+	}
+
+	for _, step := range steps {
+		started := time.Now()
+		status, detail, err := step.run()
+		if err != nil && detail == "" {
+			detail = err.Error()
+		}
+		report.Steps = append(report.Steps, updaterdto.SimulationStep{
+			Name:     step.name,
+			Status:   status,
+			Detail:   detail,
+			Duration: time.Since(started),
+		})
+		s.relay.Info(RlyUpdaterLog{Msg: fmt.Sprintf("simulate %s: %s. %s", step.name, status, detail)})
+		if err != nil {
+			return report, fmt.Errorf("simulate %s: %w", step.name, err)
+		}
+	}
+	return report, nil
+}
+
+// simulateHelper resolves the helper PerformUpdate would use and confirms it
+// executes on this machine. Helpers extracted for the check are removed again.
+func (s *UpdaterSvc) simulateHelper(ctx context.Context, report *updaterdto.SimulationReport) (updaterdto.SimulationStepStatus, string, error) {
+	helperPath, err := s.resolveHelper(ctx)
+	if errors.Is(err, updaterdto.ErrNoHelper) && s.cfg.InProcessFallback {
+		report.InProcess = true
+		return updaterdto.SIMULATION_PASSED, "no update helper available, would replace in-process", nil
+	}
+	if err != nil {
+		return updaterdto.SIMULATION_FAILED, "", err
+	}
+	report.HelperPath = helperPath
+	if helperDir := filepath.Dir(helperPath); strings.HasPrefix(filepath.Base(helperDir), updatercopier.HelperDirPrefix) {
+		defer os.RemoveAll(helperDir)
+	}
+
+	preflightCtx, cancel := context.WithTimeout(ctx, helperPreflightTimeout)
+	defer cancel()
+	if output, runErr := exec.CommandContext(preflightCtx, helperPath, updatercopier.HelperPreflightArg).CombinedOutput(); runErr != nil {
+		return updaterdto.SIMULATION_FAILED, "", fmt.Errorf("helper %s did not run: %w: %s", helperPath, runErr, strings.TrimSpace(string(output)))
+	}
+	return updaterdto.SIMULATION_PASSED, fmt.Sprintf("helper %s verified and runs", helperPath), nil
+}
@@ -0,0 +1,167 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/relay"
+)
+
+type fakeCheckClient struct {
+	asset releaserdto.ReleaseAsset
+}
+
+func (c fakeCheckClient) CheckUpdate(ctx context.Context, cfg *updaterdto.UpdaterConfig) (releaserdto.ReleaseAsset, error) {
+	return c.asset, nil
+}
+
+func (c fakeCheckClient) GetRef() string {
+	return "fake"
+}
+
+func (c fakeCheckClient) GetVersionLink() (releaserdto.ReleaseAsset, error) {
+	return c.asset, nil
+}
+
+type fakeVerifier struct {
+	err error
+}
+
+func (v fakeVerifier) GetRef() string {
+	return "fake"
+}
+
+func (v fakeVerifier) Verify(artefactPath string) error {
+	return v.err
+}
+
+func newSimulateFixture(t *testing.T) (*UpdaterSvc, string) {
+	t.Helper()
+	dir := t.TempDir()
+	target := filepath.Join(dir, "app", "app-example")
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("v1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	helperPath := filepath.Join(dir, "update-helper")
+	if err := os.WriteFile(helperPath, []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := updaterdto.DefaultUpdaterSvcConfig()
+	cfg.WithTemporaryPath(filepath.Join(dir, "tmp")).
+		WithVersion("1.0.0").
+		WithHelperPath(helperPath).
+		WithCheckClient(fakeCheckClient{asset: releaserdto.ReleaseAsset{Version: "2.0.0"}}).
+		WithUpdateFunc(func(ctx context.Context, agentCfg *updaterdto.UpdaterAgentCfg) (string, error) {
+			artefactPath := filepath.Join(dir, "tmp", "app-example")
+			if err := os.MkdirAll(filepath.Dir(artefactPath), 0755); err != nil {
+				return "", err
+			}
+			return artefactPath, os.WriteFile(artefactPath, []byte("v2"), 0755)
+		})
+
+	return &UpdaterSvc{
+		cfg:          &cfg,
+		relay:        &relay.RelaySvc{},
+		status:       updaterdto.INITIAL,
+		version:      semver.MustParse("1.0.0"),
+		updateTarget: target,
+	}, target
+}
+
+func TestUpdaterSvc_SimulateUpdate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture helper is a shell script")
+	}
+	tests := []struct {
+		name        string
+		setup       func(t *testing.T, s *UpdaterSvc, target string)
+		wantErr     bool
+		wantLast    updaterdto.SimulationStepName
+		wantLastRes updaterdto.SimulationStepStatus
+	}{
+		{
+			name:        "full pipeline",
+			setup:       func(t *testing.T, s *UpdaterSvc, target string) {},
+			wantLast:    updaterdto.SIMULATION_STEP_SWAP,
+			wantLastRes: updaterdto.SIMULATION_SKIPPED,
+		},
+		{
+			name: "keeps the downloaded update",
+			setup: func(t *testing.T, s *UpdaterSvc, target string) {
+				s.status = updaterdto.DOWNLOADED
+				s.downloadSource = "https://example.com/app-example-1.5.0"
+				s.contextUpdate = &releaserdto.ReleaseAsset{Version: "1.5.0", ArtefactName: filepath.Join(s.cfg.TemporaryPath, "app-example-1.5.0")}
+			},
+			wantLast:    updaterdto.SIMULATION_STEP_SWAP,
+			wantLastRes: updaterdto.SIMULATION_SKIPPED,
+		},
+		{
+			name: "verifier failure stops the pipeline",
+			setup: func(t *testing.T, s *UpdaterSvc, target string) {
+				s.cfg.WithVerifier(fakeVerifier{err: errors.New("bad artefact")})
+			},
+			wantErr:     true,
+			wantLast:    updaterdto.SIMULATION_STEP_VERIFIERS,
+			wantLastRes: updaterdto.SIMULATION_FAILED,
+		},
+		{
+			name: "missing helper without fallback",
+			setup: func(t *testing.T, s *UpdaterSvc, target string) {
+				s.cfg.WithHelperPath(filepath.Join(filepath.Dir(target), "missing-helper"))
+			},
+			wantErr:     true,
+			wantLast:    updaterdto.SIMULATION_STEP_HELPER,
+			wantLastRes: updaterdto.SIMULATION_FAILED,
+		},
+		{
+			name: "pending interrupted swap",
+			setup: func(t *testing.T, s *UpdaterSvc, target string) {
+				if err := os.WriteFile(target+".gophorth-journal", []byte(`{"phase":"staged"}`), 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr:     true,
+			wantLast:    updaterdto.SIMULATION_STEP_SWAP,
+			wantLastRes: updaterdto.SIMULATION_FAILED,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, target := newSimulateFixture(t)
+			tc.setup(t, svc, target)
+			before := svc.State()
+
+			report, err := svc.SimulateUpdate(context.Background())
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err=%v wantErr=%v", err, tc.wantErr)
+			}
+			last := report.Steps[len(report.Steps)-1]
+			if last.Name != tc.wantLast || last.Status != tc.wantLastRes {
+				t.Fatalf("last step=%s/%s want %s/%s (%s)", last.Name, last.Status, tc.wantLast, tc.wantLastRes, last.Detail)
+			}
+			if report.Passed() == tc.wantErr {
+				t.Fatalf("passed=%v with wantErr=%v", report.Passed(), tc.wantErr)
+			}
+			if contents, readErr := os.ReadFile(target); readErr != nil || string(contents) != "v1" {
+				t.Fatalf("target changed during simulation: %q, %v", contents, readErr)
+			}
+			after := svc.State()
+			if after.Status != before.Status || after.DownloadSource != before.DownloadSource || after.Changelog != before.Changelog || !reflect.DeepEqual(after.UpdateLink, before.UpdateLink) {
+				t.Fatalf("simulation changed state: %+v want %+v", after, before)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package updater
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+// SkipVersion stops version being offered by CheckLatest, the pending update
+// when version is empty. A pending download of the version is removed. Skipped
+// versions are persisted when StatePath is set.
+func (s *UpdaterSvc) SkipVersion(version string) error {
+	pendingVersion, hasPending := s.pendingVersion()
+	if version == "" {
+		if !hasPending {
+			return errors.New("no update selected, run CheckLatest first")
+		}
+		version = pendingVersion
+	}
+	skipped, err := semver.NewVersion(version)
+	if err != nil {
+		return fmt.Errorf("parse skipped version: %w", err)
+	}
+
+	if !s.isSkipped(skipped) {
+		s.mu.Lock()
+		s.skippedVersions = append(s.skippedVersions, skipped.String())
+		// A copy, as State does, for saving without holding the lock
+		skippedVersions := append([]string(nil), s.skippedVersions...)
+		s.mu.Unlock()
+		if s.cfg.StatePath != "" {
+			state, loadErr := s.loadPersistentState()
+			if loadErr != nil {
+				return loadErr
+			}
+			state.SkippedVersions = skippedVersions
+			if saveErr := s.savePersistentState(state); saveErr != nil {
+				return saveErr
+			}
+		}
+	}
+	s.relay.Info(RlyUpdaterLog{Msg: fmt.Sprintf("skipping version %s", skipped.String())})
+
+	if status := s.Status(); !hasPending || (status != updaterdto.UPDATE_AVAILABLE && status != updaterdto.DOWNLOADED) {
+		return nil
+	}
+	if pending, parseErr := semver.NewVersion(pendingVersion); parseErr == nil && pending.Equal(skipped) {
+		s.removeDownload()
+		s.setStatus(updaterdto.UP_TO_DATE)
+	}
+	return nil
+}
+
+// pendingVersion returns the version of the update CheckLatest selected
+func (s *UpdaterSvc) pendingVersion() (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.contextUpdate == nil {
+		return "", false
+	}
+	return s.contextUpdate.Version, true
+}
+
+func (s *UpdaterSvc) isSkipped(version *semver.Version) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, skipped := range s.skippedVersions {
+		if skippedVersion, err := semver.NewVersion(skipped); err == nil && skippedVersion.Equal(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadSkippedVersions restores the versions skipped in earlier runs
+func (s *UpdaterSvc) loadSkippedVersions() error {
+	if s.cfg.StatePath == "" {
+		return nil
+	}
+	state, err := s.loadPersistentState()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.skippedVersions = state.SkippedVersions
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,85 @@
+package updater
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/relay"
+)
+
+func TestUpdaterSvc_SkipVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		skip       string
+		remote     string
+		wantStatus updaterdto.UpdateStatus
+	}{
+		{name: "skipped version is not offered", skip: "2.0.0", remote: "2.0.0", wantStatus: updaterdto.UP_TO_DATE},
+		{name: "skip matches equal versions", skip: "v2.0", remote: "2.0.0", wantStatus: updaterdto.UP_TO_DATE},
+		{name: "later version is offered", skip: "2.0.0", remote: "2.1.0", wantStatus: updaterdto.UPDATE_AVAILABLE},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			statePath := filepath.Join(t.TempDir(), "state.json")
+			newSvc := func() *UpdaterSvc {
+				cfg := updaterdto.DefaultUpdaterSvcConfig()
+				cfg.WithStatePath(statePath).
+					WithCheckClient(fakeCheckClient{asset: releaserdto.ReleaseAsset{Version: tc.remote}})
+				return &UpdaterSvc{
+					cfg:     &cfg,
+					relay:   &relay.RelaySvc{},
+					status:  updaterdto.INITIAL,
+					version: semver.MustParse("1.0.0"),
+				}
+			}
+			if err := newSvc().SkipVersion(tc.skip); err != nil {
+				t.Fatal(err)
+			}
+
+			// A later run remembers the skip
+			svc := newSvc()
+			if err := svc.loadSkippedVersions(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := svc.CheckLatest(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if svc.Status() != tc.wantStatus {
+				t.Fatalf("status=%s want %s", svc.Status(), tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestUpdaterSvc_SkipPendingDownload(t *testing.T) {
+	temporaryPath := t.TempDir()
+	artefact := filepath.Join(temporaryPath, "app-example")
+	if err := os.WriteFile(artefact, []byte("v2"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := updaterdto.DefaultUpdaterSvcConfig()
+	cfg.WithTemporaryPath(temporaryPath)
+	svc := &UpdaterSvc{
+		cfg:    &cfg,
+		relay:  &relay.RelaySvc{},
+		status: updaterdto.DOWNLOADED,
+		contextUpdate: &releaserdto.ReleaseAsset{
+			Version:     "2.0.0",
+			DownloadURL: "https://example.com/app-example",
+		},
+	}
+	if err := svc.SkipVersion(""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(artefact); !os.IsNotExist(err) {
+		t.Fatalf("expected download removed, err=%v", err)
+	}
+	if svc.Status() != updaterdto.UP_TO_DATE || len(svc.State().SkippedVersions) != 1 {
+		t.Fatalf("status=%s skipped=%v", svc.Status(), svc.State().SkippedVersions)
+	}
+}
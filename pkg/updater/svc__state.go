@@ -14,42 +14,67 @@ import (
 	"github.com/joy-dx/gophorth/pkg/hydrate"
 	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
 	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterswap"
 )
 
 func (s *UpdaterSvc) UpdateLink() *releaserdto.ReleaseAsset {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.contextUpdate
 }
 
-func (s UpdaterSvc) State() *updaterdto.UpdaterState {
+func (s *UpdaterSvc) State() *updaterdto.UpdaterState {
 	version := "unknown"
 	if s.version != nil {
 		version = s.version.String()
 	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	// A copy, the update is still written to while downloading
+	var updateLink *releaserdto.ReleaseAsset
+	if s.contextUpdate != nil {
+		link := *s.contextUpdate
+		updateLink = &link
+	}
 	return &updaterdto.UpdaterState{
-		Architecture:    s.cfg.Architecture,
-		Changelog:       s.changelog,
-		CheckInterval:   s.cfg.CheckInterval,
-		LastUpdateCheck: s.cfg.LastUpdateCheck,
-		Log:             s.updateLog,
-		LogPath:         s.cfg.LogPath,
-		Platform:        s.cfg.Platform,
-		PublicKey:       s.cfg.PublicKey,
-		PublicKeyPath:   s.cfg.PublicKeyPath,
-		ReleasedAt:      s.releasedAt,
-		Status:          s.status,
-		TemporaryPath:   s.cfg.TemporaryPath,
-		UpdateLink:      s.contextUpdate,
-		Updating:        false,
-		Variant:         s.cfg.Variant,
-		Version:         version,
+		Architecture:      s.cfg.Architecture,
+		Changelog:         s.changelog,
+		CheckInterval:     s.cfg.CheckInterval,
+		CombinedChangelog: s.combinedChangelog,
+		DownloadSource:    s.downloadSource,
+		LastUpdateCheck:   s.cfg.LastUpdateCheck,
+		Log:               s.updateLog,
+		LogPath:           s.cfg.LogPath,
+		Migrations:        s.migrations,
+		Platform:          s.cfg.Platform,
+		PublicKey:         s.cfg.PublicKey,
+		PublicKeyPath:     s.cfg.PublicKeyPath,
+		ReleasedAt:        s.releasedAt,
+		ReleaseNotes:      s.releaseNotes,
+		ReleaseURL:        s.releaseURL,
+		SkippedVersions:   append([]string(nil), s.skippedVersions...),
+		Status:            s.status,
+		TemporaryPath:     s.cfg.TemporaryPath,
+		UpdateLink:        updateLink,
+		Updating:          false,
+		Variant:           s.cfg.Variant,
+		Version:           version,
 	}
 }
 
-func (s UpdaterSvc) Status() updaterdto.UpdateStatus {
+func (s *UpdaterSvc) Status() updaterdto.UpdateStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.status
 }
 
-func (s UpdaterSvc) UpdateLog() string {
+func (s *UpdaterSvc) setStatus(status updaterdto.UpdateStatus) {
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+}
+
+func (s *UpdaterSvc) UpdateLog() string {
 	return s.updateLog
 }
 
@@ -68,7 +93,7 @@ func (s *UpdaterSvc) Hydrate(ctx context.Context) error {
 		logContents, readErr := file.ToBytes(s.cfg.LogPath)
 		if readErr == nil {
 			s.updateLog = string(logContents)
-			s.status = updaterdto.COMPLETE
+			s.setStatus(updaterdto.COMPLETE)
 		}
 	}
 
@@ -76,7 +101,7 @@ func (s *UpdaterSvc) Hydrate(ctx context.Context) error {
 		s.relay.Debug(RlyUpdaterLog{Msg: fmt.Sprintf("public key provided from file: %s", s.cfg.PublicKeyPath)})
 		publicKey, err := file.ToBytes(s.cfg.PublicKeyPath)
 		if err != nil {
-			s.relay.Debug(RlyUpdaterLog{Msg: fmt.Sprintf("failed to read public key file %s. %w", s.cfg.PublicKeyPath, err.Error())})
+			s.relay.Debug(RlyUpdaterLog{Msg: fmt.Sprintf("failed to read public key file %s. %s", s.cfg.PublicKeyPath, err.Error())})
 		}
 		s.cfg.WithPublicKey(string(publicKey))
 	}
@@ -108,15 +133,49 @@ func (s *UpdaterSvc) Hydrate(ctx context.Context) error {
 	if s.cfg.Version != "" {
 		parsedVersion, err := semver.NewVersion(s.cfg.Version)
 		if err != nil {
-			s.status = updaterdto.INOPERATIVE
+			s.setStatus(updaterdto.INOPERATIVE)
 			s.relay.Warn(RlyUpdaterLog{Msg: fmt.Sprintf("could not parse release version: %s", err.Error())})
 			return updaterdto.ErrServiceInoperable
 		}
 		s.version = parsedVersion
 	} else {
-		s.status = updaterdto.INOPERATIVE
+		s.setStatus(updaterdto.INOPERATIVE)
+	}
+
+	selfPath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	updateTarget := selfPath
+	if s.cfg.Platform == "darwin" {
+		appBundleRoot := findAppBundleRoot(selfPath)
+		if appBundleRoot != "" {
+			s.relay.Debug(RlyUpdaterLog{Msg: fmt.Sprintf("found app bundle at %s", appBundleRoot)})
+			updateTarget = appBundleRoot
+		}
+	}
+	s.updateTarget = updateTarget
+
+	// Was a previous update interrupted mid-swap?
+	outcome, recoverErr := updaterswap.Recover(updateTarget, func(format string, args ...interface{}) {
+		s.relay.Debug(RlyUpdaterLog{Msg: fmt.Sprintf(format, args...)})
+	})
+	if recoverErr != nil {
+		s.relay.Warn(RlyUpdaterLog{Msg: fmt.Sprintf("could not recover interrupted update: %s", recoverErr.Error())})
+	} else if outcome != updaterswap.OUTCOME_NONE {
+		s.relay.Warn(RlyUpdaterLog{Msg: fmt.Sprintf("recovered interrupted update: %s", outcome)})
 	}
 
+	// First launch of a new version?
+	if migrationErr := s.runMigrations(ctx); migrationErr != nil {
+		s.relay.Warn(RlyUpdaterLog{Msg: fmt.Sprintf("post-update migrations failed: %s", migrationErr.Error())})
+	}
+	if skippedErr := s.loadSkippedVersions(); skippedErr != nil {
+		s.relay.Warn(RlyUpdaterLog{Msg: fmt.Sprintf("could not load skipped versions: %s", skippedErr.Error())})
+	}
+
+	// Only once skipped versions are loaded and any interrupted update is
+	// settled can the background check judge what is new
 	needUpdateCheck := true
 	if s.cfg.LastUpdateCheck != nil {
 		now := time.Now()
@@ -127,7 +186,7 @@ func (s *UpdaterSvc) Hydrate(ctx context.Context) error {
 		}
 	}
 
-	if needUpdateCheck && s.status != updaterdto.INOPERATIVE {
+	if needUpdateCheck && s.Status() != updaterdto.INOPERATIVE {
 		s.relay.Debug(RlyUpdaterLog{Msg: "update has crossed check interval, checking in BG"})
 		go func() {
 			if _, checkErr := s.CheckLatest(ctx); checkErr != nil {
@@ -139,20 +198,6 @@ func (s *UpdaterSvc) Hydrate(ctx context.Context) error {
 		s.relay.Debug(RlyUpdaterLog{Msg: "no need to check for updates"})
 	}
 
-	selfPath, err := os.Executable()
-	if err != nil {
-		return err
-	}
-	updateTarget := selfPath
-	if s.cfg.Platform == "darwin" {
-		appBundleRoot := findAppBundleRoot(selfPath)
-		if appBundleRoot != "" {
-			s.relay.Debug(RlyUpdaterLog{Msg: fmt.Sprintf("found app bundle at %s", appBundleRoot)})
-			updateTarget = appBundleRoot
-		}
-	}
-	s.updateTarget = updateTarget
-
 	s.relay.Debug(RlyUpdaterLog{Msg: "end: hydrate state"})
 	return nil
 }
@@ -18,6 +18,7 @@ func ProvideUpdaterSvc(cfg *updaterdto.UpdaterConfig) *UpdaterSvc {
 			netSvc: cfg.NetSvc,
 			relay:  cfg.Relay,
 			status: updaterdto.INITIAL,
+			engine: newDownloadEngine(cfg),
 		}
 	})
 	return service
@@ -0,0 +1,246 @@
+package updatercache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidKey The checksum is not a hex encoded SHA-256
+var ErrInvalidKey = errors.New("cache key must be a hex encoded sha256")
+
+// Cache Content-addressed store of downloaded artefacts keyed by SHA-256.
+// A lock file in the cache directory coordinates processes sharing it: reads
+// take a shared lock, writes and eviction an exclusive one. Entries are
+// re-hashed whenever they are used and least recently used entries are
+// evicted once the cache exceeds its size cap.
+type Cache struct {
+	dir      string
+	maxBytes int64
+}
+
+// New returns a cache rooted at dir. maxBytes caps the total size, 0 for no cap.
+func New(dir string, maxBytes int64) *Cache {
+	return &Cache{dir: dir, maxBytes: maxBytes}
+}
+
+// Key normalises a checksum, accepting an optional "sha256:" prefix
+func Key(checksum string) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(checksum))
+	key = strings.TrimPrefix(key, "sha256:")
+	if len(key) != sha256.Size*2 {
+		return "", ErrInvalidKey
+	}
+	if _, err := hex.DecodeString(key); err != nil {
+		return "", ErrInvalidKey
+	}
+	return key, nil
+}
+
+// Path returns where the entry for key is stored
+func (c *Cache) Path(key string) string {
+	return filepath.Join(c.dir, "objects", key)
+}
+
+// Contains reports whether an entry for checksum exists. Unlike Get it does
+// not verify the entry, so Get may still report a miss.
+func (c *Cache) Contains(checksum string) bool {
+	key, err := Key(checksum)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(c.Path(key))
+	return err == nil
+}
+
+// Get copies the entry for checksum to destination, verifying its hash on the
+// way. A missing entry returns false. A corrupt entry is removed and reported
+// as a miss.
+func (c *Cache) Get(checksum string, destination string) (bool, error) {
+	key, err := Key(checksum)
+	if err != nil {
+		return false, err
+	}
+
+	var corrupt bool
+	hit, err := c.withLock(false, func() (bool, error) {
+		entry, openErr := os.Open(c.Path(key))
+		if errors.Is(openErr, os.ErrNotExist) {
+			return false, nil
+		}
+		if openErr != nil {
+			return false, fmt.Errorf("open cache entry: %w", openErr)
+		}
+		defer entry.Close()
+
+		matches, copyErr := copyVerified(entry, destination, key)
+		if copyErr != nil {
+			return false, copyErr
+		}
+		if !matches {
+			corrupt = true
+			_ = os.Remove(destination)
+			return false, nil
+		}
+		// Modification time doubles as last use for eviction
+		now := time.Now()
+		_ = os.Chtimes(c.Path(key), now, now)
+		return true, nil
+	})
+	if err != nil || !corrupt {
+		return hit, err
+	}
+
+	_, err = c.withLock(true, func() (bool, error) {
+		if removeErr := os.Remove(c.Path(key)); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+			return false, fmt.Errorf("remove corrupt cache entry: %w", removeErr)
+		}
+		return false, nil
+	})
+	return false, err
+}
+
+// Put stores a copy of source under checksum, refusing content that does not
+// match it, then evicts entries beyond the size cap
+func (c *Cache) Put(checksum string, source string) error {
+	key, err := Key(checksum)
+	if err != nil {
+		return err
+	}
+	objectsDir := filepath.Join(c.dir, "objects")
+	if err := os.MkdirAll(objectsDir, 0755); err != nil {
+		return fmt.Errorf("create cache directory: %w", err)
+	}
+
+	sourceFile, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("open artefact: %w", err)
+	}
+	defer sourceFile.Close()
+
+	// Copy outside the lock to a unique name, publishing only takes the lock briefly
+	tmpFile, err := os.CreateTemp(objectsDir, ".tmp-"+key+"-")
+	if err != nil {
+		return fmt.Errorf("create cache entry: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+	tmpFile.Close()
+	matches, err := copyVerified(sourceFile, tmpPath, key)
+	if err != nil {
+		return err
+	}
+	if !matches {
+		return fmt.Errorf("artefact does not match checksum %s", key)
+	}
+
+	_, err = c.withLock(true, func() (bool, error) {
+		if renameErr := os.Rename(tmpPath, c.Path(key)); renameErr != nil {
+			return false, fmt.Errorf("publish cache entry: %w", renameErr)
+		}
+		return false, c.evict(key)
+	})
+	return err
+}
+
+// Evict removes least recently used entries until the cache fits its size cap
+func (c *Cache) Evict() error {
+	_, err := c.withLock(true, func() (bool, error) {
+		return false, c.evict("")
+	})
+	return err
+}
+
+// evict trims the cache to maxBytes, never removing keep. Callers hold the exclusive lock.
+func (c *Cache) evict(keep string) error {
+	if c.maxBytes <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(filepath.Join(c.dir, "objects"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("list cache: %w", err)
+	}
+
+	type cached struct {
+		name    string
+		size    int64
+		lastUse time.Time
+	}
+	var candidates []cached
+	var total int64
+	for _, entry := range entries {
+		if _, keyErr := Key(entry.Name()); keyErr != nil {
+			// In-flight temporary files belong to other processes
+			continue
+		}
+		info, infoErr := entry.Info()
+		if infoErr != nil {
+			continue
+		}
+		total += info.Size()
+		if entry.Name() != keep {
+			candidates = append(candidates, cached{name: entry.Name(), size: info.Size(), lastUse: info.ModTime()})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].lastUse.Before(candidates[j].lastUse)
+	})
+	for _, candidate := range candidates {
+		if total <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(c.Path(candidate.name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("evict %s: %w", candidate.name, err)
+		}
+		total -= candidate.size
+	}
+	return nil
+}
+
+// withLock runs fn holding the cache lock file
+func (c *Cache) withLock(exclusive bool, fn func() (bool, error)) (bool, error) {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return false, fmt.Errorf("create cache directory: %w", err)
+	}
+	lock, err := os.OpenFile(filepath.Join(c.dir, ".lock"), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return false, fmt.Errorf("open cache lock: %w", err)
+	}
+	defer lock.Close()
+	if err := lockFile(lock, exclusive); err != nil {
+		return false, fmt.Errorf("lock cache: %w", err)
+	}
+	defer unlockFile(lock)
+	return fn()
+}
+
+// copyVerified copies src to destination while hashing, reporting whether the
+// content matched key
+func copyVerified(src io.Reader, destination string, key string) (bool, error) {
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return false, fmt.Errorf("create destination folder: %w", err)
+	}
+	dst, err := os.OpenFile(destination, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return false, fmt.Errorf("create %s: %w", destination, err)
+	}
+	hasher := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(dst, hasher), src); err != nil {
+		dst.Close()
+		return false, fmt.Errorf("copy to %s: %w", destination, err)
+	}
+	if err := dst.Close(); err != nil {
+		return false, fmt.Errorf("close %s: %w", destination, err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)) == key, nil
+}
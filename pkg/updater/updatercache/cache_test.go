@@ -0,0 +1,161 @@
+package updatercache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writeArtefact(t *testing.T, dir string, name string, contents string) (string, string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(contents))
+	return path, hex.EncodeToString(sum[:])
+}
+
+func TestCache_PutGet(t *testing.T) {
+	dir := t.TempDir()
+	cache := New(filepath.Join(dir, "cache"), 0)
+	source, checksum := writeArtefact(t, dir, "artefact", "v2")
+
+	destination := filepath.Join(dir, "out", "artefact")
+	if hit, err := cache.Get(checksum, destination); err != nil || hit {
+		t.Fatalf("empty cache: hit=%v err=%v", hit, err)
+	}
+	if cache.Contains(checksum) {
+		t.Fatal("empty cache contains the artefact")
+	}
+	if err := cache.Put("sha256:"+checksum, source); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if !cache.Contains(strings.ToUpper(checksum)) {
+		t.Fatal("cache does not contain the artefact put")
+	}
+	hit, err := cache.Get(checksum, destination)
+	if err != nil || !hit {
+		t.Fatalf("hit=%v err=%v", hit, err)
+	}
+	if contents, _ := os.ReadFile(destination); string(contents) != "v2" {
+		t.Fatalf("destination=%q want v2", contents)
+	}
+}
+
+func TestCache_RejectsBadContent(t *testing.T) {
+	dir := t.TempDir()
+	cache := New(filepath.Join(dir, "cache"), 0)
+	source, _ := writeArtefact(t, dir, "artefact", "v2")
+	_, otherChecksum := writeArtefact(t, dir, "other", "other")
+
+	tests := []struct {
+		name     string
+		checksum string
+		wantErr  error
+	}{
+		{name: "invalid key", checksum: "abc", wantErr: ErrInvalidKey},
+		{name: "content mismatch", checksum: otherChecksum},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := cache.Put(tc.checksum, source)
+			if err == nil {
+				t.Fatal("expected put to fail")
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err=%v want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCache_CorruptEntryIsMiss(t *testing.T) {
+	dir := t.TempDir()
+	cache := New(filepath.Join(dir, "cache"), 0)
+	source, checksum := writeArtefact(t, dir, "artefact", "v2")
+	if err := cache.Put(checksum, source); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cache.Path(checksum), []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	destination := filepath.Join(dir, "out")
+	hit, err := cache.Get(checksum, destination)
+	if err != nil || hit {
+		t.Fatalf("hit=%v err=%v", hit, err)
+	}
+	if _, err := os.Stat(cache.Path(checksum)); !os.IsNotExist(err) {
+		t.Fatalf("corrupt entry kept: %v", err)
+	}
+	if _, err := os.Stat(destination); !os.IsNotExist(err) {
+		t.Fatalf("corrupt copy left at destination: %v", err)
+	}
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	// Room for two of the three 10 byte entries
+	cache := New(filepath.Join(dir, "cache"), 25)
+
+	var checksums []string
+	for i := 0; i < 3; i++ {
+		source, checksum := writeArtefact(t, dir, fmt.Sprintf("artefact-%d", i), fmt.Sprintf("artefact-%d", i)+"!")
+		if i == 2 {
+			// Touch the oldest entry so the middle one becomes least recently used
+			past := time.Now().Add(-time.Hour)
+			_ = os.Chtimes(cache.Path(checksums[1]), past, past)
+			if hit, err := cache.Get(checksums[0], filepath.Join(dir, "out")); err != nil || !hit {
+				t.Fatalf("hit=%v err=%v", hit, err)
+			}
+		}
+		if err := cache.Put(checksum, source); err != nil {
+			t.Fatal(err)
+		}
+		checksums = append(checksums, checksum)
+	}
+
+	for i, wantPresent := range []bool{true, false, true} {
+		_, err := os.Stat(cache.Path(checksums[i]))
+		if present := err == nil; present != wantPresent {
+			t.Fatalf("entry %d present=%v want %v", i, present, wantPresent)
+		}
+	}
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, "cache")
+	source, checksum := writeArtefact(t, dir, "artefact", "v2")
+
+	var waitGroup sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 8; i++ {
+		waitGroup.Add(1)
+		go func(i int) {
+			defer waitGroup.Done()
+			// Separate instances, as separate processes would have
+			cache := New(cacheDir, 0)
+			if err := cache.Put(checksum, source); err != nil {
+				errs <- err
+				return
+			}
+			if _, err := cache.Get(checksum, filepath.Join(dir, fmt.Sprintf("out-%d", i))); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	waitGroup.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
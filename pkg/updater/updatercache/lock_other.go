@@ -0,0 +1,14 @@
+//go:build !unix && !windows
+
+package updatercache
+
+import "os"
+
+// File locking is unavailable, the cache is only safe for a single process
+func lockFile(file *os.File, exclusive bool) error {
+	return nil
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
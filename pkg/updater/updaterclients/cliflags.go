@@ -0,0 +1,23 @@
+package updaterclients
+
+import (
+	"github.com/joy-dx/gophorth/pkg/config/builder"
+	"github.com/joy-dx/gophorth/pkg/config/options"
+	"github.com/spf13/cobra"
+)
+
+const GithubConfigPrefix = "github"
+
+// GithubCobraAndViper Flags for FromGithubConfig. With viper's AutomaticEnv and
+// no environment prefix, the token is also read from GITHUB_TOKEN.
+func GithubCobraAndViper(cmd *cobra.Command) {
+	configBuilder := builder.ConfigBuilder{}
+	configBuilder.SetCommand(cmd)
+	configBuilder.SetConfigPrefix([]string{GithubConfigPrefix})
+	configBuilder.AddStringParam(options.GithubBaseURL, "", "Optional GitHub Enterprise API URL, e.g. https://github.example.com/api/v3/")
+	configBuilder.AddStringParam(options.GithubCacheDir, "", "Where GitHub responses are kept for conditional requests, defaults beside the updater state path")
+	configBuilder.AddStringParam(options.GithubOwner, "", "Owner of the repository releases are published to")
+	configBuilder.AddStringParam(options.GithubRepo, "", "Repository releases are published to")
+	configBuilder.AddStringParam(options.GithubTagPrefix, "", "Only consider release tags starting with it, e.g. app/v")
+	configBuilder.AddStringHiddenParam(options.GithubToken, "", "Access token, raising the rate limit and allowing private repositories")
+}
@@ -0,0 +1,280 @@
+package updaterclients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+const UpdateClientCompositeRef = "composite"
+
+// Composite asks several check clients for the latest release, e.g. an own
+// manifest server with GitHub as a fallback. The chosen asset records the
+// source that supplied it, and downloads are signed by that source.
+type Composite struct {
+	cfg          *CompositeConfig
+	Ref          string
+	FoundVersion *releaserdto.ReleaseAsset
+	// foundClient Source of FoundVersion, asked to sign and authorise its downloads
+	foundClient updaterdto.CheckClientInterface
+}
+
+// compositeAnswer Outcome of asking one source
+type compositeAnswer struct {
+	source  string
+	client  updaterdto.CheckClientInterface
+	asset   releaserdto.ReleaseAsset
+	version *semver.Version
+	err     error
+}
+
+func NewComposite(cfg *CompositeConfig) *Composite {
+	return &Composite{
+		Ref: UpdateClientCompositeRef,
+		cfg: cfg,
+	}
+}
+
+func (c *Composite) GetRef() string {
+	return c.Ref
+}
+
+func (c *Composite) GetVersionLink() (releaserdto.ReleaseAsset, error) {
+	if c.FoundVersion == nil {
+		return releaserdto.ReleaseAsset{}, errors.New("no release found yet, run CheckUpdate first")
+	}
+	return *c.FoundVersion, nil
+}
+
+func (c *Composite) CheckUpdate(ctx context.Context, cfg *updaterdto.UpdaterConfig) (releaserdto.ReleaseAsset, error) {
+	if len(c.cfg.Clients) == 0 {
+		return releaserdto.ReleaseAsset{}, errors.New("CompositeCheckClient: no clients configured")
+	}
+
+	var (
+		chosen compositeAnswer
+		err    error
+	)
+	switch c.cfg.Mode {
+	case COMPOSITE_FIRST_SUCCESS, "":
+		chosen, err = c.firstSuccess(ctx, cfg)
+	case COMPOSITE_HIGHEST_VERSION:
+		chosen, err = c.highestVersion(c.askAll(ctx, cfg))
+	case COMPOSITE_QUORUM:
+		chosen, err = c.quorum(c.askAll(ctx, cfg))
+	default:
+		return releaserdto.ReleaseAsset{}, fmt.Errorf("unknown composite mode %q", c.cfg.Mode)
+	}
+	if err != nil {
+		return releaserdto.ReleaseAsset{}, err
+	}
+	if cfg.Relay != nil {
+		cfg.Relay.Debug(updater.RlyUpdaterLog{Msg: fmt.Sprintf("using %s %s from %s", chosen.asset.ArtefactName, chosen.asset.Version, chosen.source)})
+	}
+
+	chosen.asset.WithSource(chosen.source)
+	c.FoundVersion = &chosen.asset
+	c.foundClient = chosen.client
+	return chosen.asset, nil
+}
+
+// SignDownloadURLs passes the asset to the source that supplied it, when that
+// source has expiring download URLs
+func (c *Composite) SignDownloadURLs(ctx context.Context, asset *releaserdto.ReleaseAsset) error {
+	client := c.foundClient
+	if asset.Source != "" {
+		names := c.sourceNames()
+		for i, name := range names {
+			if name == asset.Source {
+				client = c.cfg.Clients[i]
+				break
+			}
+		}
+	}
+	signer, ok := client.(updaterdto.DownloadURLSignerInterface)
+	if !ok {
+		return nil
+	}
+	return signer.SignDownloadURLs(ctx, asset)
+}
+
+// DownloadHeaders asks the source of the last found release for the headers
+// its downloads need
+func (c *Composite) DownloadHeaders(rawURL string) http.Header {
+	authoriser, ok := c.foundClient.(updaterdto.DownloadHeadersInterface)
+	if !ok {
+		return nil
+	}
+	return authoriser.DownloadHeaders(rawURL)
+}
+
+// ReleaseHistory asks the source of the last found release for past releases
+func (c *Composite) ReleaseHistory(ctx context.Context, cfg *updaterdto.UpdaterConfig) ([]updaterdto.ReleaseNote, error) {
+	history, ok := c.foundClient.(updaterdto.ReleaseHistoryInterface)
+	if !ok {
+		return nil, errors.New("release source cannot list past releases")
+	}
+	return history.ReleaseHistory(ctx, cfg)
+}
+
+// sourceNames names each client by its ref, numbering repeated refs so two
+// clients of the same kind can be told apart
+func (c *Composite) sourceNames() []string {
+	seen := make(map[string]int, len(c.cfg.Clients))
+	names := make([]string, len(c.cfg.Clients))
+	for i, client := range c.cfg.Clients {
+		ref := client.GetRef()
+		seen[ref]++
+		if seen[ref] > 1 {
+			ref = fmt.Sprintf("%s_%d", ref, seen[ref])
+		}
+		names[i] = ref
+	}
+	return names
+}
+
+// ask queries one source, turning answers that fail RequireSignature or do
+// not carry a semantic version in to errors
+func (c *Composite) ask(ctx context.Context, cfg *updaterdto.UpdaterConfig, source string, client updaterdto.CheckClientInterface) compositeAnswer {
+	answer := compositeAnswer{source: source, client: client}
+	answer.asset, answer.err = client.CheckUpdate(ctx, cfg)
+	switch {
+	case answer.err != nil:
+	case c.cfg.RequireSignature && answer.asset.Signature == "":
+		answer.err = fmt.Errorf("%s %s is unsigned", answer.asset.ArtefactName, answer.asset.Version)
+	default:
+		version, versionErr := semver.NewVersion(answer.asset.Version)
+		if versionErr != nil {
+			answer.err = fmt.Errorf("version %q: %w", answer.asset.Version, versionErr)
+			break
+		}
+		answer.version = version
+	}
+	if answer.err != nil {
+		answer.err = fmt.Errorf("%s: %w", source, answer.err)
+		if cfg.Relay != nil {
+			cfg.Relay.Warn(updater.RlyUpdaterLog{Msg: fmt.Sprintf("update source failed: %s", answer.err.Error())})
+		}
+	}
+	return answer
+}
+
+// firstSuccess asks sources one at a time, in priority order
+func (c *Composite) firstSuccess(ctx context.Context, cfg *updaterdto.UpdaterConfig) (compositeAnswer, error) {
+	var errs []error
+	for i, source := range c.sourceNames() {
+		if err := ctx.Err(); err != nil {
+			return compositeAnswer{}, err
+		}
+		answer := c.ask(ctx, cfg, source, c.cfg.Clients[i])
+		if answer.err == nil {
+			return answer, nil
+		}
+		errs = append(errs, answer.err)
+	}
+	return compositeAnswer{}, fmt.Errorf("all update sources failed: %w", errors.Join(errs...))
+}
+
+// askAll queries every source at once, keeping answers in priority order
+func (c *Composite) askAll(ctx context.Context, cfg *updaterdto.UpdaterConfig) []compositeAnswer {
+	names := c.sourceNames()
+	answers := make([]compositeAnswer, len(names))
+	var wg sync.WaitGroup
+	for i, source := range names {
+		wg.Add(1)
+		go func(i int, source string) {
+			defer wg.Done()
+			answers[i] = c.ask(ctx, cfg, source, c.cfg.Clients[i])
+		}(i, source)
+	}
+	wg.Wait()
+	return answers
+}
+
+// highestVersion picks the highest version found, earlier sources winning ties
+func (c *Composite) highestVersion(answers []compositeAnswer) (compositeAnswer, error) {
+	var (
+		best compositeAnswer
+		errs []error
+	)
+	for _, answer := range answers {
+		if answer.err != nil {
+			errs = append(errs, answer.err)
+			continue
+		}
+		if best.version == nil || answer.version.GreaterThan(best.version) {
+			best = answer
+		}
+	}
+	if best.version == nil {
+		return compositeAnswer{}, fmt.Errorf("all update sources failed: %w", errors.Join(errs...))
+	}
+	return best, nil
+}
+
+// quorum picks the highest version for which enough sources report the same
+// checksum. Answers without a checksum cannot vote.
+func (c *Composite) quorum(answers []compositeAnswer) (compositeAnswer, error) {
+	needed := c.cfg.Quorum
+	if needed <= 0 {
+		needed = len(answers)/2 + 1
+	}
+
+	type vote struct {
+		first compositeAnswer
+		count int
+	}
+	var (
+		votes []*vote
+		errs  []error
+	)
+	for _, answer := range answers {
+		if answer.err != nil {
+			errs = append(errs, answer.err)
+			continue
+		}
+		checksum := strings.ToLower(answer.asset.Checksum)
+		if checksum == "" {
+			errs = append(errs, fmt.Errorf("%s: %s %s has no checksum", answer.source, answer.asset.ArtefactName, answer.asset.Version))
+			continue
+		}
+		var matched bool
+		for _, v := range votes {
+			if v.first.version.Equal(answer.version) && strings.EqualFold(v.first.asset.Checksum, checksum) {
+				v.count++
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			votes = append(votes, &vote{first: answer, count: 1})
+		}
+	}
+
+	var best *vote
+	for _, v := range votes {
+		if v.count < needed {
+			continue
+		}
+		if best == nil || v.first.version.GreaterThan(best.first.version) {
+			best = v
+		}
+	}
+	if best == nil {
+		seen := make([]string, 0, len(votes))
+		for _, v := range votes {
+			seen = append(seen, fmt.Sprintf("%s %s from %d", v.first.asset.Version, v.first.asset.Checksum, v.count))
+		}
+		noQuorum := fmt.Errorf("no release reported by %d of %d update sources (%s)", needed, len(answers), strings.Join(seen, ", "))
+		return compositeAnswer{}, errors.Join(append([]error{noQuorum}, errs...)...)
+	}
+	return best.first, nil
+}
@@ -0,0 +1,56 @@
+package updaterclients
+
+import "github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+
+// CompositeMode How a Composite combines the answers of its sources
+type CompositeMode string
+
+const (
+	// COMPOSITE_FIRST_SUCCESS Ask sources in order, using the first that answers
+	COMPOSITE_FIRST_SUCCESS CompositeMode = "first_success"
+	// COMPOSITE_HIGHEST_VERSION Ask every source, using the highest version found
+	COMPOSITE_HIGHEST_VERSION CompositeMode = "highest_version"
+	// COMPOSITE_QUORUM Ask every source, using the highest version enough sources agree on the checksum of
+	COMPOSITE_QUORUM CompositeMode = "quorum"
+)
+
+// CompositeConfig Service configuration struct
+type CompositeConfig struct {
+	// Clients Sources in priority order, earlier ones win ties
+	Clients []updaterdto.CheckClientInterface `json:"-" yaml:"-" mapstructure:"-"`
+	Mode    CompositeMode                     `json:"mode" yaml:"mode" mapstructure:"mode"`
+	// Quorum Sources that must report the same checksum in COMPOSITE_QUORUM mode, 0 for a majority of Clients
+	Quorum int `json:"quorum" yaml:"quorum" mapstructure:"quorum"`
+	// RequireSignature Ignore answers without a signature, e.g. for "highest signed version"
+	RequireSignature bool `json:"require_signature" yaml:"require_signature" mapstructure:"require_signature"`
+}
+
+func DefaultCompositeConfig() CompositeConfig {
+	return CompositeConfig{
+		Mode: COMPOSITE_FIRST_SUCCESS,
+	}
+}
+
+func (c *CompositeConfig) GetRef() string {
+	return UpdateClientCompositeRef + "_config"
+}
+
+func (c *CompositeConfig) WithClients(clients ...updaterdto.CheckClientInterface) *CompositeConfig {
+	c.Clients = clients
+	return c
+}
+
+func (c *CompositeConfig) WithMode(mode CompositeMode) *CompositeConfig {
+	c.Mode = mode
+	return c
+}
+
+func (c *CompositeConfig) WithQuorum(quorum int) *CompositeConfig {
+	c.Quorum = quorum
+	return c
+}
+
+func (c *CompositeConfig) WithRequireSignature(truthy bool) *CompositeConfig {
+	c.RequireSignature = truthy
+	return c
+}
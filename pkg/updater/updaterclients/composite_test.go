@@ -0,0 +1,122 @@
+package updaterclients
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/relay"
+)
+
+func TestComposite_CheckUpdate(t *testing.T) {
+	source := func(ref string, version string, checksum string, signature string, err error) updaterdto.CheckClientInterface {
+		cfg := DefaultFromNetConfig()
+		cfg.UserFetchFunction = func(ctx context.Context, agentCfg NetAgentCfg) (releaserdto.ReleaseAsset, error) {
+			if err != nil {
+				return releaserdto.ReleaseAsset{}, err
+			}
+			asset := releaserdto.ReleaseAsset{ArtefactName: "app-" + version, Version: version, Checksum: checksum, Signature: signature}
+			return asset, nil
+		}
+		client := NewFromNet(&cfg)
+		client.Ref = ref
+		return client
+	}
+	down := errors.New("unreachable")
+
+	tests := []struct {
+		name             string
+		mode             CompositeMode
+		quorum           int
+		requireSignature bool
+		noRelay          bool
+		clients          []updaterdto.CheckClientInterface
+		wantVersion      string
+		wantSource       string
+		wantErr          bool
+	}{
+		{
+			name:        "first success falls back",
+			mode:        COMPOSITE_FIRST_SUCCESS,
+			clients:     []updaterdto.CheckClientInterface{source("manifest", "", "", "", down), source("github", "1.0.0", "aa", "", nil), source("gitlab", "2.0.0", "bb", "", nil)},
+			wantVersion: "1.0.0",
+			wantSource:  "github",
+		},
+		{
+			name:        "first success without a relay",
+			mode:        COMPOSITE_FIRST_SUCCESS,
+			noRelay:     true,
+			clients:     []updaterdto.CheckClientInterface{source("manifest", "", "", "", down), source("github", "1.0.0", "aa", "", nil)},
+			wantVersion: "1.0.0",
+			wantSource:  "github",
+		},
+		{
+			name:    "first success all down",
+			mode:    COMPOSITE_FIRST_SUCCESS,
+			clients: []updaterdto.CheckClientInterface{source("manifest", "", "", "", down), source("github", "", "", "", down)},
+			wantErr: true,
+		},
+		{
+			name:        "highest version with repeated refs",
+			mode:        COMPOSITE_HIGHEST_VERSION,
+			clients:     []updaterdto.CheckClientInterface{source("from_net", "1.0.0", "aa", "", nil), source("from_net", "1.2.0", "bb", "", nil), source("github", "", "", "", down)},
+			wantVersion: "1.2.0",
+			wantSource:  "from_net_2",
+		},
+		{
+			name:             "highest signed version",
+			mode:             COMPOSITE_HIGHEST_VERSION,
+			requireSignature: true,
+			clients:          []updaterdto.CheckClientInterface{source("manifest", "1.0.0", "aa", "sig", nil), source("github", "1.2.0", "bb", "", nil)},
+			wantVersion:      "1.0.0",
+			wantSource:       "manifest",
+		},
+		{
+			name:        "quorum majority",
+			mode:        COMPOSITE_QUORUM,
+			clients:     []updaterdto.CheckClientInterface{source("manifest", "1.1.0", "aa", "", nil), source("github", "1.1.0", "AA", "", nil), source("mirror", "1.2.0", "cc", "", nil)},
+			wantVersion: "1.1.0",
+			wantSource:  "manifest",
+		},
+		{
+			name:    "quorum checksum disagreement",
+			mode:    COMPOSITE_QUORUM,
+			clients: []updaterdto.CheckClientInterface{source("manifest", "1.1.0", "aa", "", nil), source("github", "1.1.0", "bb", "", nil)},
+			wantErr: true,
+		},
+		{
+			name:        "quorum of one",
+			mode:        COMPOSITE_QUORUM,
+			quorum:      1,
+			clients:     []updaterdto.CheckClientInterface{source("manifest", "1.1.0", "aa", "", nil), source("github", "1.3.0", "bb", "", nil)},
+			wantVersion: "1.3.0",
+			wantSource:  "github",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			updaterCfg := updaterdto.DefaultUpdaterSvcConfig()
+			if !tc.noRelay {
+				updaterCfg.WithRelay(&relay.RelaySvc{})
+			}
+
+			compositeCfg := DefaultCompositeConfig()
+			compositeCfg.WithClients(tc.clients...).
+				WithMode(tc.mode).
+				WithQuorum(tc.quorum).
+				WithRequireSignature(tc.requireSignature)
+			asset, err := NewComposite(&compositeCfg).CheckUpdate(context.Background(), &updaterCfg)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err=%v wantErr=%v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if asset.Version != tc.wantVersion || asset.Source != tc.wantSource {
+				t.Fatalf("version=%s source=%s want %s %s", asset.Version, asset.Source, tc.wantVersion, tc.wantSource)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package updaterclients
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/joy-dx/gophorth/pkg/archive"
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+	"github.com/joy-dx/gophorth/pkg/file"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+const UpdateClientFromBundleRef = "from_bundle"
+
+// FromBundle reads releases from an offline bundle on a local path. The chosen
+// artefact is unpacked to the temporary path and offered as a file:// download
+// so DownloadUpdate verifies it exactly as it would a network download.
+type FromBundle struct {
+	cfg          *FromBundleConfig
+	Ref          string
+	FoundVersion releaserdto.ReleaseAsset
+	// bundleDir Where the last check unpacked the bundle
+	bundleDir string
+}
+
+func NewFromBundle(cfg *FromBundleConfig) *FromBundle {
+	return &FromBundle{
+		Ref: UpdateClientFromBundleRef,
+		cfg: cfg,
+	}
+}
+
+func (c *FromBundle) GetRef() string {
+	return c.Ref
+}
+
+func (c *FromBundle) GetVersionLink() (releaserdto.ReleaseAsset, error) {
+	return c.FoundVersion, nil
+}
+
+// CheckUpdate unpacks the bundle manifest, verifying its signature when the
+// updater has a public key, and selects the asset for the current device
+func (c *FromBundle) CheckUpdate(ctx context.Context, cfg *updaterdto.UpdaterConfig) (releaserdto.ReleaseAsset, error) {
+	if c.cfg.Path == "" {
+		return releaserdto.ReleaseAsset{}, errors.New("FromBundleCheckClient: missing bundle path")
+	}
+	if err := os.MkdirAll(cfg.TemporaryPath, 0755); err != nil {
+		return releaserdto.ReleaseAsset{}, err
+	}
+	// Keyed by the bundle contents so repeated checks reuse one extraction
+	checksum, err := cryptography.Sha256SumFile(c.cfg.Path)
+	if err != nil {
+		return releaserdto.ReleaseAsset{}, fmt.Errorf("read bundle: %w", err)
+	}
+	bundleDir := filepath.Join(cfg.TemporaryPath, "gophorth-bundle-"+checksum[:16])
+	if c.bundleDir != "" && c.bundleDir != bundleDir {
+		_ = os.RemoveAll(c.bundleDir)
+	}
+	c.bundleDir = ""
+	if err := os.MkdirAll(bundleDir, 0755); err != nil {
+		return releaserdto.ReleaseAsset{}, fmt.Errorf("bundle staging: %w", err)
+	}
+
+	asset, err := c.readBundle(ctx, cfg, bundleDir)
+	if err != nil {
+		_ = os.RemoveAll(bundleDir)
+		return releaserdto.ReleaseAsset{}, err
+	}
+	c.bundleDir = bundleDir
+	c.FoundVersion = asset
+	return asset, nil
+}
+
+func (c *FromBundle) readBundle(ctx context.Context, cfg *updaterdto.UpdaterConfig, bundleDir string) (releaserdto.ReleaseAsset, error) {
+	if err := extractBundle(ctx, c.cfg.Path, bundleDir, releaserdto.BUNDLE_MANIFEST, releaserdto.BUNDLE_MANIFEST_SIGNATURE, releaserdto.BUNDLE_CHECKSUMS); err != nil {
+		return releaserdto.ReleaseAsset{}, err
+	}
+	manifestPath := filepath.Join(bundleDir, releaserdto.BUNDLE_MANIFEST)
+	if err := verifyBundleManifest(cfg, manifestPath, filepath.Join(bundleDir, releaserdto.BUNDLE_MANIFEST_SIGNATURE)); err != nil {
+		return releaserdto.ReleaseAsset{}, err
+	}
+
+	var manifest releaserdto.BundleManifest
+	if err := file.FileToStruct(manifestPath, &manifest); err != nil {
+		return releaserdto.ReleaseAsset{}, fmt.Errorf("bundle manifest: %w", err)
+	}
+	if manifest.Format != releaserdto.BUNDLE_FORMAT_VERSION {
+		return releaserdto.ReleaseAsset{}, fmt.Errorf("unsupported bundle format %d", manifest.Format)
+	}
+
+	var asset releaserdto.ReleaseAsset
+	found := false
+	for _, candidate := range manifest.Release.Assets {
+		if candidate.Platform == cfg.Platform && candidate.Arch == cfg.Architecture && candidate.Variant == cfg.Variant {
+			asset, found = candidate, true
+			break
+		}
+	}
+	if !found {
+		return releaserdto.ReleaseAsset{}, fmt.Errorf("bundle has no artefact for %s %s %s", cfg.Platform, cfg.Architecture, cfg.Variant)
+	}
+	if asset.ArtefactName == "" || filepath.Base(asset.ArtefactName) != asset.ArtefactName {
+		return releaserdto.ReleaseAsset{}, fmt.Errorf("invalid bundle artefact name %q", asset.ArtefactName)
+	}
+	if asset.Version == "" {
+		asset.WithVersion(manifest.Release.Version)
+	}
+	asset.WithReleaseSummary(manifest.Release)
+
+	if err := extractBundle(ctx, c.cfg.Path, bundleDir, asset.ArtefactName, asset.ArtefactName+".asc"); err != nil {
+		return releaserdto.ReleaseAsset{}, err
+	}
+	artefactPath := filepath.Join(bundleDir, asset.ArtefactName)
+	if _, err := os.Stat(artefactPath); err != nil {
+		return releaserdto.ReleaseAsset{}, fmt.Errorf("bundle artefact: %w", err)
+	}
+	// The signed manifest is authoritative, checksums.txt must agree with it
+	if err := attachSidecars(&asset, artefactPath, filepath.Join(bundleDir, releaserdto.BUNDLE_CHECKSUMS)); err != nil {
+		return releaserdto.ReleaseAsset{}, fmt.Errorf("bundle: %w", err)
+	}
+	if asset.Checksum == "" {
+		return releaserdto.ReleaseAsset{}, fmt.Errorf("bundle has no checksum for %s", asset.ArtefactName)
+	}
+
+	downloadURL, err := localFileURL(artefactPath)
+	if err != nil {
+		return releaserdto.ReleaseAsset{}, err
+	}
+	asset.WithDownloadURL(downloadURL).WithMirrors(nil)
+
+	if cfg.Relay != nil {
+		cfg.Relay.Debug(updater.RlyUpdaterLog{Msg: fmt.Sprintf("found %s %s in bundle %s", asset.ArtefactName, asset.Version, c.cfg.Path)})
+	}
+	return asset, nil
+}
+
+func extractBundle(ctx context.Context, bundlePath string, dest string, names ...string) error {
+	opts := archive.DefaultExtractOptions()
+	opts.Overwrite = true
+	opts.IncludePatterns = names
+	if err := archive.Extract(ctx, bundlePath, dest, opts); err != nil {
+		return fmt.Errorf("read bundle: %w", err)
+	}
+	return nil
+}
+
+// verifyBundleManifest checks the manifest signature against the updater public
+// key. Without a key the manifest is trusted as is, as other clients do.
+func verifyBundleManifest(cfg *updaterdto.UpdaterConfig, manifestPath string, signaturePath string) error {
+	if cfg.PublicKey == "" {
+		if cfg.Relay != nil {
+			cfg.Relay.Warn(updater.RlyUpdaterLog{Msg: "no public key configured, bundle manifest not verified"})
+		}
+		return nil
+	}
+	signature, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return fmt.Errorf("bundle manifest is not signed: %w", err)
+	}
+	keyInfo, err := cryptography.DetectSignatureInformation([]byte(cfg.PublicKey))
+	if err != nil {
+		return fmt.Errorf("could not detect public key information: %w", err)
+	}
+	switch keyInfo.Format {
+	case "PGP":
+		keyRing, err := cryptography.LoadKeyRingAuto([]byte(cfg.PublicKey))
+		if err != nil {
+			return fmt.Errorf("load public key: %w", err)
+		}
+		err = cryptography.PGPVerifyFile(keyRing, manifestPath, *bytes.NewBuffer(signature))
+		if err != nil {
+			return fmt.Errorf("bundle manifest signature: %w", err)
+		}
+	case "X509":
+		publicKey, err := cryptography.ParseECDSAPublicKeyFromPEM(cfg.PublicKey)
+		if err != nil {
+			return fmt.Errorf("load public key: %w", err)
+		}
+		if err := cryptography.ECDSAVerifyFile(publicKey, manifestPath, string(signature)); err != nil {
+			return fmt.Errorf("bundle manifest signature: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported public key format %s", keyInfo.Format)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package updaterclients
+
+// FromBundleConfig Service configuration struct
+type FromBundleConfig struct {
+	// Path Offline bundle produced by the releaser, e.g. on a USB drive
+	Path string `json:"path" yaml:"path" mapstructure:"path"`
+}
+
+func DefaultFromBundleConfig() FromBundleConfig {
+	return FromBundleConfig{}
+}
+
+func (c *FromBundleConfig) GetRef() string {
+	return UpdateClientFromBundleRef + "_config"
+}
+
+func (c *FromBundleConfig) WithPath(path string) *FromBundleConfig {
+	c.Path = path
+	return c
+}
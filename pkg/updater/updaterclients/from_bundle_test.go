@@ -0,0 +1,108 @@
+package updaterclients
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+	"github.com/joy-dx/gophorth/pkg/releaser"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/relay"
+)
+
+func TestFromBundle_CheckUpdate(t *testing.T) {
+	ctx := context.Background()
+	privateKey, publicKey, err := cryptography.ECDSACreateKey(*cryptography.DefaultECDSACreateKeyCfg())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPublicKey, err := cryptography.ECDSACreateKey(*cryptography.DefaultECDSACreateKeyCfg())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targetPath := t.TempDir()
+	for _, name := range []string{"app-example-linux-amd64", "app-example-darwin-arm64"} {
+		if err := os.WriteFile(filepath.Join(targetPath, name), []byte("v2 "+name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bundlePath := filepath.Join(t.TempDir(), "app-example-2.0.0.tar.gz")
+
+	releaserCfg := releaserdto.DefaultReleaserConfig()
+	releaserCfg.WithRelay(&relay.RelaySvc{}).
+		WithVersion("2.0.0").
+		WithPrivateKey(privateKey).
+		WithTargetPath(targetPath).
+		WithOutputPath(t.TempDir()).
+		WithFilePattern("app-example-{platform}-{arch}").
+		WithBundlePath(bundlePath)
+	releaserSvc := releaser.ProvideReleaserSvc(&releaserCfg)
+	if err := releaserSvc.Hydrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := releaserSvc.GenerateReleaseSummary(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		publicKey string
+		platform  string
+		wantErr   string
+	}{
+		{name: "signed manifest", publicKey: publicKey, platform: "linux"},
+		{name: "no public key", platform: "linux"},
+		{name: "wrong public key", publicKey: otherPublicKey, platform: "linux", wantErr: "manifest signature"},
+		{name: "no artefact for device", publicKey: publicKey, platform: "windows", wantErr: "no artefact"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			updaterCfg := updaterdto.DefaultUpdaterSvcConfig()
+			updaterCfg.WithTemporaryPath(t.TempDir()).
+				WithRelay(&relay.RelaySvc{}).
+				WithPublicKey(tc.publicKey)
+			updaterCfg.Platform = tc.platform
+			updaterCfg.Architecture = "amd64"
+			updaterCfg.Variant = ""
+
+			bundleCfg := DefaultFromBundleConfig()
+			bundleCfg.WithPath(bundlePath)
+			client := NewFromBundle(&bundleCfg)
+			asset, err := client.CheckUpdate(ctx, &updaterCfg)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("err=%v want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if asset.Version != "2.0.0" || asset.SignatureType != "X509" {
+				t.Fatalf("asset=%+v", asset)
+			}
+			artefactURL, err := url.Parse(asset.DownloadURL)
+			if err != nil || artefactURL.Scheme != "file" {
+				t.Fatalf("download url=%s", asset.DownloadURL)
+			}
+			if err := cryptography.Sha256SumVerify(filepath.FromSlash(artefactURL.Path), asset.Checksum); err != nil {
+				t.Fatalf("unpacked artefact: %v", err)
+			}
+
+			// Checking again reuses the extraction rather than leaving another copy
+			if _, err := client.CheckUpdate(ctx, &updaterCfg); err != nil {
+				t.Fatal(err)
+			}
+			extracted, err := filepath.Glob(filepath.Join(updaterCfg.TemporaryPath, "gophorth-bundle-*"))
+			if err != nil || len(extracted) != 1 {
+				t.Fatalf("extractions=%v err=%v", extracted, err)
+			}
+		})
+	}
+}
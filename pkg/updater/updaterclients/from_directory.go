@@ -0,0 +1,135 @@
+package updaterclients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+	"github.com/joy-dx/gophorth/pkg/file"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+const UpdateClientFromDirectoryRef = "from_directory"
+
+// FromDirectory finds releases in a local or network share directory, either
+// from a releaser manifest or by parsing artefact file names, and offers the
+// highest compatible version as a file:// download
+type FromDirectory struct {
+	cfg          *FromDirectoryConfig
+	Ref          string
+	FoundVersion releaserdto.ReleaseAsset
+}
+
+func NewFromDirectory(cfg *FromDirectoryConfig) *FromDirectory {
+	return &FromDirectory{
+		Ref: UpdateClientFromDirectoryRef,
+		cfg: cfg,
+	}
+}
+
+func (c *FromDirectory) GetRef() string {
+	return c.Ref
+}
+
+func (c *FromDirectory) GetVersionLink() (releaserdto.ReleaseAsset, error) {
+	return c.FoundVersion, nil
+}
+
+func (c *FromDirectory) CheckUpdate(ctx context.Context, cfg *updaterdto.UpdaterConfig) (releaserdto.ReleaseAsset, error) {
+	if c.cfg.Path == "" {
+		return releaserdto.ReleaseAsset{}, errors.New("FromDirectoryCheckClient: missing directory path")
+	}
+
+	var (
+		candidates []releaserdto.ReleaseAsset
+		err        error
+	)
+	manifestPath := filepath.Join(c.cfg.Path, c.cfg.ManifestName)
+	if exists, _ := file.PathExists(manifestPath); c.cfg.ManifestName != "" && exists {
+		candidates, err = c.manifestAssets(manifestPath)
+	} else {
+		candidates, err = c.scanAssets()
+	}
+	if err != nil {
+		return releaserdto.ReleaseAsset{}, err
+	}
+
+	asset, found := selectHighestAsset(cfg, candidates)
+	if !found {
+		return releaserdto.ReleaseAsset{}, fmt.Errorf("no release in %s for %s %s %s", c.cfg.Path, cfg.Platform, cfg.Architecture, cfg.Variant)
+	}
+
+	artefactPath := filepath.Join(c.cfg.Path, asset.ArtefactName)
+	info, err := os.Stat(artefactPath)
+	if err != nil {
+		return releaserdto.ReleaseAsset{}, fmt.Errorf("release artefact: %w", err)
+	}
+	if err := attachSidecars(&asset, artefactPath, filepath.Join(c.cfg.Path, releaserdto.BUNDLE_CHECKSUMS)); err != nil {
+		return releaserdto.ReleaseAsset{}, err
+	}
+	if asset.Checksum == "" {
+		// Still worth having so a file replaced on the share mid-copy is caught
+		checksum, checksumErr := cryptography.Sha256SumFile(artefactPath)
+		if checksumErr != nil {
+			return releaserdto.ReleaseAsset{}, checksumErr
+		}
+		asset.WithChecksum(checksum)
+	}
+	downloadURL, err := localFileURL(artefactPath)
+	if err != nil {
+		return releaserdto.ReleaseAsset{}, err
+	}
+	asset.WithDownloadURL(downloadURL).
+		WithMirrors(nil).
+		WithSize(info.Size())
+
+	if cfg.Relay != nil {
+		cfg.Relay.Debug(updater.RlyUpdaterLog{Msg: fmt.Sprintf("found %s %s in %s", asset.ArtefactName, asset.Version, c.cfg.Path)})
+	}
+	c.FoundVersion = asset
+	return asset, nil
+}
+
+// manifestAssets reads the releaser summary at manifestPath
+func (c *FromDirectory) manifestAssets(manifestPath string) ([]releaserdto.ReleaseAsset, error) {
+	var summary releaserdto.ReleaseSummary
+	if err := file.FileToStruct(manifestPath, &summary); err != nil {
+		return nil, fmt.Errorf("release manifest: %w", err)
+	}
+	return summaryAssets(summary), nil
+}
+
+// ReleaseHistory reads the notes of past releases from the releaser history index
+func (c *FromDirectory) ReleaseHistory(ctx context.Context, cfg *updaterdto.UpdaterConfig) ([]updaterdto.ReleaseNote, error) {
+	if c.cfg.HistoryName == "" {
+		return nil, errors.New("FromDirectoryCheckClient: no history index configured")
+	}
+	var history releaserdto.ReleaseHistory
+	if err := file.FileToStruct(filepath.Join(c.cfg.Path, c.cfg.HistoryName), &history); err != nil {
+		return nil, fmt.Errorf("release history: %w", err)
+	}
+	return historyNotes(history), nil
+}
+
+// scanAssets parses the directory file names with FilePattern, as ReleaserSvc.ScanDir does
+func (c *FromDirectory) scanAssets() ([]releaserdto.ReleaseAsset, error) {
+	if c.cfg.FilePattern == "" {
+		return nil, errors.New("FromDirectoryCheckClient: no manifest found and no file pattern configured")
+	}
+	entries, err := os.ReadDir(c.cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("read dir %q: %w", c.cfg.Path, err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return patternAssets(c.cfg.FilePattern, c.cfg.AllowAnyExtension, names)
+}
@@ -0,0 +1,53 @@
+package updaterclients
+
+import "github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+
+// FromDirectoryConfig Service configuration struct
+type FromDirectoryConfig struct {
+	// Path Directory of published artefacts, e.g. a mounted NFS or SMB share
+	Path string `json:"path" yaml:"path" mapstructure:"path"`
+	// ManifestName Releaser summary within Path, used in place of file name parsing when present
+	ManifestName string `json:"manifest_name" yaml:"manifest_name" mapstructure:"manifest_name"`
+	// HistoryName Releaser history index within Path, read for the notes of skipped versions
+	HistoryName string `json:"history_name" yaml:"history_name" mapstructure:"history_name"`
+	// FilePattern Reverse template for artefact names, e.g. "app-example-{platform}-{arch}{variant}{version}"
+	FilePattern string `json:"file_pattern" yaml:"file_pattern" mapstructure:"file_pattern"`
+	// AllowAnyExtension Allows a file extension after the pattern (".zip", ".tar.gz", etc.)
+	AllowAnyExtension bool `json:"allow_any_extension" yaml:"allow_any_extension" mapstructure:"allow_any_extension"`
+}
+
+func DefaultFromDirectoryConfig() FromDirectoryConfig {
+	return FromDirectoryConfig{
+		ManifestName: "version.json",
+		HistoryName:  releaserdto.RELEASE_HISTORY,
+	}
+}
+
+func (c *FromDirectoryConfig) GetRef() string {
+	return UpdateClientFromDirectoryRef + "_config"
+}
+
+func (c *FromDirectoryConfig) WithPath(path string) *FromDirectoryConfig {
+	c.Path = path
+	return c
+}
+
+func (c *FromDirectoryConfig) WithManifestName(name string) *FromDirectoryConfig {
+	c.ManifestName = name
+	return c
+}
+
+func (c *FromDirectoryConfig) WithHistoryName(name string) *FromDirectoryConfig {
+	c.HistoryName = name
+	return c
+}
+
+func (c *FromDirectoryConfig) WithFilePattern(pattern string) *FromDirectoryConfig {
+	c.FilePattern = pattern
+	return c
+}
+
+func (c *FromDirectoryConfig) WithAllowAnyExtension(truthy bool) *FromDirectoryConfig {
+	c.AllowAnyExtension = truthy
+	return c
+}
@@ -0,0 +1,99 @@
+package updaterclients
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+	"github.com/joy-dx/gophorth/pkg/file"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/relay"
+)
+
+func TestFromDirectory_CheckUpdate(t *testing.T) {
+	shareDir := t.TempDir()
+	for _, name := range []string{
+		"app-example-linux-amd64-1.0.0",
+		"app-example-linux-amd64-1.10.0",
+		"app-example-linux-amd64-1.9.0",
+		"app-example-linux-amd64-webkit241-3.0.0",
+		"app-example-linux-amd64-2.0.0-beta.1",
+		"app-example-darwin-arm64-4.0.0",
+		"notes.txt",
+	} {
+		if err := os.WriteFile(filepath.Join(shareDir, name), []byte(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checksum, err := cryptography.Sha256SumFile(filepath.Join(shareDir, "app-example-linux-amd64-1.10.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(shareDir, releaserdto.BUNDLE_CHECKSUMS), []byte(checksum+"  app-example-linux-amd64-1.10.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manifestDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(manifestDir, "app-example"), []byte("v5"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.StructToJSONFile(releaserdto.ReleaseSummary{
+		Version: "5.0.0",
+		Assets:  []releaserdto.ReleaseAsset{{ArtefactName: "app-example", Platform: "linux", Arch: "amd64"}},
+	}, filepath.Join(manifestDir, "version.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		dir             string
+		variant         string
+		allowPrerelease bool
+		wantArtefact    string
+		wantVersion     string
+		wantChecksum    string
+		wantErr         string
+	}{
+		{name: "highest stable version", dir: shareDir, wantArtefact: "app-example-linux-amd64-1.10.0", wantVersion: "1.10.0", wantChecksum: checksum},
+		{name: "prerelease allowed", dir: shareDir, allowPrerelease: true, wantArtefact: "app-example-linux-amd64-2.0.0-beta.1", wantVersion: "2.0.0-beta.1"},
+		{name: "variant", dir: shareDir, variant: "webkit241", wantArtefact: "app-example-linux-amd64-webkit241-3.0.0", wantVersion: "3.0.0"},
+		{name: "no compatible release", dir: shareDir, variant: "qt", wantErr: "no release"},
+		{name: "manifest", dir: manifestDir, wantArtefact: "app-example", wantVersion: "5.0.0"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			updaterCfg := updaterdto.DefaultUpdaterSvcConfig()
+			updaterCfg.WithRelay(&relay.RelaySvc{})
+			updaterCfg.Platform = "linux"
+			updaterCfg.Architecture = "amd64"
+			updaterCfg.Variant = tc.variant
+			updaterCfg.AllowPrerelease = tc.allowPrerelease
+
+			directoryCfg := DefaultFromDirectoryConfig()
+			directoryCfg.WithPath(tc.dir).WithFilePattern("app-example-{platform}-{arch}{variant}{version}")
+			asset, err := NewFromDirectory(&directoryCfg).CheckUpdate(context.Background(), &updaterCfg)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("err=%v want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if asset.ArtefactName != tc.wantArtefact || asset.Version != tc.wantVersion {
+				t.Fatalf("artefact=%s version=%s want %s %s", asset.ArtefactName, asset.Version, tc.wantArtefact, tc.wantVersion)
+			}
+			if !strings.HasPrefix(asset.DownloadURL, "file://") || !strings.HasSuffix(asset.DownloadURL, "/"+tc.wantArtefact) {
+				t.Fatalf("download url=%s", asset.DownloadURL)
+			}
+			if tc.wantChecksum != "" && asset.Checksum != tc.wantChecksum {
+				t.Fatalf("checksum=%s want %s", asset.Checksum, tc.wantChecksum)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package updaterclients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+const UpdateClientFromGiteaRef = "from_gitea"
+
+// giteaPageSize Releases requested per page, the default maximum of Gitea instances
+const giteaPageSize = 50
+
+type FromGitea struct {
+	cfg          *FromGiteaConfig
+	Ref          string
+	FoundVersion *releaserdto.ReleaseAsset
+}
+
+func NewFromGitea(cfg *FromGiteaConfig) *FromGitea {
+	return &FromGitea{
+		Ref: UpdateClientFromGiteaRef,
+		cfg: cfg,
+	}
+}
+
+func (c *FromGitea) GetRef() string {
+	return c.Ref
+}
+
+func (c *FromGitea) GetVersionLink() (releaserdto.ReleaseAsset, error) {
+	if c.FoundVersion == nil {
+		return releaserdto.ReleaseAsset{}, errors.New("no release found yet, run CheckUpdate first")
+	}
+	return *c.FoundVersion, nil
+}
+
+// ListReleases returns the published releases of the repository, newest first.
+// Drafts are only visible, and returned, with a token allowed to see them.
+func (c *FromGitea) ListReleases(ctx context.Context) ([]GiteaRelease, error) {
+	var releases []GiteaRelease
+	if err := c.getJSON(ctx, c.repoURL("releases")+fmt.Sprintf("?limit=%d", giteaPageSize), &releases); err != nil {
+		return nil, fmt.Errorf("gitea release list: %w", err)
+	}
+	return releases, nil
+}
+
+// ReleaseHistory lists the notes of the published releases
+func (c *FromGitea) ReleaseHistory(ctx context.Context, cfg *updaterdto.UpdaterConfig) ([]updaterdto.ReleaseNote, error) {
+	releases, err := c.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	notes := make([]updaterdto.ReleaseNote, 0, len(releases))
+	for _, release := range releases {
+		if release.Draft {
+			continue
+		}
+		notes = append(notes, updaterdto.ReleaseNote{
+			Version:     release.TagName,
+			PublishedAt: release.PublishedAt,
+			Changelog:   release.Body,
+			ReleaseURL:  release.HTMLURL,
+		})
+	}
+	return notes, nil
+}
+
+func (c *FromGitea) CheckUpdate(ctx context.Context, cfg *updaterdto.UpdaterConfig) (releaserdto.ReleaseAsset, error) {
+	asset := releaserdto.ReleaseAsset{}
+	if c.cfg.Owner == "" || c.cfg.Repo == "" {
+		return asset, errors.New("FromGiteaCheckClient: missing owner or repo")
+	}
+
+	release, err := c.findRelease(ctx, cfg)
+	if err != nil {
+		return asset, err
+	}
+
+	remoteAssets := make([]remoteAsset, 0, len(release.Assets))
+	for _, releaseAsset := range release.Assets {
+		remoteAssets = append(remoteAssets, remoteAsset{
+			Name: releaseAsset.Name,
+			URL:  releaseAsset.BrowserDownloadURL,
+			Size: releaseAsset.Size,
+		})
+	}
+	chosen, found, err := selectRemoteAsset(cfg, c.cfg.SelectAssetPattern, remoteAssets)
+	if err != nil {
+		return asset, fmt.Errorf("gitea asset selection: %w", err)
+	}
+
+	checksum, signature, sidecarErr := discoverSidecars(ctx, c.fetch, remoteAssets, chosen)
+	if errors.Is(sidecarErr, updaterdto.ErrChecksumConflict) {
+		return asset, sidecarErr
+	}
+	if sidecarErr != nil && cfg.Relay != nil {
+		cfg.Relay.Warn(updater.RlyUpdaterLog{Msg: fmt.Sprintf("problem reading release sidecars for %s: %s", chosen.Name, sidecarErr.Error())})
+	}
+
+	asset.WithPlatform(found.Platform).
+		WithArch(found.Arch).
+		WithVariant(found.Variant).
+		WithDownloadURL(chosen.URL).
+		WithChecksum(checksum).
+		WithSize(chosen.Size).
+		WithVersion(release.TagName).
+		WithChangelog(release.Body).
+		WithPublishedAt(release.PublishedAt).
+		WithReleaseURL(release.HTMLURL)
+
+	if signature != "" {
+		sigInfo, sigInfoErr := cryptography.DetectSignatureInformation([]byte(signature))
+		if sigInfoErr != nil {
+			if cfg.Relay != nil {
+				cfg.Relay.Warn(updater.RlyUpdaterLog{Msg: fmt.Sprintf("problem detecting signature info: %s", sigInfoErr.Error())})
+			}
+		} else {
+			asset.WithSignatureType(sigInfo.Format)
+			asset.WithSignature(signature)
+		}
+	}
+
+	c.FoundVersion = &asset
+	return asset, nil
+}
+
+// findRelease fetches the configured tag, or the newest published release
+// that, unless allowed, is not a prerelease
+func (c *FromGitea) findRelease(ctx context.Context, cfg *updaterdto.UpdaterConfig) (*GiteaRelease, error) {
+	if c.cfg.Tag != "" {
+		var release GiteaRelease
+		if err := c.getJSON(ctx, c.repoURL("releases", "tags", url.PathEscape(c.cfg.Tag)), &release); err != nil {
+			return nil, fmt.Errorf("gitea release fetch: %w", err)
+		}
+		if release.Prerelease && !cfg.AllowPrerelease {
+			return nil, fmt.Errorf("release %s is a prerelease, but prereleases not allowed", release.TagName)
+		}
+		return &release, nil
+	}
+
+	releases, err := c.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range releases {
+		if releases[idx].Draft {
+			continue
+		}
+		if (releases[idx].Prerelease || isPrereleaseTag(releases[idx].TagName)) && !cfg.AllowPrerelease {
+			continue
+		}
+		return &releases[idx], nil
+	}
+	return nil, errors.New("gitea release fetch: no published release found")
+}
+
+func (c *FromGitea) repoURL(parts ...string) string {
+	base := strings.TrimRight(c.cfg.BaseURL, "/") + "/api/v1/repos/" + url.PathEscape(c.cfg.Owner) + "/" + url.PathEscape(c.cfg.Repo)
+	if len(parts) == 0 {
+		return base
+	}
+	return base + "/" + strings.Join(parts, "/")
+}
+
+func (c *FromGitea) getJSON(ctx context.Context, rawURL string, target interface{}) error {
+	body, err := c.fetch(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, target)
+}
+
+// DownloadHeaders sends the token with downloads from the instance, which
+// private repositories need, see updaterdto.DownloadHeadersInterface
+func (c *FromGitea) DownloadHeaders(rawURL string) http.Header {
+	if c.cfg.Token == "" || !sameHost(rawURL, c.cfg.BaseURL) {
+		return nil
+	}
+	header := http.Header{}
+	header.Set("Authorization", "token "+c.cfg.Token)
+	return header
+}
+
+// fetch performs a GET, sending the token to the instance itself only
+func (c *FromGitea) fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	return httpFetch(ctx, c.cfg.Client, rawURL, func(req *http.Request) {
+		if c.cfg.Token != "" && sameHost(rawURL, c.cfg.BaseURL) {
+			req.Header.Set("Authorization", "token "+c.cfg.Token)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package updaterclients
+
+import (
+	"net/http"
+	"time"
+)
+
+// FromGiteaConfig Service configuration struct, also suited to Forgejo and Codeberg
+type FromGiteaConfig struct {
+	// Client HTTP client used for API requests, defaults to http.DefaultClient
+	Client *http.Client
+	// BaseURL Instance URL, e.g. "https://codeberg.org"
+	BaseURL string `json:"base_url" yaml:"base_url" mapstructure:"base_url"`
+	Owner   string `json:"owner" yaml:"owner" mapstructure:"owner"`
+	Repo    string `json:"repo" yaml:"repo" mapstructure:"repo"`
+	// Token Optional access token for private repositories
+	Token string `json:"-" yaml:"-" mapstructure:"token"`
+	// Optional: if specified, use that tag; otherwise, latest release.
+	Tag string `json:"tag" yaml:"tag" mapstructure:"tag"`
+	// SelectAssetPattern A template style string representing the file name wanted
+	SelectAssetPattern string `json:"select_asset_pattern" yaml:"select_asset_pattern" mapstructure:"select_asset_pattern"`
+}
+
+func DefaultFromGiteaConfig() FromGiteaConfig {
+	return FromGiteaConfig{
+		BaseURL: "https://codeberg.org",
+	}
+}
+
+func (c *FromGiteaConfig) GetRef() string {
+	return UpdateClientFromGiteaRef + "_config"
+}
+
+func (c *FromGiteaConfig) WithClient(client *http.Client) *FromGiteaConfig {
+	c.Client = client
+	return c
+}
+
+func (c *FromGiteaConfig) WithBaseURL(url string) *FromGiteaConfig {
+	c.BaseURL = url
+	return c
+}
+
+func (c *FromGiteaConfig) WithOwner(owner string) *FromGiteaConfig {
+	c.Owner = owner
+	return c
+}
+
+func (c *FromGiteaConfig) WithRepo(repo string) *FromGiteaConfig {
+	c.Repo = repo
+	return c
+}
+
+func (c *FromGiteaConfig) WithToken(token string) *FromGiteaConfig {
+	c.Token = token
+	return c
+}
+
+func (c *FromGiteaConfig) WithTag(tag string) *FromGiteaConfig {
+	c.Tag = tag
+	return c
+}
+
+func (c *FromGiteaConfig) WithSelectAssetPattern(pattern string) *FromGiteaConfig {
+	c.SelectAssetPattern = pattern
+	return c
+}
+
+// GiteaRelease Subset of the Gitea / Forgejo releases API response
+type GiteaRelease struct {
+	ID          int64               `json:"id"`
+	TagName     string              `json:"tag_name"`
+	Name        string              `json:"name"`
+	Body        string              `json:"body"`
+	Draft       bool                `json:"draft"`
+	Prerelease  bool                `json:"prerelease"`
+	PublishedAt *time.Time          `json:"published_at"`
+	HTMLURL     string              `json:"html_url"`
+	Assets      []GiteaReleaseAsset `json:"assets"`
+}
+
+// GiteaReleaseAsset A file attached to a release
+type GiteaReleaseAsset struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
@@ -0,0 +1,113 @@
+package updaterclients
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/relay"
+)
+
+func TestFromGitea_CheckUpdate(t *testing.T) {
+	const checksum = "fb04dcb6970e4c3d1873de51fd5a50d7bb46b3383113602665c350ec40b5f990"
+	var releases []GiteaRelease
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/files/checksums.txt" {
+			_, _ = w.Write([]byte(checksum + "  tool_2.0.0_linux_amd64.tar.gz\n"))
+			return
+		}
+		if r.Header.Get("Authorization") != "token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		const prefix = "/api/v1/repos/org/tool/releases"
+		switch {
+		case r.URL.Path == prefix:
+			_ = json.NewEncoder(w).Encode(releases)
+		case strings.HasPrefix(r.URL.Path, prefix+"/tags/"):
+			tag := strings.TrimPrefix(r.URL.Path, prefix+"/tags/")
+			for _, release := range releases {
+				if release.TagName == tag {
+					_ = json.NewEncoder(w).Encode(release)
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	release := func(tag string, draft bool, prerelease bool, names ...string) GiteaRelease {
+		r := GiteaRelease{TagName: tag, Draft: draft, Prerelease: prerelease}
+		for _, name := range names {
+			r.Assets = append(r.Assets, GiteaReleaseAsset{Name: name, Size: 2, BrowserDownloadURL: server.URL + "/files/" + name})
+		}
+		return r
+	}
+	releases = []GiteaRelease{
+		release("v3.0.0", true, false, "tool_3.0.0_linux_amd64.tar.gz"),
+		release("v2.1.0", false, true, "tool_2.1.0_linux_amd64.tar.gz"),
+		release("v2.0.0", false, false, "tool_2.0.0_darwin_arm64.tar.gz", "tool_2.0.0_linux_amd64.tar.gz", "app-linux-amd64-qt.zip", "checksums.txt"),
+		release("v1.0.0", false, false, "tool_1.0.0_linux_amd64.tar.gz"),
+	}
+
+	tests := []struct {
+		name            string
+		tag             string
+		pattern         string
+		variant         string
+		allowPrerelease bool
+		wantVersion     string
+		wantAsset       string
+		wantChecksum    string
+	}{
+		{name: "latest stable by name guess", wantVersion: "v2.0.0", wantAsset: "tool_2.0.0_linux_amd64.tar.gz", wantChecksum: checksum},
+		{name: "prerelease allowed", allowPrerelease: true, wantVersion: "v2.1.0", wantAsset: "tool_2.1.0_linux_amd64.tar.gz"},
+		{name: "tag", tag: "v1.0.0", wantVersion: "v1.0.0", wantAsset: "tool_1.0.0_linux_amd64.tar.gz"},
+		{name: "pattern", pattern: "app-{platform}-{arch}{variant}", variant: "qt", wantVersion: "v2.0.0", wantAsset: "app-linux-amd64-qt.zip"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			updaterCfg := updaterdto.DefaultUpdaterSvcConfig()
+			updaterCfg.WithRelay(&relay.RelaySvc{})
+			updaterCfg.Platform = "linux"
+			updaterCfg.Architecture = "amd64"
+			updaterCfg.Variant = tc.variant
+			updaterCfg.AllowPrerelease = tc.allowPrerelease
+
+			giteaCfg := DefaultFromGiteaConfig()
+			giteaCfg.WithBaseURL(server.URL).
+				WithOwner("org").
+				WithRepo("tool").
+				WithToken("secret").
+				WithTag(tc.tag).
+				WithSelectAssetPattern(tc.pattern)
+			asset, err := NewFromGitea(&giteaCfg).CheckUpdate(context.Background(), &updaterCfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if asset.Version != tc.wantVersion || asset.DownloadURL != server.URL+"/files/"+tc.wantAsset {
+				t.Fatalf("version=%s url=%s want %s %s", asset.Version, asset.DownloadURL, tc.wantVersion, tc.wantAsset)
+			}
+			if tc.wantChecksum != "" && asset.Checksum != tc.wantChecksum {
+				t.Fatalf("checksum=%s want %s", asset.Checksum, tc.wantChecksum)
+			}
+		})
+	}
+}
+
+func TestFromGitea_DownloadHeaders(t *testing.T) {
+	testPrivateDownload(t, func(baseURL string, token string) updaterdto.DownloadHeadersInterface {
+		cfg := DefaultFromGiteaConfig()
+		cfg.WithBaseURL(baseURL).WithOwner("joy-dx").WithRepo("app-example").WithToken(token)
+		return NewFromGitea(&cfg)
+	}, func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "token secret"
+	})
+}
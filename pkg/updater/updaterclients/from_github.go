@@ -2,11 +2,19 @@ package updaterclients
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/google/go-github/v81/github"
 	"github.com/joy-dx/gophorth/pkg/cryptography"
 	"github.com/joy-dx/gophorth/pkg/file"
@@ -19,10 +27,20 @@ import (
 
 const UpdateClientFromGithubRef = "from_net"
 
+// githubPageSize Releases requested per page, the API maximum
+const githubPageSize = 100
+
+// githubRequestTimeout Applied to clients built from Token and BaseURL
+const githubRequestTimeout = 30 * time.Second
+
 type FromGithub struct {
 	cfg          *FromGithubConfig
 	Ref          string
 	FoundVersion *releaserdto.ReleaseAsset
+	client       *github.Client
+	cacheDir     string
+	stateDir     string
+	rateLimit    *updaterdto.RateLimitError
 }
 
 func NewFromGithub(cfg *FromGithubConfig) *FromGithub {
@@ -40,51 +58,373 @@ func (c *FromGithub) GetVersionLink() (releaserdto.ReleaseAsset, error) {
 	return *c.FoundVersion, nil
 }
 
-// TODO With both reverse template and asset name guesser, refactor combining both
+// ListReleases returns the releases of the repository, newest first, paging
+// through at most MaxReleasePages pages
+func (c *FromGithub) ListReleases(ctx context.Context) ([]*github.RepositoryRelease, error) {
+	client, err := c.api()
+	if err != nil {
+		return nil, err
+	}
+	var releases []*github.RepositoryRelease
+	opts := &github.ListOptions{PerPage: githubPageSize}
+	for page := 0; c.cfg.MaxReleasePages <= 0 || page < c.cfg.MaxReleasePages; page++ {
+		pageReleases, resp, err := client.Repositories.ListReleases(ctx, c.cfg.Owner, c.cfg.Repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("github release list: %w", c.checkRateLimit(client, err))
+		}
+		releases = append(releases, pageReleases...)
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return releases, nil
+}
+
 // TODO Rethink asset designation strategy to be more efficient
 func (c *FromGithub) CheckUpdate(ctx context.Context, cfg *updaterdto.UpdaterConfig) (releaserdto.ReleaseAsset, error) {
 	asset := releaserdto.ReleaseAsset{}
 
 	if hydrateErr := hydrate.NilCheck("github_check_update", map[string]interface{}{
-		"client": c.cfg.Client,
 		"netSvc": cfg.NetSvc,
 	}); hydrateErr != nil {
 		return asset, hydrateErr
 	}
+	if cfg.StatePath != "" {
+		c.stateDir = filepath.Dir(cfg.StatePath)
+	}
+	client, err := c.api()
+	if err != nil {
+		return asset, err
+	}
+	// Back off until the host's rate limit resets rather than spending requests on refusals
+	if c.rateLimit != nil && time.Now().Before(c.rateLimit.ResetAt) {
+		return asset, fmt.Errorf("github release fetch: %w", c.rateLimit)
+	}
+
+	var candidates []*github.RepositoryRelease
+	if c.cfg.Tag != "" {
+		foundRelease, _, err := client.Repositories.GetReleaseByTag(ctx, c.cfg.Owner, c.cfg.Repo, c.cfg.Tag)
+		if err != nil {
+			return asset, fmt.Errorf("github release fetch: %w", c.checkRateLimit(client, err))
+		}
+		// Pre-release handling
+		if foundRelease.GetPrerelease() && !cfg.AllowPrerelease {
+			return asset, fmt.Errorf("release %s is a prerelease, but prereleases not allowed", foundRelease.GetTagName())
+		}
+		candidates = []*github.RepositoryRelease{foundRelease}
+	} else {
+		releases, err := c.ListReleases(ctx)
+		if err != nil {
+			return asset, err
+		}
+		if candidates = c.rankReleases(cfg, releases); len(candidates) == 0 {
+			return asset, fmt.Errorf("github release fetch: no published release tagged %s<semver>", c.cfg.TagPrefix)
+		}
+	}
 
 	var (
 		foundRelease *github.RepositoryRelease
 		chosenAsset  *github.ReleaseAsset
 		variant      string
-		err          error
+		agentConfig  GithubAgentCfg
+		selectErrs   []error
 	)
+	// Releases are ranked highest first, the first with an asset for this device wins
+	for _, candidate := range candidates {
+		asset = releaserdto.ReleaseAsset{}
+		agentConfig = GithubAgentCfg{
+			NetSvc:        cfg.NetSvc,
+			UpdaterCfg:    updaterdto.UpdaterConfig{},
+			GithubRelease: candidate,
+			VersionLink:   &asset,
+		}
+		githubAsset, assetVariant, selectErr := c.selectAsset(ctx, cfg, &agentConfig)
+		if selectErr != nil {
+			selectErrs = append(selectErrs, fmt.Errorf("%s: %w", candidate.GetTagName(), selectErr))
+			continue
+		}
+		foundRelease, chosenAsset, variant = candidate, githubAsset, assetVariant
+		break
+	}
+	if foundRelease == nil {
+		return asset, fmt.Errorf("github asset selection: %w", errors.Join(selectErrs...))
+	}
+	if len(selectErrs) > 0 && cfg.Relay != nil {
+		cfg.Relay.Debug(updater.RlyUpdaterLog{Msg: fmt.Sprintf("skipped %d newer releases without a matching asset, using %s", len(selectErrs), foundRelease.GetTagName())})
+	}
 
-	if c.cfg.Tag != "" {
-		foundRelease, _, err = c.cfg.Client.Repositories.GetReleaseByTag(ctx, c.cfg.Owner, c.cfg.Repo, c.cfg.Tag)
-	} else {
-		foundRelease, _, err = c.cfg.Client.Repositories.GetLatestRelease(ctx, c.cfg.Owner, c.cfg.Repo)
+	checksum := strings.TrimPrefix(strings.ToLower(chosenAsset.GetDigest()), "sha256:")
+	var signature string
+	if c.cfg.DiscoverSidecars {
+		remoteAssets, fetch := c.releaseFiles(foundRelease)
+		sidecarChecksum, sidecarSignature, sidecarErr := discoverSidecars(ctx, fetch, remoteAssets, remoteAsset{
+			Name:   chosenAsset.GetName(),
+			URL:    chosenAsset.GetBrowserDownloadURL(),
+			Digest: chosenAsset.GetDigest(),
+		})
+		if errors.Is(sidecarErr, updaterdto.ErrChecksumConflict) {
+			return asset, sidecarErr
+		}
+		if sidecarErr != nil && cfg.Relay != nil {
+			cfg.Relay.Warn(updater.RlyUpdaterLog{Msg: fmt.Sprintf("problem reading release sidecars for %s: %s", chosenAsset.GetName(), sidecarErr.Error())})
+		}
+		if sidecarChecksum != "" {
+			checksum = sidecarChecksum
+		}
+		signature = sidecarSignature
 	}
+
+	asset.WithDownloadURL(chosenAsset.GetBrowserDownloadURL()).
+		WithVariant(variant).
+		WithChecksum(checksum).
+		WithSize(int64(chosenAsset.GetSize())).
+		WithVersion(c.tagVersion(foundRelease.GetTagName())).
+		WithChangelog(foundRelease.GetBody()).
+		WithPublishedAt(foundRelease.PublishedAt.GetTime()).
+		WithReleaseURL(foundRelease.GetHTMLURL())
+
+	if c.cfg.GetSignatureFunc != nil {
+		sig, getSigErr := c.cfg.GetSignatureFunc(ctx, &agentConfig)
+		if getSigErr != nil {
+			cfg.Relay.Warn(updater.RlyUpdaterLog{Msg: fmt.Sprintf("Failed to get signature for %s: %s", chosenAsset.GetBrowserDownloadURL(), getSigErr.Error())})
+		} else {
+			signature = sig
+		}
+	}
+	if signature != "" {
+		sigInfo, sigInfoErr := cryptography.DetectSignatureInformation([]byte(signature))
+		if sigInfoErr != nil {
+			cfg.Relay.Warn(updater.RlyUpdaterLog{Msg: fmt.Sprintf("problem detecting signature info: %s", sigInfoErr.Error())})
+		} else {
+			asset.WithSignatureType(sigInfo.Format)
+			asset.WithSignature(signature)
+		}
+	}
+
+	c.FoundVersion = &asset
+
+	return asset, nil
+}
+
+// releaseFiles lists the assets of a release for sidecar discovery, with a
+// fetch that downloads them through the API so private repositories work
+func (c *FromGithub) releaseFiles(release *github.RepositoryRelease) ([]remoteAsset, fetchFunc) {
+	assets := make([]remoteAsset, 0, len(release.Assets))
+	ids := make(map[string]int64, len(release.Assets))
+	for _, releaseAsset := range release.Assets {
+		assets = append(assets, remoteAsset{
+			Name:   releaseAsset.GetName(),
+			URL:    releaseAsset.GetBrowserDownloadURL(),
+			Size:   int64(releaseAsset.GetSize()),
+			Digest: releaseAsset.GetDigest(),
+		})
+		ids[releaseAsset.GetBrowserDownloadURL()] = releaseAsset.GetID()
+	}
+	fetch := func(ctx context.Context, rawURL string) ([]byte, error) {
+		id, ok := ids[rawURL]
+		if !ok {
+			return nil, fmt.Errorf("%s is not an asset of release %s", rawURL, release.GetTagName())
+		}
+		// Redirects go to short lived signed URLs, which need no credentials
+		client, err := c.api()
+		if err != nil {
+			return nil, err
+		}
+		contents, _, err := client.Repositories.DownloadReleaseAsset(ctx, c.cfg.Owner, c.cfg.Repo, id, http.DefaultClient)
+		if err != nil {
+			return nil, err
+		}
+		defer contents.Close()
+		return io.ReadAll(io.LimitReader(contents, releaseMaxResponseBytes))
+	}
+	return assets, fetch
+}
+
+// api returns the configured Client, or builds one from Token and BaseURL
+// that revalidates responses cached in CacheDir, by default under the
+// updater's state directory
+func (c *FromGithub) api() (*github.Client, error) {
+	if c.cfg.Client != nil {
+		return c.cfg.Client, nil
+	}
+	if c.client != nil {
+		return c.client, nil
+	}
+
+	cacheDir := c.cfg.CacheDir
+	if cacheDir == "" && c.stateDir != "" {
+		cacheDir = filepath.Join(c.stateDir, "github")
+	}
+	client := github.NewClient(&http.Client{
+		Timeout:   githubRequestTimeout,
+		Transport: &etagTransport{dir: cacheDir, next: http.DefaultTransport},
+	})
+	if c.cfg.Token != "" {
+		client = client.WithAuthToken(c.cfg.Token)
+	}
+	if c.cfg.BaseURL != "" {
+		enterpriseClient, err := client.WithEnterpriseURLs(c.cfg.BaseURL, c.cfg.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("github base url: %w", err)
+		}
+		client = enterpriseClient
+	}
+	c.client, c.cacheDir = client, cacheDir
+
+	// A limit hit by an earlier run still applies
+	if contents, err := os.ReadFile(c.rateLimitPath()); err == nil {
+		var limited updaterdto.RateLimitError
+		if json.Unmarshal(contents, &limited) == nil {
+			c.rateLimit = &limited
+		}
+	}
+	return client, nil
+}
+
+// checkRateLimit turns rate limit responses in to a RateLimitError, recording
+// when requests may resume so later checks back off
+func (c *FromGithub) checkRateLimit(client *github.Client, err error) error {
+	limited := githubRateLimit(client.BaseURL.Host, err)
+	if limited == nil {
+		return err
+	}
+	c.rateLimit = limited
+	if c.cacheDir != "" {
+		if contents, marshalErr := json.Marshal(limited); marshalErr == nil && os.MkdirAll(c.cacheDir, 0700) == nil {
+			_ = os.WriteFile(c.rateLimitPath(), contents, 0600)
+		}
+	}
+	return limited
+}
+
+func (c *FromGithub) rateLimitPath() string {
+	if c.cacheDir == "" {
+		return ""
+	}
+	return filepath.Join(c.cacheDir, "rate_limit.json")
+}
+
+// githubRateLimit recognises primary and secondary rate limit errors, and
+// 429 responses go-github leaves generic
+func githubRateLimit(host string, err error) *updaterdto.RateLimitError {
+	var (
+		rateErr  *github.RateLimitError
+		abuseErr *github.AbuseRateLimitError
+		respErr  *github.ErrorResponse
+		limited  *updaterdto.RateLimitError
+	)
+	switch {
+	case errors.As(err, &rateErr):
+		limited = &updaterdto.RateLimitError{Host: host, Limit: rateErr.Rate.Limit, Remaining: rateErr.Rate.Remaining, ResetAt: rateErr.Rate.Reset.Time}
+	case errors.As(err, &abuseErr):
+		limited = &updaterdto.RateLimitError{Host: host, ResetAt: time.Now().Add(time.Minute)}
+		if abuseErr.RetryAfter != nil {
+			limited.ResetAt = time.Now().Add(*abuseErr.RetryAfter)
+		}
+	case errors.As(err, &respErr) && respErr.Response != nil && respErr.Response.StatusCode == http.StatusTooManyRequests:
+		limited = &updaterdto.RateLimitError{Host: host, ResetAt: rateLimitReset(respErr.Response.Header)}
+	default:
+		return nil
+	}
+	if limited.ResetAt.IsZero() {
+		limited.ResetAt = time.Now().Add(time.Minute)
+	}
+	return limited
+}
+
+// rateLimitReset reads when to retry from Retry-After or X-RateLimit-Reset,
+// defaulting to a minute
+func rateLimitReset(header http.Header) time.Time {
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		return time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	if epoch, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		return time.Unix(epoch, 0)
+	}
+	return time.Now().Add(time.Minute)
+}
+
+// ReleaseHistory lists the notes of the releases ListReleases returns, ranked
+// as CheckUpdate ranks them
+func (c *FromGithub) ReleaseHistory(ctx context.Context, cfg *updaterdto.UpdaterConfig) ([]updaterdto.ReleaseNote, error) {
+	if c.rateLimit != nil && time.Now().Before(c.rateLimit.ResetAt) {
+		return nil, fmt.Errorf("github release list: %w", c.rateLimit)
+	}
+	releases, err := c.ListReleases(ctx)
 	if err != nil {
-		return asset, fmt.Errorf("github release fetch: %w", err)
+		return nil, err
+	}
+	ranked := c.rankReleases(cfg, releases)
+	notes := make([]updaterdto.ReleaseNote, 0, len(ranked))
+	for _, release := range ranked {
+		notes = append(notes, updaterdto.ReleaseNote{
+			Version:     c.tagVersion(release.GetTagName()),
+			PublishedAt: release.PublishedAt.GetTime(),
+			Changelog:   release.GetBody(),
+			ReleaseURL:  release.GetHTMLURL(),
+		})
+	}
+	return notes, nil
+}
+
+// rankReleases drops drafts, tags without TagPrefix or a semantic version and,
+// unless allowed, prereleases, ordering the rest highest version first
+func (c *FromGithub) rankReleases(cfg *updaterdto.UpdaterConfig, releases []*github.RepositoryRelease) []*github.RepositoryRelease {
+	type rankedRelease struct {
+		release *github.RepositoryRelease
+		version *semver.Version
+	}
+	ranked := make([]rankedRelease, 0, len(releases))
+	for _, release := range releases {
+		if release.GetDraft() || !strings.HasPrefix(release.GetTagName(), c.cfg.TagPrefix) {
+			continue
+		}
+		version, err := semver.NewVersion(c.tagVersion(release.GetTagName()))
+		if err != nil {
+			continue
+		}
+		if (release.GetPrerelease() || version.Prerelease() != "") && !cfg.AllowPrerelease {
+			continue
+		}
+		ranked = append(ranked, rankedRelease{release: release, version: version})
 	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].version.GreaterThan(ranked[j].version)
+	})
 
-	// Pre-release handling
-	if foundRelease.GetPrerelease() && !cfg.AllowPrerelease {
-		return asset, fmt.Errorf("latest is prerelease (%s), but prereleases not allowed", foundRelease.GetTagName())
+	sorted := make([]*github.RepositoryRelease, 0, len(ranked))
+	for _, candidate := range ranked {
+		sorted = append(sorted, candidate.release)
 	}
+	return sorted
+}
 
-	agentConfig := GithubAgentCfg{
-		NetSvc:        cfg.NetSvc,
-		UpdaterCfg:    updaterdto.UpdaterConfig{},
-		GithubRelease: foundRelease,
-		VersionLink:   &asset,
+// tagVersion strips TagPrefix from a tag, e.g. "app/v1.2.0" to "1.2.0"
+func (c *FromGithub) tagVersion(tag string) string {
+	version := strings.TrimPrefix(tag, c.cfg.TagPrefix)
+	if c.cfg.TagPrefix == "" {
+		return version
 	}
+	return strings.TrimPrefix(version, "v")
+}
+
+// selectAsset picks the asset of a release built for the current device,
+// filling in the platform and architecture of agentConfig.VersionLink
+func (c *FromGithub) selectAsset(ctx context.Context, cfg *updaterdto.UpdaterConfig, agentConfig *GithubAgentCfg) (*github.ReleaseAsset, string, error) {
+	var (
+		release     = agentConfig.GithubRelease
+		asset       = agentConfig.VersionLink
+		chosenAsset *github.ReleaseAsset
+		variant     string
+	)
 
+	// TODO With both reverse template and asset name guesser, refactor combining both
 	switch true {
 	case c.cfg.SelectAssetFunc != nil:
-		githubAsset, assetVariant, selErr := c.cfg.SelectAssetFunc(ctx, &agentConfig)
+		githubAsset, assetVariant, selErr := c.cfg.SelectAssetFunc(ctx, agentConfig)
 		if selErr != nil {
-			return asset, selErr
+			return nil, "", selErr
 		}
 		chosenAsset, variant = githubAsset, assetVariant
 	case c.cfg.SelectAssetPattern != "":
@@ -94,51 +434,44 @@ func (c *FromGithub) CheckUpdate(ctx context.Context, cfg *updaterdto.UpdaterCon
 			RequireVersion:    false,
 		})
 		if compileTemplateErr != nil {
-			return asset, compileTemplateErr
+			return nil, "", compileTemplateErr
 		}
-		asset.Version = foundRelease.GetTagName()
-		for idx := range foundRelease.Assets {
-			name := foundRelease.Assets[idx].GetName()
+		for idx := range release.Assets {
+			name := release.Assets[idx].GetName()
 
-			// Skip signature files that may be present
-			if strings.HasSuffix(name, ".asc") || strings.HasSuffix(name, ".asc.sig") {
+			// Skip signature and checksum sidecars published alongside the artefact
+			if isSignatureName(name) || isChecksumName(name) {
 				continue
 			}
 
-			matches := re.FindStringSubmatch(name)
-			if matches == nil {
+			// Matched without the extension, which the optional {variant} would otherwise swallow
+			g, ok := stringz.MatchReverseTemplate(re, trimArchiveExtension(name))
+			if !ok {
 				continue
 			}
 
-			groupNames := re.SubexpNames()
-			g := make(map[string]string, len(groupNames))
-			for i, n := range groupNames {
-				if i == 0 || n == "" {
-					continue
-				}
-				g[n] = matches[i]
+			// Check if the asset matches our criteria
+			if cfg.Platform != g["platform"] ||
+				cfg.Architecture != g["arch"] ||
+				cfg.Variant != g["variant"] {
+				continue
 			}
-
 			asset.
 				WithArch(g["arch"]).
 				WithPlatform(g["platform"])
-
-			// Check if the asset matches our criteria
-			if cfg.Platform != asset.Platform ||
-				cfg.Architecture != asset.Arch ||
-				cfg.Variant != asset.Variant {
-				continue
-			}
-			chosenAsset = foundRelease.Assets[idx]
-			variant = strings.TrimLeft(g["variant"], "/-_")
+			chosenAsset = release.Assets[idx]
+			variant = g["variant"]
 
 			break
 		}
+		if chosenAsset == nil {
+			return nil, "", errors.New("no asset matches pattern")
+		}
 	default:
 		// Default: best-effort choose asset based on name patterns + cfg.Platform/Architecture
-		githubAsset, variantFound, selectErr := selectGitHubAssetDefault(cfg, foundRelease.Assets)
+		githubAsset, variantFound, selectErr := selectGitHubAssetDefault(cfg, release.Assets)
 		if selectErr != nil {
-			return asset, fmt.Errorf("github asset selection: %w", selectErr)
+			return nil, "", selectErr
 		}
 		chosenAsset, variant = githubAsset, variantFound
 	}
@@ -146,7 +479,7 @@ func (c *FromGithub) CheckUpdate(ctx context.Context, cfg *updaterdto.UpdaterCon
 	if asset.Platform == "" || asset.Arch == "" {
 		foundPlatform, foundArchitecture := file.AssetNameGuess(chosenAsset.GetName())
 		if foundPlatform == "" || foundArchitecture == "" {
-			return asset, errors.New("no asset found for platform/architecture")
+			return nil, "", errors.New("no asset found for platform/architecture")
 		}
 		if asset.Platform == "" {
 			asset.WithPlatform(foundPlatform)
@@ -155,31 +488,7 @@ func (c *FromGithub) CheckUpdate(ctx context.Context, cfg *updaterdto.UpdaterCon
 			asset.WithArch(foundArchitecture)
 		}
 	}
-
-	asset.WithDownloadURL(chosenAsset.GetBrowserDownloadURL()).
-		WithVariant(variant).
-		WithChecksum(strings.Replace(chosenAsset.GetDigest(), "sha256:", "", 1)).
-		WithSize(int64(chosenAsset.GetSize())).
-		WithVersion(foundRelease.GetTagName())
-
-	if c.cfg.GetSignatureFunc != nil {
-		sig, getSigErr := c.cfg.GetSignatureFunc(ctx, &agentConfig)
-		if getSigErr != nil {
-			cfg.Relay.Warn(updater.RlyUpdaterLog{Msg: fmt.Sprintf("Failed to get signature for %s: %s", chosenAsset.GetBrowserDownloadURL(), getSigErr.Error())})
-		} else {
-			sigInfo, sigInfoErr := cryptography.DetectSignatureInformation([]byte(sig))
-			if sigInfoErr != nil {
-				cfg.Relay.Warn(updater.RlyUpdaterLog{Msg: fmt.Sprintf("problem detecting signature info: %s", sigInfoErr.Error())})
-			} else {
-				asset.WithSignatureType(sigInfo.Format)
-				asset.WithSignature(sig)
-			}
-		}
-	}
-
-	c.FoundVersion = &asset
-
-	return asset, nil
+	return chosenAsset, variant, nil
 }
 
 func selectGitHubAssetDefault(cfg *updaterdto.UpdaterConfig, assets []*github.ReleaseAsset) (*github.ReleaseAsset, string, error) {
@@ -205,7 +514,7 @@ func selectGitHubAssetDefault(cfg *updaterdto.UpdaterConfig, assets []*github.Re
 		}
 
 		// Prefer non-source-archives and non-checksums
-		if isChecksumName(name) || isSourceArchive(name) {
+		if isSignatureName(name) || isChecksumName(name) || isSourceArchive(name) {
 			continue
 		}
 
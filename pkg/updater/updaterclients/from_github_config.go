@@ -14,22 +14,38 @@ type GetSignatureFuncType func(ctx context.Context, cfg *GithubAgentCfg) (string
 
 // FromGithubConfig Service configuration struct
 type FromGithubConfig struct {
-	// Pre-initialized GitHub client (optionally with auth)
-	Client *github.Client
-	Owner  string
-	Repo   string
-	// Optional: if specified, use that tag; otherwise, latest release.
-	Tag string
+	// Optional pre-initialized GitHub client, used as is in place of Token, BaseURL and the response cache
+	Client *github.Client `json:"-" yaml:"-" mapstructure:"-"`
+	// BaseURL Optional GitHub Enterprise API URL, e.g. "https://github.example.com/api/v3/"
+	BaseURL string `json:"base_url" yaml:"base_url" mapstructure:"base_url"`
+	// Token Optional access token, raising the rate limit and allowing private repositories
+	Token string `json:"-" yaml:"-" mapstructure:"token"`
+	// CacheDir Where API responses are kept for conditional requests, defaults to "github" beside UpdaterConfig.StatePath
+	CacheDir string `json:"cache_dir" yaml:"cache_dir" mapstructure:"cache_dir"`
+	Owner    string `json:"owner" yaml:"owner" mapstructure:"owner"`
+	Repo     string `json:"repo" yaml:"repo" mapstructure:"repo"`
+	// Optional: if specified, use that tag; otherwise, the highest semantic version release.
+	Tag string `json:"tag" yaml:"tag" mapstructure:"tag"`
+	// TagPrefix Only consider tags starting with it, stripped before parsing the version, e.g. "app/v"
+	TagPrefix string `json:"tag_prefix" yaml:"tag_prefix" mapstructure:"tag_prefix"`
+	// MaxReleasePages Pages of 100 releases to scan for the highest version, 0 for all
+	MaxReleasePages int `json:"max_release_pages" yaml:"max_release_pages" mapstructure:"max_release_pages"`
 	// SelectAssetPattern A template style string representing the file name wanted
-	SelectAssetPattern string `json:"select_asset_pattern"`
+	SelectAssetPattern string `json:"select_asset_pattern" yaml:"select_asset_pattern" mapstructure:"select_asset_pattern"`
 	// Optional asset filter callback; if nil, AssetNameGuess + cfg filters apply.
-	SelectAssetFunc SelectAssetFuncType
-	// Optional to download key ready for later verification
-	GetSignatureFunc GetSignatureFuncType
+	SelectAssetFunc SelectAssetFuncType `json:"-" yaml:"-" mapstructure:"-"`
+	// Optional to download key ready for later verification, in place of a discovered signature
+	GetSignatureFunc GetSignatureFuncType `json:"-" yaml:"-" mapstructure:"-"`
+	// DiscoverSidecars Read "<asset>.asc", ".sig" or ".pem" signatures and checksum files published
+	// alongside the asset, cross-checking checksums against the digest GitHub reports
+	DiscoverSidecars bool `json:"discover_sidecars" yaml:"discover_sidecars" mapstructure:"discover_sidecars"`
 }
 
 func DefaultFromGithubConfig() FromGithubConfig {
-	return FromGithubConfig{}
+	return FromGithubConfig{
+		MaxReleasePages:  5,
+		DiscoverSidecars: true,
+	}
 }
 
 func (c *FromGithubConfig) GetRef() string {
@@ -41,6 +57,21 @@ func (c *FromGithubConfig) WithClient(client *github.Client) *FromGithubConfig {
 	return c
 }
 
+func (c *FromGithubConfig) WithBaseURL(url string) *FromGithubConfig {
+	c.BaseURL = url
+	return c
+}
+
+func (c *FromGithubConfig) WithToken(token string) *FromGithubConfig {
+	c.Token = token
+	return c
+}
+
+func (c *FromGithubConfig) WithCacheDir(path string) *FromGithubConfig {
+	c.CacheDir = path
+	return c
+}
+
 func (c *FromGithubConfig) WithOwner(owner string) *FromGithubConfig {
 	c.Owner = owner
 	return c
@@ -56,6 +87,16 @@ func (c *FromGithubConfig) WithTag(tag string) *FromGithubConfig {
 	return c
 }
 
+func (c *FromGithubConfig) WithTagPrefix(prefix string) *FromGithubConfig {
+	c.TagPrefix = prefix
+	return c
+}
+
+func (c *FromGithubConfig) WithMaxReleasePages(pages int) *FromGithubConfig {
+	c.MaxReleasePages = pages
+	return c
+}
+
 func (c *FromGithubConfig) WithSelectAssetPattern(pattern string) *FromGithubConfig {
 	c.SelectAssetPattern = pattern
 	return c
@@ -66,6 +107,11 @@ func (c *FromGithubConfig) WithSelectAssetFunc(userFunc SelectAssetFuncType) *Fr
 	return c
 }
 
+func (c *FromGithubConfig) WithDiscoverSidecars(truthy bool) *FromGithubConfig {
+	c.DiscoverSidecars = truthy
+	return c
+}
+
 func (c *FromGithubConfig) WithGetSignatureFunc(userFunc GetSignatureFuncType) *FromGithubConfig {
 	c.GetSignatureFunc = userFunc
 	return c
@@ -0,0 +1,336 @@
+package updaterclients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v81/github"
+	"github.com/joy-dx/gonetic"
+	"github.com/joy-dx/gonetic/config"
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/relay"
+)
+
+func TestFromGithub_CheckUpdate(t *testing.T) {
+	release := func(tag string, draft bool, prerelease bool, names ...string) *github.RepositoryRelease {
+		r := &github.RepositoryRelease{TagName: github.Ptr(tag), Draft: github.Ptr(draft), Prerelease: github.Ptr(prerelease)}
+		for _, name := range names {
+			r.Assets = append(r.Assets, &github.ReleaseAsset{
+				Name:               github.Ptr(name),
+				BrowserDownloadURL: github.Ptr("https://example.com/" + tag + "/" + name),
+			})
+		}
+		return r
+	}
+	// Deliberately out of version order and split over two pages
+	pages := [][]*github.RepositoryRelease{
+		{
+			release("app/v1.0.0", false, false, "app-linux-amd64"),
+			release("app/v2.2.0-rc.1", false, true, "app-linux-amd64"),
+			release("app/v2.1.0", false, false, "app-darwin-arm64"),
+			release("app/v2.0.1", true, false, "app-linux-amd64"),
+			release("other/v9.0.0", false, false, "other-linux-amd64"),
+		},
+		{
+			release("app/v2.0.0", false, false, "app-linux-amd64", "app-darwin-arm64"),
+			release("nightly", false, false, "app-linux-amd64"),
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/joy-dx/app/releases":
+			page := 0
+			if r.URL.Query().Get("page") == "2" {
+				page = 1
+			} else {
+				w.Header().Set("Link", fmt.Sprintf(`<http://%s/repos/joy-dx/app/releases?page=2>; rel="next"`, r.Host))
+			}
+			_ = json.NewEncoder(w).Encode(pages[page])
+		case "/repos/joy-dx/app/releases/tags/app/v1.0.0":
+			_ = json.NewEncoder(w).Encode(pages[0][0])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	netCfg := config.DefaultNetSvcConfig()
+	netCfg.WithRelay(&relay.RelaySvc{})
+	netSvc := gonetic.ProvideNetSvc(&netCfg)
+
+	tests := []struct {
+		name            string
+		tag             string
+		platform        string
+		allowPrerelease bool
+		wantVersion     string
+		wantErr         bool
+	}{
+		{name: "highest stable with a matching asset", platform: "linux", wantVersion: "2.0.0"},
+		{name: "newer release for another platform", platform: "darwin", wantVersion: "2.1.0"},
+		{name: "prerelease allowed", platform: "linux", allowPrerelease: true, wantVersion: "2.2.0-rc.1"},
+		{name: "pinned tag", tag: "app/v1.0.0", platform: "linux", wantVersion: "1.0.0"},
+		{name: "no matching asset", platform: "windows", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := github.NewClient(nil)
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+			githubCfg := DefaultFromGithubConfig()
+			githubCfg.WithClient(client).WithOwner("joy-dx").WithRepo("app").WithTagPrefix("app/v").WithTag(tc.tag)
+
+			updaterCfg := updaterdto.DefaultUpdaterSvcConfig()
+			updaterCfg.WithRelay(&relay.RelaySvc{}).WithNetSvc(netSvc)
+			updaterCfg.Platform = tc.platform
+			updaterCfg.Architecture = map[string]string{"linux": "amd64", "darwin": "arm64", "windows": "amd64"}[tc.platform]
+			updaterCfg.Variant = ""
+			updaterCfg.AllowPrerelease = tc.allowPrerelease
+
+			asset, err := NewFromGithub(&githubCfg).CheckUpdate(context.Background(), &updaterCfg)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err=%v wantErr=%v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if asset.Version != tc.wantVersion || asset.Platform != tc.platform {
+				t.Fatalf("version=%s platform=%s url=%s", asset.Version, asset.Platform, asset.DownloadURL)
+			}
+		})
+	}
+}
+
+func TestFromGithub_SelectAssetPattern(t *testing.T) {
+	names := []string{
+		"app-linux-amd64.tar.gz.sha256",
+		"app-linux-amd64.tar.gz.sig",
+		"app-linux-amd64.tar.gz.pem",
+		"app-linux-amd64.tar.gz.asc",
+		"checksums.txt",
+		"app-linux-amd64-qt.zip.sig",
+		"app-linux-amd64-qt.zip",
+		"app-linux-amd64.tar.gz",
+	}
+	release := &github.RepositoryRelease{TagName: github.Ptr("v2.0.0")}
+	for _, name := range names {
+		release.Assets = append(release.Assets, &github.ReleaseAsset{Name: github.Ptr(name)})
+	}
+
+	tests := []struct {
+		name      string
+		variant   string
+		wantAsset string
+	}{
+		{name: "sidecars published first are skipped", wantAsset: "app-linux-amd64.tar.gz"},
+		{name: "variant", variant: "qt", wantAsset: "app-linux-amd64-qt.zip"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			updaterCfg := updaterdto.DefaultUpdaterSvcConfig()
+			updaterCfg.Platform = "linux"
+			updaterCfg.Architecture = "amd64"
+			updaterCfg.Variant = tc.variant
+
+			githubCfg := DefaultFromGithubConfig()
+			githubCfg.WithSelectAssetPattern("app-{platform}-{arch}{variant}")
+			agentCfg := &GithubAgentCfg{GithubRelease: release, VersionLink: &releaserdto.ReleaseAsset{}}
+			chosen, variant, err := NewFromGithub(&githubCfg).selectAsset(context.Background(), &updaterCfg, agentCfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if chosen.GetName() != tc.wantAsset || variant != tc.variant {
+				t.Fatalf("asset=%s variant=%s want %s %s", chosen.GetName(), variant, tc.wantAsset, tc.variant)
+			}
+		})
+	}
+}
+
+func TestFromGithub_DiscoverSidecars(t *testing.T) {
+	privateKey, _, err := cryptography.ECDSACreateKey(*cryptography.DefaultECDSACreateKeyCfg())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaKey, err := cryptography.ParseECDSAPrivateKeyFromPEM(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	artefactPath := filepath.Join(t.TempDir(), "app-linux-amd64.tar.gz")
+	if err := os.WriteFile(artefactPath, []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	checksum, err := cryptography.Sha256SumFile(artefactPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := cryptography.ECDSASignFile(ecdsaKey, artefactPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherChecksum := strings.Repeat("0", 64)
+
+	netCfg := config.DefaultNetSvcConfig()
+	netCfg.WithRelay(&relay.RelaySvc{})
+	netSvc := gonetic.ProvideNetSvc(&netCfg)
+
+	tests := []struct {
+		name          string
+		digest        string
+		listed        string
+		wantChecksum  string
+		wantSignature bool
+		wantErr       error
+	}{
+		{name: "listing agrees with digest", digest: "sha256:" + checksum, listed: checksum, wantChecksum: checksum, wantSignature: true},
+		{name: "listing without digest", listed: checksum, wantChecksum: checksum, wantSignature: true},
+		{name: "digest without listing", digest: "sha256:" + checksum, wantChecksum: checksum, wantSignature: true},
+		{name: "listing disagrees with digest", digest: "sha256:" + checksum, listed: otherChecksum, wantErr: updaterdto.ErrChecksumConflict},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			files := map[int64]string{2: signature}
+			release := &github.RepositoryRelease{
+				TagName: github.Ptr("v2.0.0"),
+				Assets: []*github.ReleaseAsset{
+					{ID: github.Ptr(int64(1)), Name: github.Ptr("app-linux-amd64.tar.gz"), Digest: github.Ptr(tc.digest), BrowserDownloadURL: github.Ptr("https://example.com/app-linux-amd64.tar.gz")},
+					{ID: github.Ptr(int64(2)), Name: github.Ptr("app-linux-amd64.tar.gz.asc"), BrowserDownloadURL: github.Ptr("https://example.com/app-linux-amd64.tar.gz.asc")},
+				},
+			}
+			if tc.listed != "" {
+				files[3] = tc.listed + "  app-linux-amd64.tar.gz\n"
+				release.Assets = append(release.Assets, &github.ReleaseAsset{ID: github.Ptr(int64(3)), Name: github.Ptr("checksums.txt"), BrowserDownloadURL: github.Ptr("https://example.com/checksums.txt")})
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/repos/joy-dx/app/releases/tags/v2.0.0" {
+					_ = json.NewEncoder(w).Encode(release)
+					return
+				}
+				var id int64
+				if _, err := fmt.Sscanf(r.URL.Path, "/repos/joy-dx/app/releases/assets/%d", &id); err != nil || files[id] == "" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Header().Set("Content-Type", "application/octet-stream")
+				_, _ = w.Write([]byte(files[id]))
+			}))
+			defer server.Close()
+
+			client := github.NewClient(nil)
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+			githubCfg := DefaultFromGithubConfig()
+			githubCfg.WithClient(client).WithOwner("joy-dx").WithRepo("app").WithTag("v2.0.0")
+
+			updaterCfg := updaterdto.DefaultUpdaterSvcConfig()
+			updaterCfg.WithRelay(&relay.RelaySvc{}).WithNetSvc(netSvc)
+			updaterCfg.Platform = "linux"
+			updaterCfg.Architecture = "amd64"
+			updaterCfg.Variant = ""
+
+			asset, err := NewFromGithub(&githubCfg).CheckUpdate(context.Background(), &updaterCfg)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err=%v want %v", err, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+			if asset.Checksum != tc.wantChecksum {
+				t.Fatalf("checksum=%s want %s", asset.Checksum, tc.wantChecksum)
+			}
+			if (asset.Signature != "") != tc.wantSignature || (tc.wantSignature && asset.SignatureType != "X509") {
+				t.Fatalf("signature type=%q present=%v", asset.SignatureType, asset.Signature != "")
+			}
+		})
+	}
+}
+
+func TestFromGithub_ConditionalRequestsAndRateLimit(t *testing.T) {
+	releases := []*github.RepositoryRelease{{
+		TagName: github.Ptr("v2.0.0"),
+		Assets:  []*github.ReleaseAsset{{Name: github.Ptr("app-linux-amd64"), BrowserDownloadURL: github.Ptr("https://example.com/app-linux-amd64")}},
+	}}
+	var (
+		fullResponses int
+		notModified   int
+		limited       bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			t.Errorf("authorization=%q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Path != "/api/v3/repos/joy-dx/app/releases" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if limited {
+			fullResponses++
+			w.Header().Set("X-RateLimit-Limit", "60")
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Add(time.Hour).Unix()))
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"API rate limit exceeded"}`))
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"releases-v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fullResponses++
+		w.Header().Set("ETag", `"releases-v1"`)
+		_ = json.NewEncoder(w).Encode(releases)
+	}))
+	defer server.Close()
+
+	netCfg := config.DefaultNetSvcConfig()
+	netCfg.WithRelay(&relay.RelaySvc{})
+	netSvc := gonetic.ProvideNetSvc(&netCfg)
+	updaterCfg := updaterdto.DefaultUpdaterSvcConfig()
+	updaterCfg.WithRelay(&relay.RelaySvc{}).WithNetSvc(netSvc).WithStatePath(filepath.Join(t.TempDir(), "state.json"))
+	updaterCfg.Platform = "linux"
+	updaterCfg.Architecture = "amd64"
+	updaterCfg.Variant = ""
+
+	// A fresh client per check, as after an app restart
+	check := func() (string, error) {
+		githubCfg := DefaultFromGithubConfig()
+		githubCfg.WithBaseURL(server.URL + "/").WithToken("secret-token").WithOwner("joy-dx").WithRepo("app")
+		asset, err := NewFromGithub(&githubCfg).CheckUpdate(context.Background(), &updaterCfg)
+		return asset.Version, err
+	}
+
+	for idx := 0; idx < 2; idx++ {
+		version, err := check()
+		if err != nil || version != "v2.0.0" {
+			t.Fatalf("check %d: version=%s err=%v", idx, version, err)
+		}
+	}
+	if fullResponses != 1 || notModified != 1 {
+		t.Fatalf("full=%d notModified=%d want 1 and 1", fullResponses, notModified)
+	}
+
+	limited = true
+	for idx := 0; idx < 2; idx++ {
+		_, err := check()
+		var rateErr *updaterdto.RateLimitError
+		if !errors.Is(err, updaterdto.ErrRateLimited) || !errors.As(err, &rateErr) || rateErr.Limit != 60 {
+			t.Fatalf("check %d: err=%v want rate limit error", idx, err)
+		}
+	}
+	// The second check backed off without asking the host again
+	if fullResponses != 2 {
+		t.Fatalf("requests while limited=%d want 1", fullResponses-1)
+	}
+}
@@ -0,0 +1,234 @@
+package updaterclients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+const UpdateClientFromGitlabRef = "from_gitlab"
+
+type FromGitlab struct {
+	cfg          *FromGitlabConfig
+	Ref          string
+	FoundVersion *releaserdto.ReleaseAsset
+}
+
+func NewFromGitlab(cfg *FromGitlabConfig) *FromGitlab {
+	return &FromGitlab{
+		Ref: UpdateClientFromGitlabRef,
+		cfg: cfg,
+	}
+}
+
+func (c *FromGitlab) GetRef() string {
+	return c.Ref
+}
+
+func (c *FromGitlab) GetVersionLink() (releaserdto.ReleaseAsset, error) {
+	if c.FoundVersion == nil {
+		return releaserdto.ReleaseAsset{}, errors.New("no release found yet, run CheckUpdate first")
+	}
+	return *c.FoundVersion, nil
+}
+
+// ListReleases returns the releases of the project, most recently released first
+func (c *FromGitlab) ListReleases(ctx context.Context) ([]GitlabRelease, error) {
+	var releases []GitlabRelease
+	if err := c.getJSON(ctx, c.projectURL("releases")+"?order_by=released_at&sort=desc&per_page=100", &releases); err != nil {
+		return nil, fmt.Errorf("gitlab release fetch: %w", err)
+	}
+	return releases, nil
+}
+
+// ReleaseHistory lists the notes of the releases that are already out
+func (c *FromGitlab) ReleaseHistory(ctx context.Context, cfg *updaterdto.UpdaterConfig) ([]updaterdto.ReleaseNote, error) {
+	releases, err := c.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	notes := make([]updaterdto.ReleaseNote, 0, len(releases))
+	for _, release := range releases {
+		if release.UpcomingRelease {
+			continue
+		}
+		notes = append(notes, updaterdto.ReleaseNote{
+			Version:     release.TagName,
+			PublishedAt: release.ReleasedAt,
+			Changelog:   release.Description,
+			ReleaseURL:  release.Links.Self,
+		})
+	}
+	return notes, nil
+}
+
+func (c *FromGitlab) CheckUpdate(ctx context.Context, cfg *updaterdto.UpdaterConfig) (releaserdto.ReleaseAsset, error) {
+	asset := releaserdto.ReleaseAsset{}
+	if c.cfg.Project == "" {
+		return asset, errors.New("FromGitlabCheckClient: missing project")
+	}
+
+	release, err := c.findRelease(ctx, cfg)
+	if err != nil {
+		return asset, err
+	}
+
+	agentConfig := GitlabAgentCfg{
+		NetSvc:        cfg.NetSvc,
+		UpdaterCfg:    *cfg,
+		GitlabRelease: release,
+		VersionLink:   &asset,
+	}
+
+	remoteAssets := make([]remoteAsset, 0, len(release.Assets.Links))
+	for _, link := range release.Assets.Links {
+		remoteAssets = append(remoteAssets, remoteAsset{Name: link.Name, URL: link.DownloadURL()})
+	}
+
+	var chosen remoteAsset
+	if c.cfg.SelectAssetFunc != nil {
+		link, variant, selErr := c.cfg.SelectAssetFunc(ctx, &agentConfig)
+		if selErr != nil {
+			return asset, selErr
+		}
+		if link == nil {
+			return asset, errors.New("gitlab asset selection: no asset returned")
+		}
+		chosen = remoteAsset{Name: link.Name, URL: link.DownloadURL()}
+		asset.WithVariant(variant).
+			WithPlatform(cfg.Platform).
+			WithArch(cfg.Architecture)
+	} else {
+		selected, found, selErr := selectRemoteAsset(cfg, c.cfg.SelectAssetPattern, remoteAssets)
+		if selErr != nil {
+			return asset, fmt.Errorf("gitlab asset selection: %w", selErr)
+		}
+		chosen = selected
+		asset.WithPlatform(found.Platform).
+			WithArch(found.Arch).
+			WithVariant(found.Variant)
+	}
+
+	checksum, signature, sidecarErr := discoverSidecars(ctx, c.fetch, remoteAssets, chosen)
+	if errors.Is(sidecarErr, updaterdto.ErrChecksumConflict) {
+		return asset, sidecarErr
+	}
+	if sidecarErr != nil && cfg.Relay != nil {
+		cfg.Relay.Warn(updater.RlyUpdaterLog{Msg: fmt.Sprintf("problem reading release sidecars for %s: %s", chosen.Name, sidecarErr.Error())})
+	}
+
+	asset.WithDownloadURL(chosen.URL).
+		WithChecksum(checksum).
+		WithVersion(release.TagName).
+		WithChangelog(release.Description).
+		WithPublishedAt(release.ReleasedAt).
+		WithReleaseURL(release.Links.Self)
+
+	if c.cfg.GetSignatureFunc != nil {
+		sig, getSigErr := c.cfg.GetSignatureFunc(ctx, &agentConfig)
+		if getSigErr != nil {
+			if cfg.Relay != nil {
+				cfg.Relay.Warn(updater.RlyUpdaterLog{Msg: fmt.Sprintf("Failed to get signature for %s: %s", chosen.URL, getSigErr.Error())})
+			}
+		} else {
+			signature = sig
+		}
+	}
+	if signature != "" {
+		sigInfo, sigInfoErr := cryptography.DetectSignatureInformation([]byte(signature))
+		if sigInfoErr != nil {
+			if cfg.Relay != nil {
+				cfg.Relay.Warn(updater.RlyUpdaterLog{Msg: fmt.Sprintf("problem detecting signature info: %s", sigInfoErr.Error())})
+			}
+		} else {
+			asset.WithSignatureType(sigInfo.Format)
+			asset.WithSignature(signature)
+		}
+	}
+
+	c.FoundVersion = &asset
+	return asset, nil
+}
+
+// findRelease fetches the configured tag, or the newest release that is out
+// and, unless allowed, not a prerelease
+func (c *FromGitlab) findRelease(ctx context.Context, cfg *updaterdto.UpdaterConfig) (*GitlabRelease, error) {
+	if c.cfg.Tag != "" {
+		var release GitlabRelease
+		if err := c.getJSON(ctx, c.projectURL("releases", url.PathEscape(c.cfg.Tag)), &release); err != nil {
+			return nil, fmt.Errorf("gitlab release fetch: %w", err)
+		}
+		if isPrereleaseTag(release.TagName) && !cfg.AllowPrerelease {
+			return nil, fmt.Errorf("release %s is a prerelease, but prereleases not allowed", release.TagName)
+		}
+		return &release, nil
+	}
+
+	releases, err := c.ListReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range releases {
+		if releases[idx].UpcomingRelease {
+			continue
+		}
+		if isPrereleaseTag(releases[idx].TagName) && !cfg.AllowPrerelease {
+			continue
+		}
+		return &releases[idx], nil
+	}
+	return nil, errors.New("gitlab release fetch: no published release found")
+}
+
+func (c *FromGitlab) projectURL(parts ...string) string {
+	base := strings.TrimRight(c.cfg.BaseURL, "/") + "/api/v4/projects/" + url.PathEscape(c.cfg.Project)
+	if len(parts) == 0 {
+		return base
+	}
+	return base + "/" + strings.Join(parts, "/")
+}
+
+func (c *FromGitlab) getJSON(ctx context.Context, rawURL string, target interface{}) error {
+	body, err := c.fetch(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, target)
+}
+
+// fetch performs an authenticated GET. The token is only sent to the GitLab
+// instance itself, never to externally hosted release links.
+func (c *FromGitlab) fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	return httpFetch(ctx, c.cfg.Client, rawURL, func(req *http.Request) {
+		if c.cfg.Token != "" && sameHost(rawURL, c.cfg.BaseURL) {
+			req.Header.Set("PRIVATE-TOKEN", c.cfg.Token)
+		}
+	})
+}
+
+// DownloadHeaders sends the token with downloads from the GitLab instance,
+// which private projects need, see updaterdto.DownloadHeadersInterface
+func (c *FromGitlab) DownloadHeaders(rawURL string) http.Header {
+	if c.cfg.Token == "" || !sameHost(rawURL, c.cfg.BaseURL) {
+		return nil
+	}
+	header := http.Header{}
+	header.Set("PRIVATE-TOKEN", c.cfg.Token)
+	return header
+}
+
+// isPrereleaseTag reports whether a tag parses as a semantic prerelease, e.g. "v2.0.0-rc.1"
+func isPrereleaseTag(tag string) bool {
+	version, err := semver.NewVersion(tag)
+	return err == nil && version.Prerelease() != ""
+}
@@ -0,0 +1,123 @@
+package updaterclients
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	netDTO "github.com/joy-dx/gonetic/dto"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+type GitlabSelectAssetFuncType func(ctx context.Context, cfg *GitlabAgentCfg) (*GitlabReleaseLink, string /*variant*/, error)
+type GitlabGetSignatureFuncType func(ctx context.Context, cfg *GitlabAgentCfg) (string, error)
+
+// FromGitlabConfig Service configuration struct
+type FromGitlabConfig struct {
+	// Client HTTP client used for API requests, defaults to http.DefaultClient
+	Client *http.Client
+	// BaseURL GitLab instance, e.g. a self-hosted "https://gitlab.example.com"
+	BaseURL string `json:"base_url" yaml:"base_url" mapstructure:"base_url"`
+	// Project Numeric ID or full path, e.g. "group/subgroup/app"
+	Project string `json:"project" yaml:"project" mapstructure:"project"`
+	// Token Optional personal, project or group access token for private projects
+	Token string `json:"-" yaml:"-" mapstructure:"token"`
+	// Optional: if specified, use that tag; otherwise, latest release.
+	Tag string `json:"tag" yaml:"tag" mapstructure:"tag"`
+	// SelectAssetPattern A template style string representing the file name wanted
+	SelectAssetPattern string `json:"select_asset_pattern" yaml:"select_asset_pattern" mapstructure:"select_asset_pattern"`
+	// Optional asset filter callback; if nil, SelectAssetPattern or AssetNameGuess apply.
+	SelectAssetFunc GitlabSelectAssetFuncType
+	// Optional signature lookup, used in place of "<asset>.asc" / "<asset>.sig" release links
+	GetSignatureFunc GitlabGetSignatureFuncType
+}
+
+func DefaultFromGitlabConfig() FromGitlabConfig {
+	return FromGitlabConfig{
+		BaseURL: "https://gitlab.com",
+	}
+}
+
+func (c *FromGitlabConfig) GetRef() string {
+	return UpdateClientFromGitlabRef + "_config"
+}
+
+func (c *FromGitlabConfig) WithClient(client *http.Client) *FromGitlabConfig {
+	c.Client = client
+	return c
+}
+
+func (c *FromGitlabConfig) WithBaseURL(url string) *FromGitlabConfig {
+	c.BaseURL = url
+	return c
+}
+
+func (c *FromGitlabConfig) WithProject(project string) *FromGitlabConfig {
+	c.Project = project
+	return c
+}
+
+func (c *FromGitlabConfig) WithToken(token string) *FromGitlabConfig {
+	c.Token = token
+	return c
+}
+
+func (c *FromGitlabConfig) WithTag(tag string) *FromGitlabConfig {
+	c.Tag = tag
+	return c
+}
+
+func (c *FromGitlabConfig) WithSelectAssetPattern(pattern string) *FromGitlabConfig {
+	c.SelectAssetPattern = pattern
+	return c
+}
+
+func (c *FromGitlabConfig) WithSelectAssetFunc(userFunc GitlabSelectAssetFuncType) *FromGitlabConfig {
+	c.SelectAssetFunc = userFunc
+	return c
+}
+
+func (c *FromGitlabConfig) WithGetSignatureFunc(userFunc GitlabGetSignatureFuncType) *FromGitlabConfig {
+	c.GetSignatureFunc = userFunc
+	return c
+}
+
+type GitlabAgentCfg struct {
+	NetSvc        netDTO.NetInterface
+	UpdaterCfg    updaterdto.UpdaterConfig
+	GitlabRelease *GitlabRelease
+	VersionLink   *releaserdto.ReleaseAsset
+}
+
+// GitlabRelease Subset of the GitLab releases API response
+type GitlabRelease struct {
+	TagName         string     `json:"tag_name"`
+	Name            string     `json:"name"`
+	Description     string     `json:"description"`
+	ReleasedAt      *time.Time `json:"released_at"`
+	UpcomingRelease bool       `json:"upcoming_release"`
+	Assets          struct {
+		Links []GitlabReleaseLink `json:"links"`
+	} `json:"assets"`
+	Links struct {
+		Self string `json:"self"`
+	} `json:"_links"`
+}
+
+// GitlabReleaseLink A file attached to a release
+type GitlabReleaseLink struct {
+	ID             int64  `json:"id"`
+	Name           string `json:"name"`
+	URL            string `json:"url"`
+	DirectAssetURL string `json:"direct_asset_url"`
+	LinkType       string `json:"link_type"`
+}
+
+// DownloadURL prefers the permanent direct asset URL over the raw link
+func (l GitlabReleaseLink) DownloadURL() string {
+	if l.DirectAssetURL != "" {
+		return l.DirectAssetURL
+	}
+	return l.URL
+}
@@ -0,0 +1,225 @@
+package updaterclients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdownload"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/relay"
+)
+
+func TestFromGitlab_CheckUpdate(t *testing.T) {
+	privateKey, _, err := cryptography.ECDSACreateKey(*cryptography.DefaultECDSACreateKeyCfg())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaKey, err := cryptography.ParseECDSAPrivateKeyFromPEM(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	artefactPath := filepath.Join(t.TempDir(), "app-example-linux-amd64.tar.gz")
+	if err := os.WriteFile(artefactPath, []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	checksum, err := cryptography.Sha256SumFile(artefactPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := cryptography.ECDSASignFile(ecdsaKey, artefactPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var server *httptest.Server
+	files := map[string]string{
+		"/files/checksums.txt":                      checksum + "  app-example-linux-amd64.tar.gz\n",
+		"/files/app-example-linux-amd64.tar.gz.asc": signature,
+	}
+	link := func(name string) GitlabReleaseLink {
+		return GitlabReleaseLink{Name: name, DirectAssetURL: server.URL + "/files/" + name}
+	}
+	release := func(tag string, upcoming bool, names ...string) GitlabRelease {
+		r := GitlabRelease{TagName: tag, UpcomingRelease: upcoming}
+		for _, name := range names {
+			r.Assets.Links = append(r.Assets.Links, link(name))
+		}
+		return r
+	}
+	var releases []GitlabRelease
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contents, ok := files[r.URL.Path]; ok {
+			_, _ = w.Write([]byte(contents))
+			return
+		}
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		const prefix = "/api/v4/projects/group%2Fapp/releases"
+		path := r.URL.EscapedPath()
+		switch {
+		case path == prefix:
+			_ = json.NewEncoder(w).Encode(releases)
+		case strings.HasPrefix(path, prefix+"/"):
+			tag := strings.TrimPrefix(path, prefix+"/")
+			for _, candidate := range releases {
+				if candidate.TagName == tag {
+					_ = json.NewEncoder(w).Encode(candidate)
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	stable := release("v2.0.0", false, "app-example-linux-amd64.tar.gz", "app-example-linux-amd64-webkit241.tar.gz", "app-example-darwin-arm64.tar.gz", "app-example-linux-amd64.tar.gz.asc", "checksums.txt")
+	stable.Description = "Fixed the thing"
+	stable.Links.Self = server.URL + "/group/app/-/releases/v2.0.0"
+	releases = []GitlabRelease{
+		release("v3.0.0", true, "app-example-linux-amd64.tar.gz"),
+		release("v2.1.0-rc.1", false, "app-example-linux-amd64.tar.gz"),
+		stable,
+		release("v1.0.0", false, "app-example-linux-amd64.tar.gz"),
+	}
+
+	tests := []struct {
+		name            string
+		tag             string
+		token           string
+		pattern         string
+		variant         string
+		allowPrerelease bool
+		wantVersion     string
+		wantURL         string
+		wantSigned      bool
+		wantErr         bool
+	}{
+		{name: "latest stable", token: "secret", wantVersion: "v2.0.0", wantURL: "/files/app-example-linux-amd64.tar.gz", wantSigned: true},
+		{name: "prerelease allowed", token: "secret", allowPrerelease: true, wantVersion: "v2.1.0-rc.1", wantURL: "/files/app-example-linux-amd64.tar.gz"},
+		{name: "tag", token: "secret", tag: "v1.0.0", wantVersion: "v1.0.0", wantURL: "/files/app-example-linux-amd64.tar.gz"},
+		{name: "pattern with variant", token: "secret", pattern: "app-example-{platform}-{arch}{variant}", variant: "webkit241", wantVersion: "v2.0.0", wantURL: "/files/app-example-linux-amd64-webkit241.tar.gz"},
+		{name: "bad token", token: "wrong", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			updaterCfg := updaterdto.DefaultUpdaterSvcConfig()
+			updaterCfg.WithRelay(&relay.RelaySvc{})
+			updaterCfg.Platform = "linux"
+			updaterCfg.Architecture = "amd64"
+			updaterCfg.Variant = tc.variant
+			updaterCfg.AllowPrerelease = tc.allowPrerelease
+
+			gitlabCfg := DefaultFromGitlabConfig()
+			gitlabCfg.WithBaseURL(server.URL).
+				WithProject("group/app").
+				WithToken(tc.token).
+				WithTag(tc.tag).
+				WithSelectAssetPattern(tc.pattern)
+			asset, err := NewFromGitlab(&gitlabCfg).CheckUpdate(context.Background(), &updaterCfg)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err=%v wantErr=%v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if asset.Version != tc.wantVersion || asset.DownloadURL != server.URL+tc.wantURL {
+				t.Fatalf("version=%s url=%s want %s %s", asset.Version, asset.DownloadURL, tc.wantVersion, tc.wantURL)
+			}
+			if tc.wantSigned {
+				if asset.Checksum != checksum || asset.SignatureType != "X509" {
+					t.Fatalf("checksum=%s signature type=%s", asset.Checksum, asset.SignatureType)
+				}
+				if asset.Changelog != stable.Description || asset.ReleaseURL != stable.Links.Self {
+					t.Fatalf("changelog=%q release url=%s", asset.Changelog, asset.ReleaseURL)
+				}
+			}
+		})
+	}
+}
+
+// testPrivateDownload downloads from a stand-in for a private instance, only
+// serving artefacts to requests authorised, and a stand-in for the object
+// storage it redirects to, which must never see the credentials
+func testPrivateDownload(t *testing.T, newClient func(baseURL string, token string) updaterdto.DownloadHeadersInterface, authorised func(r *http.Request) bool) {
+	t.Helper()
+	artefact := bytes.Repeat([]byte("v2"), 1024)
+	storageSawCredentials := false
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		storageSawCredentials = authorised(r)
+		_, _ = w.Write(artefact)
+	}))
+	defer storage.Close()
+	instance := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorised(r) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Path == "/files/stored.tar.gz" {
+			http.Redirect(w, r, storage.URL+"/bucket/stored.tar.gz", http.StatusFound)
+			return
+		}
+		_, _ = w.Write(artefact)
+	}))
+	defer instance.Close()
+
+	tests := []struct {
+		name    string
+		token   string
+		path    string
+		wantErr bool
+	}{
+		{name: "authorised", token: "secret", path: "/files/app.tar.gz"},
+		{name: "redirected to storage", token: "secret", path: "/files/stored.tar.gz"},
+		{name: "no token", path: "/files/app.tar.gz", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newClient(instance.URL, tc.token)
+			rawURL := instance.URL + tc.path
+			destination := filepath.Join(t.TempDir(), "app.tar.gz")
+			err := updaterdownload.New(updaterdownload.DefaultEngineConfig()).Download(context.Background(), updaterdownload.Request{
+				URL:         rawURL,
+				Destination: destination,
+				Header:      client.DownloadHeaders(rawURL),
+			}, nil)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err=%v wantErr=%v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if contents, readErr := os.ReadFile(destination); readErr != nil || !bytes.Equal(contents, artefact) {
+				t.Fatalf("downloaded %d bytes, err=%v", len(contents), readErr)
+			}
+			if storageSawCredentials {
+				t.Fatal("credentials sent to the redirected host")
+			}
+		})
+	}
+
+	if header := newClient(instance.URL, "secret").DownloadHeaders(storage.URL + "/bucket/app.tar.gz"); header != nil {
+		t.Fatalf("credentials offered for another host: %v", header)
+	}
+}
+
+func TestFromGitlab_DownloadHeaders(t *testing.T) {
+	testPrivateDownload(t, func(baseURL string, token string) updaterdto.DownloadHeadersInterface {
+		cfg := DefaultFromGitlabConfig()
+		cfg.WithBaseURL(baseURL).WithProject("group/app").WithToken(token)
+		return NewFromGitlab(&cfg)
+	}, func(r *http.Request) bool {
+		return r.Header.Get("PRIVATE-TOKEN") == "secret"
+	})
+}
@@ -0,0 +1,253 @@
+package updaterclients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+	"github.com/joy-dx/gophorth/pkg/ociregistry"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater"
+	"github.com/joy-dx/gophorth/pkg/updater/updatercache"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+const UpdateClientFromOCIRef = "from_oci"
+
+// FromOCI finds releases stored as OCI artefacts, one index per version with a
+// manifest per platform, as published by ReleaserSvc.PublishOCI. The artefact
+// digest doubles as its checksum.
+type FromOCI struct {
+	cfg           *FromOCIConfig
+	Ref           string
+	FoundVersion  *releaserdto.ReleaseAsset
+	temporaryPath string
+	cacheDir      string
+	// blobDir Where the last blob read through the client was copied
+	blobDir string
+}
+
+func NewFromOCI(cfg *FromOCIConfig) *FromOCI {
+	return &FromOCI{
+		Ref: UpdateClientFromOCIRef,
+		cfg: cfg,
+	}
+}
+
+func (c *FromOCI) GetRef() string {
+	return c.Ref
+}
+
+func (c *FromOCI) GetVersionLink() (releaserdto.ReleaseAsset, error) {
+	if c.FoundVersion == nil {
+		return releaserdto.ReleaseAsset{}, errors.New("no release found yet, run CheckUpdate first")
+	}
+	return *c.FoundVersion, nil
+}
+
+func (c *FromOCI) CheckUpdate(ctx context.Context, cfg *updaterdto.UpdaterConfig) (releaserdto.ReleaseAsset, error) {
+	asset := releaserdto.ReleaseAsset{}
+	if c.cfg.Client == nil {
+		return asset, errors.New("FromOCICheckClient: missing registry client")
+	}
+	c.temporaryPath = cfg.TemporaryPath
+	c.cacheDir = cfg.CacheDir
+
+	tag, err := c.findTag(ctx, cfg)
+	if err != nil {
+		return asset, err
+	}
+	body, mediaType, _, err := c.cfg.Client.GetManifest(ctx, tag)
+	if err != nil {
+		return asset, fmt.Errorf("oci release fetch: %w", err)
+	}
+	if !ociregistry.IsIndex(mediaType) {
+		return asset, fmt.Errorf("oci release %s is a single manifest, expected an index of platforms", tag)
+	}
+	var index ociregistry.Index
+	if err := json.Unmarshal(body, &index); err != nil {
+		return asset, fmt.Errorf("oci release index: %w", err)
+	}
+
+	var platformManifest *ociregistry.Descriptor
+	for idx, descriptor := range index.Manifests {
+		if descriptor.Platform == nil || descriptor.Platform.OS != cfg.Platform || descriptor.Platform.Architecture != cfg.Architecture {
+			continue
+		}
+		if descriptor.Annotations[ociregistry.ANNOTATION_VARIANT] != cfg.Variant {
+			continue
+		}
+		platformManifest = &index.Manifests[idx]
+		break
+	}
+	if platformManifest == nil {
+		return asset, fmt.Errorf("oci release %s has no build for %s %s %s", tag, cfg.Platform, cfg.Architecture, cfg.Variant)
+	}
+
+	if body, _, _, err = c.cfg.Client.GetManifest(ctx, platformManifest.Digest); err != nil {
+		return asset, fmt.Errorf("oci release fetch: %w", err)
+	}
+	var manifest ociregistry.Manifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return asset, fmt.Errorf("oci release manifest: %w", err)
+	}
+
+	var artefact, signature *ociregistry.Descriptor
+	for idx, layer := range manifest.Layers {
+		switch layer.MediaType {
+		case ociregistry.MEDIA_TYPE_ARTEFACT:
+			artefact = &manifest.Layers[idx]
+		case ociregistry.MEDIA_TYPE_SIGNATURE:
+			signature = &manifest.Layers[idx]
+		}
+	}
+	if artefact == nil {
+		return asset, fmt.Errorf("oci release %s has no artefact layer", platformManifest.Digest)
+	}
+	checksum, found := strings.CutPrefix(artefact.Digest, "sha256:")
+	if !found {
+		return asset, fmt.Errorf("oci artefact digest %s is not sha256", artefact.Digest)
+	}
+
+	version := manifest.Annotations[ociregistry.ANNOTATION_VERSION]
+	if version == "" {
+		version = ociregistry.VersionFromTag(tag)
+	}
+	name := artefact.Annotations[ociregistry.ANNOTATION_TITLE]
+	if name == "" || filepath.Base(name) != name {
+		name = checksum
+	}
+
+	asset.WithArtefactName(name).
+		WithPlatform(cfg.Platform).
+		WithArch(cfg.Architecture).
+		WithVariant(cfg.Variant).
+		WithDownloadURL(c.cfg.Client.BlobURL(artefact.Digest)).
+		WithChecksum(checksum).
+		WithSize(artefact.Size).
+		WithVersion(version).
+		WithChangelog(index.Annotations[ociregistry.ANNOTATION_DESCRIPTION]).
+		WithReleaseURL(index.Annotations[ociregistry.ANNOTATION_URL])
+	if created, parseErr := time.Parse(time.RFC3339, index.Annotations[ociregistry.ANNOTATION_CREATED]); parseErr == nil {
+		asset.WithPublishedAt(&created)
+	}
+
+	if signature != nil {
+		contents, sigErr := c.cfg.Client.FetchBlob(ctx, signature.Digest)
+		if sigErr == nil {
+			var sigInfo *cryptography.KeyInfo
+			if sigInfo, sigErr = cryptography.DetectSignatureInformation(contents); sigErr == nil {
+				asset.WithSignatureType(sigInfo.Format)
+				asset.WithSignature(string(contents))
+			}
+		}
+		if sigErr != nil && cfg.Relay != nil {
+			cfg.Relay.Warn(updater.RlyUpdaterLog{Msg: fmt.Sprintf("problem reading signature for %s: %s", name, sigErr.Error())})
+		}
+	}
+
+	c.FoundVersion = &asset
+	return asset, nil
+}
+
+// SignDownloadURLs swaps the blob URL, which needs registry credentials, for
+// the storage URL the registry redirects to. Registries serving blobs
+// themselves are read through the client in to a directory of the temporary
+// path named after the digest, reused while the digest stays the same. Blobs
+// already in the download cache are left for the download to take from there.
+func (c *FromOCI) SignDownloadURLs(ctx context.Context, asset *releaserdto.ReleaseAsset) error {
+	if c.cfg.Client == nil || asset.Checksum == "" {
+		return nil
+	}
+	key, err := updatercache.Key(asset.Checksum)
+	if err != nil {
+		return fmt.Errorf("oci artefact checksum: %w", err)
+	}
+	digest := "sha256:" + key
+	if asset.DownloadURL != c.cfg.Client.BlobURL(digest) {
+		// Already resolved, or replaced by the caller
+		return nil
+	}
+	if c.cacheDir != "" && updatercache.New(c.cacheDir, 0).Contains(key) {
+		return nil
+	}
+	blobDir := filepath.Join(c.temporaryPath, "gophorth-oci-"+key[:16])
+	blobPath := filepath.Join(blobDir, asset.ArtefactName)
+	if c.blobDir == blobDir {
+		if checksum, sumErr := cryptography.Sha256SumFile(blobPath); sumErr == nil && strings.EqualFold(checksum, key) {
+			return c.useLocalBlob(asset, blobPath)
+		}
+	}
+
+	storageURL, ok, err := c.cfg.Client.ResolveBlobURL(ctx, digest)
+	if err != nil {
+		return err
+	}
+	if ok {
+		asset.WithDownloadURL(storageURL)
+		return nil
+	}
+
+	if c.blobDir != "" {
+		_ = os.RemoveAll(c.blobDir)
+	}
+	c.blobDir = ""
+	if err := os.MkdirAll(blobDir, 0755); err != nil {
+		return err
+	}
+	if err := c.cfg.Client.CopyBlob(ctx, digest, blobPath); err != nil {
+		_ = os.RemoveAll(blobDir)
+		return err
+	}
+	c.blobDir = blobDir
+	return c.useLocalBlob(asset, blobPath)
+}
+
+// useLocalBlob points the download of asset at a blob copied in to the temporary path
+func (c *FromOCI) useLocalBlob(asset *releaserdto.ReleaseAsset, blobPath string) error {
+	localURL, err := localFileURL(blobPath)
+	if err != nil {
+		return err
+	}
+	asset.WithDownloadURL(localURL)
+	return nil
+}
+
+// findTag returns the configured tag, or the highest semantic version tag
+// that, unless allowed, is not a prerelease
+func (c *FromOCI) findTag(ctx context.Context, cfg *updaterdto.UpdaterConfig) (string, error) {
+	if c.cfg.Tag != "" {
+		return c.cfg.Tag, nil
+	}
+	tags, err := c.cfg.Client.Tags(ctx)
+	if err != nil {
+		return "", fmt.Errorf("oci release listing: %w", err)
+	}
+	var (
+		bestTag     string
+		bestVersion *semver.Version
+	)
+	for _, tag := range tags {
+		version, err := semver.NewVersion(ociregistry.VersionFromTag(tag))
+		if err != nil {
+			continue
+		}
+		if version.Prerelease() != "" && !cfg.AllowPrerelease {
+			continue
+		}
+		if bestVersion == nil || version.GreaterThan(bestVersion) {
+			bestTag, bestVersion = tag, version
+		}
+	}
+	if bestVersion == nil {
+		return "", errors.New("oci release listing: no version tags found")
+	}
+	return bestTag, nil
+}
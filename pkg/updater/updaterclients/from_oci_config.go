@@ -0,0 +1,29 @@
+package updaterclients
+
+import "github.com/joy-dx/gophorth/pkg/ociregistry"
+
+// FromOCIConfig Service configuration struct
+type FromOCIConfig struct {
+	// Client Pre-initialized registry client with repository and credentials
+	Client *ociregistry.Client
+	// Optional: if specified, use that tag; otherwise, the highest semantic version tag.
+	Tag string `json:"tag" yaml:"tag" mapstructure:"tag"`
+}
+
+func DefaultFromOCIConfig() FromOCIConfig {
+	return FromOCIConfig{}
+}
+
+func (c *FromOCIConfig) GetRef() string {
+	return UpdateClientFromOCIRef + "_config"
+}
+
+func (c *FromOCIConfig) WithClient(client *ociregistry.Client) *FromOCIConfig {
+	c.Client = client
+	return c
+}
+
+func (c *FromOCIConfig) WithTag(tag string) *FromOCIConfig {
+	c.Tag = tag
+	return c
+}
@@ -0,0 +1,285 @@
+package updaterclients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/joy-dx/gophorth/pkg/ociregistry"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updatercache"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/relay"
+)
+
+// fakeRegistry serves one repository, requiring a bearer token from its own
+// token endpoint, optionally redirecting blob downloads to separate storage
+func fakeRegistry(t *testing.T, repository string, redirectBlobs bool) *httptest.Server {
+	t.Helper()
+	var (
+		mu        sync.Mutex
+		blobs     = map[string][]byte{}
+		manifests = map[string][]byte{}
+		types     = map[string]string{}
+		uploads   int
+	)
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("registry credentials leaked to storage")
+		}
+		_, _ = w.Write(blobs[strings.TrimPrefix(r.URL.Path, "/")])
+	}))
+	t.Cleanup(storage.Close)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/token" {
+			if user, pass, _ := r.BasicAuth(); user != "ci" || pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token":"fake-token"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer fake-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake",scope="repository:%s:pull,push"`, server.URL, repository))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		route := strings.TrimPrefix(r.URL.Path, "/v2/"+repository+"/")
+		switch {
+		case route == "tags/list":
+			var tags []string
+			for reference := range manifests {
+				if !strings.HasPrefix(reference, "sha256:") {
+					tags = append(tags, reference)
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": repository, "tags": tags})
+		case route == "blobs/uploads/" && r.Method == http.MethodPost:
+			uploads++
+			w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%d", repository, uploads))
+			w.WriteHeader(http.StatusAccepted)
+		case strings.HasPrefix(route, "blobs/uploads/") && r.Method == http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			if ociregistry.Digest(body) != r.URL.Query().Get("digest") {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			blobs[r.URL.Query().Get("digest")] = body
+			w.WriteHeader(http.StatusCreated)
+		case strings.HasPrefix(route, "blobs/"):
+			digest := strings.TrimPrefix(route, "blobs/")
+			body, ok := blobs[digest]
+			switch {
+			case !ok:
+				w.WriteHeader(http.StatusNotFound)
+			case redirectBlobs && r.Method == http.MethodGet:
+				http.Redirect(w, r, storage.URL+"/"+digest, http.StatusTemporaryRedirect)
+			default:
+				_, _ = w.Write(body)
+			}
+		case strings.HasPrefix(route, "manifests/"):
+			reference := strings.TrimPrefix(route, "manifests/")
+			if r.Method == http.MethodPut {
+				body, _ := io.ReadAll(r.Body)
+				for _, key := range []string{reference, ociregistry.Digest(body)} {
+					manifests[key] = body
+					types[key] = r.Header.Get("Content-Type")
+				}
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+			body, ok := manifests[reference]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", types[reference])
+			_, _ = w.Write(body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// publishOCIRelease pushes an artefact per build, platform, arch and variant,
+// and an index tagging them as version
+func publishOCIRelease(t *testing.T, client *ociregistry.Client, artefactDir string, version string, builds [][3]string) {
+	t.Helper()
+	var descriptors []ociregistry.Descriptor
+	for _, build := range builds {
+		name := fmt.Sprintf("app-example-%s-%s%s", build[0], build[1], build[2])
+		artefactPath := filepath.Join(artefactDir, name+"-"+version)
+		if err := os.WriteFile(artefactPath, []byte(name+version), 0644); err != nil {
+			t.Fatal(err)
+		}
+		descriptor, err := client.PushArtefact(context.Background(), ociregistry.Artefact{
+			Path: artefactPath, Name: name, Platform: build[0], Arch: build[1], Variant: build[2], Version: version,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		descriptors = append(descriptors, descriptor)
+	}
+	if _, err := client.PushIndex(context.Background(), ociregistry.TagForVersion(version), descriptors, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFromOCI_CheckUpdate(t *testing.T) {
+	artefactDir := t.TempDir()
+	publish := func(t *testing.T, client *ociregistry.Client, version string, builds [][3]string) {
+		publishOCIRelease(t, client, artefactDir, version, builds)
+	}
+
+	tests := []struct {
+		name          string
+		redirectBlobs bool
+		variant       string
+		tag           string
+		wantVersion   string
+		wantErr       bool
+	}{
+		{name: "highest tag", wantVersion: "2.0.0+build.7"},
+		{name: "highest tag with redirect", redirectBlobs: true, wantVersion: "2.0.0+build.7"},
+		{name: "pinned tag", tag: "1.0.0", wantVersion: "1.0.0"},
+		{name: "variant", variant: "webkit2_41", wantVersion: "2.0.0+build.7"},
+		{name: "no build for variant", variant: "gtk4", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := fakeRegistry(t, "team/app", tc.redirectBlobs)
+			registryCfg := ociregistry.DefaultConfig()
+			registryCfg.WithBaseURL(server.URL).WithRepository("team/app").WithCredentials("ci", "secret")
+			client := ociregistry.New(registryCfg)
+
+			publish(t, client, "1.0.0", [][3]string{{"linux", "amd64", ""}})
+			publish(t, client, "2.0.0+build.7", [][3]string{{"linux", "amd64", ""}, {"linux", "amd64", "webkit2_41"}, {"darwin", "arm64", ""}})
+			publish(t, client, "3.0.0-beta.1", [][3]string{{"linux", "amd64", ""}})
+
+			ociCfg := DefaultFromOCIConfig()
+			ociCfg.WithClient(ociregistry.New(registryCfg)).WithTag(tc.tag)
+			updaterCfg := updaterdto.DefaultUpdaterSvcConfig()
+			updaterCfg.WithRelay(&relay.RelaySvc{}).WithTemporaryPath(t.TempDir())
+			updaterCfg.Platform = "linux"
+			updaterCfg.Architecture = "amd64"
+			updaterCfg.Variant = tc.variant
+
+			checkClient := NewFromOCI(&ociCfg)
+			asset, err := checkClient.CheckUpdate(context.Background(), &updaterCfg)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err=%v wantErr=%v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			wantName := "app-example-linux-amd64" + tc.variant
+			if asset.Version != tc.wantVersion || asset.ArtefactName != wantName {
+				t.Fatalf("version=%s name=%s", asset.Version, asset.ArtefactName)
+			}
+
+			if err := checkClient.SignDownloadURLs(context.Background(), &asset); err != nil {
+				t.Fatal(err)
+			}
+			var contents []byte
+			if tc.redirectBlobs {
+				resp, err := http.Get(asset.DownloadURL)
+				if err != nil {
+					t.Fatal(err)
+				}
+				contents, _ = io.ReadAll(resp.Body)
+				resp.Body.Close()
+			} else {
+				localURL, err := url.Parse(asset.DownloadURL)
+				if err != nil || localURL.Scheme != "file" {
+					t.Fatalf("download url=%s want local copy", asset.DownloadURL)
+				}
+				if contents, err = os.ReadFile(filepath.FromSlash(localURL.Path)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if ociregistry.Digest(contents) != "sha256:"+asset.Checksum {
+				t.Fatalf("downloaded %q does not match checksum", contents)
+			}
+		})
+	}
+}
+
+func TestFromOCI_SignDownloadURLsReusesCopy(t *testing.T) {
+	server := fakeRegistry(t, "team/app", false)
+	registryCfg := ociregistry.DefaultConfig()
+	registryCfg.WithBaseURL(server.URL).WithRepository("team/app").WithCredentials("ci", "secret")
+	artefactDir := t.TempDir()
+	for _, version := range []string{"1.0.0", "2.0.0"} {
+		publishOCIRelease(t, ociregistry.New(registryCfg), artefactDir, version, [][3]string{{"linux", "amd64", ""}})
+	}
+
+	ociCfg := DefaultFromOCIConfig()
+	ociCfg.WithClient(ociregistry.New(registryCfg))
+	updaterCfg := updaterdto.DefaultUpdaterSvcConfig()
+	updaterCfg.WithRelay(&relay.RelaySvc{}).WithTemporaryPath(t.TempDir()).WithCacheDir(t.TempDir())
+	updaterCfg.Platform = "linux"
+	updaterCfg.Architecture = "amd64"
+	checkClient := NewFromOCI(&ociCfg)
+	check := func(tag string) releaserdto.ReleaseAsset {
+		ociCfg.WithTag(tag)
+		asset, err := checkClient.CheckUpdate(context.Background(), &updaterCfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return asset
+	}
+	sign := func(asset releaserdto.ReleaseAsset) string {
+		if err := checkClient.SignDownloadURLs(context.Background(), &asset); err != nil {
+			t.Fatal(err)
+		}
+		return asset.DownloadURL
+	}
+	blobDirs := func() []string {
+		dirs, err := filepath.Glob(filepath.Join(updaterCfg.TemporaryPath, "gophorth-oci-*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dirs
+	}
+
+	previous := check("1.0.0")
+	previousURL, err := url.Parse(sign(previous))
+	if err != nil || previousURL.Scheme != "file" {
+		t.Fatalf("download url=%s want local copy", previousURL)
+	}
+	if err := updatercache.New(updaterCfg.CacheDir, 0).Put(previous.Checksum, filepath.FromSlash(previousURL.Path)); err != nil {
+		t.Fatal(err)
+	}
+	latest := check("2.0.0")
+	latestURL := sign(latest)
+	if dirs := blobDirs(); len(dirs) != 1 || !strings.HasPrefix(latestURL, "file://") || !strings.Contains(latestURL, filepath.ToSlash(dirs[0])) {
+		t.Fatalf("blob directories=%v want only the one of %s", dirs, latestURL)
+	}
+
+	// Neither signing again nor a cached blob may need the registry
+	server.Close()
+	if again := sign(latest); again != latestURL {
+		t.Fatalf("signed again to %s want the existing copy %s", again, latestURL)
+	}
+	if cached := sign(previous); cached != previous.DownloadURL {
+		t.Fatalf("cached blob signed to %s want the blob url kept for the cache", cached)
+	}
+}
@@ -0,0 +1,188 @@
+package updaterclients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+	"github.com/joy-dx/gophorth/pkg/objectstore"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+const UpdateClientFromS3Ref = "from_s3"
+
+// FromS3 finds releases in a private S3 compatible bucket, from a releaser
+// manifest or by listing object names. Artefacts are offered as presigned URLs,
+// re-signed just before downloading so a slow user never meets an expired link.
+type FromS3 struct {
+	cfg          *FromS3Config
+	Ref          string
+	FoundVersion *releaserdto.ReleaseAsset
+}
+
+func NewFromS3(cfg *FromS3Config) *FromS3 {
+	return &FromS3{
+		Ref: UpdateClientFromS3Ref,
+		cfg: cfg,
+	}
+}
+
+func (c *FromS3) GetRef() string {
+	return c.Ref
+}
+
+func (c *FromS3) GetVersionLink() (releaserdto.ReleaseAsset, error) {
+	if c.FoundVersion == nil {
+		return releaserdto.ReleaseAsset{}, errors.New("no release found yet, run CheckUpdate first")
+	}
+	return *c.FoundVersion, nil
+}
+
+func (c *FromS3) CheckUpdate(ctx context.Context, cfg *updaterdto.UpdaterConfig) (releaserdto.ReleaseAsset, error) {
+	if c.cfg.Client == nil {
+		return releaserdto.ReleaseAsset{}, errors.New("FromS3CheckClient: missing object store client")
+	}
+
+	var (
+		candidates []releaserdto.ReleaseAsset
+		objects    []objectstore.Object
+		err        error
+	)
+	manifest, manifestErr := c.readManifest(ctx)
+	switch {
+	case manifestErr == nil:
+		candidates = summaryAssets(manifest)
+	case errors.Is(manifestErr, objectstore.ErrNotFound):
+		if c.cfg.FilePattern == "" {
+			return releaserdto.ReleaseAsset{}, errors.New("FromS3CheckClient: no manifest found and no file pattern configured")
+		}
+		if objects, err = c.cfg.Client.List(ctx, c.cfg.Prefix); err != nil {
+			return releaserdto.ReleaseAsset{}, fmt.Errorf("s3 release listing: %w", err)
+		}
+		if candidates, err = patternAssets(c.cfg.FilePattern, c.cfg.AllowAnyExtension, c.directChildren(objects)); err != nil {
+			return releaserdto.ReleaseAsset{}, err
+		}
+	default:
+		return releaserdto.ReleaseAsset{}, fmt.Errorf("s3 release manifest: %w", manifestErr)
+	}
+
+	asset, found := selectHighestAsset(cfg, candidates)
+	if !found {
+		return releaserdto.ReleaseAsset{}, fmt.Errorf("no release under %s for %s %s %s", c.cfg.Prefix, cfg.Platform, cfg.Architecture, cfg.Variant)
+	}
+
+	downloadURL, err := c.cfg.Client.PresignGet(c.key(asset.ArtefactName))
+	if err != nil {
+		return releaserdto.ReleaseAsset{}, err
+	}
+	asset.WithDownloadURL(downloadURL).WithMirrors(nil)
+
+	if objects != nil {
+		remoteAssets := make([]remoteAsset, 0, len(objects))
+		for _, object := range objects {
+			name := path.Base(object.Key)
+			if name == asset.ArtefactName {
+				asset.WithSize(object.Size)
+			}
+			remoteAssets = append(remoteAssets, remoteAsset{Name: name, URL: object.Key, Size: object.Size})
+		}
+		checksum, signature, sidecarErr := discoverSidecars(ctx, c.fetch, remoteAssets, remoteAsset{Name: asset.ArtefactName})
+		if errors.Is(sidecarErr, updaterdto.ErrChecksumConflict) {
+			return releaserdto.ReleaseAsset{}, sidecarErr
+		}
+		if sidecarErr != nil && cfg.Relay != nil {
+			cfg.Relay.Warn(updater.RlyUpdaterLog{Msg: fmt.Sprintf("problem reading release sidecars for %s: %s", asset.ArtefactName, sidecarErr.Error())})
+		}
+		asset.WithChecksum(checksum)
+		if signature != "" {
+			if sigInfo, sigInfoErr := cryptography.DetectSignatureInformation([]byte(signature)); sigInfoErr == nil {
+				asset.WithSignatureType(sigInfo.Format)
+				asset.WithSignature(signature)
+			}
+		}
+	}
+
+	if cfg.Relay != nil {
+		cfg.Relay.Debug(updater.RlyUpdaterLog{Msg: fmt.Sprintf("found %s %s in bucket %s", asset.ArtefactName, asset.Version, c.cfg.Client.Config().Bucket)})
+	}
+	c.FoundVersion = &asset
+	return asset, nil
+}
+
+// SignDownloadURLs refreshes the presigned URLs of asset, see updaterdto.DownloadURLSignerInterface
+func (c *FromS3) SignDownloadURLs(ctx context.Context, asset *releaserdto.ReleaseAsset) error {
+	if c.cfg.Client == nil || asset.DownloadURL == "" {
+		return nil
+	}
+	downloadURL, err := c.cfg.Client.Resign(asset.DownloadURL)
+	if err != nil {
+		return err
+	}
+	asset.WithDownloadURL(downloadURL)
+	return nil
+}
+
+func (c *FromS3) readManifest(ctx context.Context) (releaserdto.ReleaseSummary, error) {
+	var summary releaserdto.ReleaseSummary
+	if c.cfg.ManifestName == "" {
+		return summary, objectstore.ErrNotFound
+	}
+	body, err := c.cfg.Client.Get(ctx, c.key(c.cfg.ManifestName))
+	if err != nil {
+		return summary, err
+	}
+	if err := json.Unmarshal(body, &summary); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// ReleaseHistory reads the notes of past releases from the releaser history index
+func (c *FromS3) ReleaseHistory(ctx context.Context, cfg *updaterdto.UpdaterConfig) ([]updaterdto.ReleaseNote, error) {
+	if c.cfg.Client == nil {
+		return nil, errors.New("FromS3CheckClient: missing object store client")
+	}
+	if c.cfg.HistoryName == "" {
+		return nil, errors.New("FromS3CheckClient: no history index configured")
+	}
+	body, err := c.cfg.Client.Get(ctx, c.key(c.cfg.HistoryName))
+	if err != nil {
+		return nil, fmt.Errorf("release history: %w", err)
+	}
+	var history releaserdto.ReleaseHistory
+	if err := json.Unmarshal(body, &history); err != nil {
+		return nil, fmt.Errorf("release history: %w", err)
+	}
+	return historyNotes(history), nil
+}
+
+// directChildren returns the names of objects directly under Prefix, ignoring deeper "folders"
+func (c *FromS3) directChildren(objects []objectstore.Object) []string {
+	names := make([]string, 0, len(objects))
+	for _, object := range objects {
+		name := strings.TrimPrefix(object.Key, c.cfg.Prefix)
+		if name == "" || strings.Contains(strings.TrimPrefix(name, "/"), "/") {
+			continue
+		}
+		names = append(names, strings.TrimPrefix(name, "/"))
+	}
+	return names
+}
+
+func (c *FromS3) key(name string) string {
+	if c.cfg.Prefix == "" {
+		return name
+	}
+	return strings.TrimRight(c.cfg.Prefix, "/") + "/" + name
+}
+
+// fetch reads a sidecar object, remoteAsset URLs hold object keys for this client
+func (c *FromS3) fetch(ctx context.Context, key string) ([]byte, error) {
+	return c.cfg.Client.Get(ctx, key)
+}
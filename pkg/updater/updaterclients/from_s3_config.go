@@ -0,0 +1,63 @@
+package updaterclients
+
+import (
+	"github.com/joy-dx/gophorth/pkg/objectstore"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+)
+
+// FromS3Config Service configuration struct
+type FromS3Config struct {
+	// Client Pre-initialized object store client with bucket and credentials
+	Client *objectstore.Client
+	// Prefix Key prefix releases are stored under, e.g. "app-example/"
+	Prefix string `json:"prefix" yaml:"prefix" mapstructure:"prefix"`
+	// ManifestName Releaser summary under Prefix, used in place of listing when present
+	ManifestName string `json:"manifest_name" yaml:"manifest_name" mapstructure:"manifest_name"`
+	// HistoryName Releaser history index under Prefix, read for the notes of skipped versions
+	HistoryName string `json:"history_name" yaml:"history_name" mapstructure:"history_name"`
+	// FilePattern Reverse template for object names, e.g. "app-example-{platform}-{arch}{variant}{version}"
+	FilePattern string `json:"file_pattern" yaml:"file_pattern" mapstructure:"file_pattern"`
+	// AllowAnyExtension Allows a file extension after the pattern (".zip", ".tar.gz", etc.)
+	AllowAnyExtension bool `json:"allow_any_extension" yaml:"allow_any_extension" mapstructure:"allow_any_extension"`
+}
+
+func DefaultFromS3Config() FromS3Config {
+	return FromS3Config{
+		ManifestName: "version.json",
+		HistoryName:  releaserdto.RELEASE_HISTORY,
+	}
+}
+
+func (c *FromS3Config) GetRef() string {
+	return UpdateClientFromS3Ref + "_config"
+}
+
+func (c *FromS3Config) WithClient(client *objectstore.Client) *FromS3Config {
+	c.Client = client
+	return c
+}
+
+func (c *FromS3Config) WithPrefix(prefix string) *FromS3Config {
+	c.Prefix = prefix
+	return c
+}
+
+func (c *FromS3Config) WithManifestName(name string) *FromS3Config {
+	c.ManifestName = name
+	return c
+}
+
+func (c *FromS3Config) WithHistoryName(name string) *FromS3Config {
+	c.HistoryName = name
+	return c
+}
+
+func (c *FromS3Config) WithFilePattern(pattern string) *FromS3Config {
+	c.FilePattern = pattern
+	return c
+}
+
+func (c *FromS3Config) WithAllowAnyExtension(truthy bool) *FromS3Config {
+	c.AllowAnyExtension = truthy
+	return c
+}
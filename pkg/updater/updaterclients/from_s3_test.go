@@ -0,0 +1,163 @@
+package updaterclients
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joy-dx/gophorth/pkg/objectstore"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/relay"
+)
+
+// fakeS3 serves path style GET and ListObjectsV2 requests for one bucket,
+// rejecting any URL not presigned with the expected credentials
+func fakeS3(t *testing.T, bucket string, creds objectstore.Credentials, objects map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		signedAt, err := time.Parse("20060102T150405Z", query.Get("X-Amz-Date"))
+		if err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		expires, _ := strconv.Atoi(query.Get("X-Amz-Expires"))
+		unsigned := *r.URL
+		unsigned.Scheme, unsigned.Host = "http", r.Host
+		stripped := url.Values{}
+		for key, values := range query {
+			if !strings.HasPrefix(key, "X-Amz-") {
+				stripped[key] = values
+			}
+		}
+		unsigned.RawQuery = stripped.Encode()
+		expected, err := objectstore.Presign(r.Method, unsigned.String(), "us-east-1", creds, time.Duration(expires)*time.Second, signedAt)
+		if err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		expectedURL, _ := url.Parse(expected)
+		if expectedURL.Query().Get("X-Amz-Signature") != query.Get("X-Amz-Signature") {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("<Error><Code>SignatureDoesNotMatch</Code><Message>bad signature</Message></Error>"))
+			return
+		}
+
+		key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/"+bucket), "/")
+		if key == "" && query.Get("list-type") == "2" {
+			type content struct {
+				Key  string `xml:"Key"`
+				Size int64  `xml:"Size"`
+			}
+			result := struct {
+				XMLName  xml.Name  `xml:"ListBucketResult"`
+				Contents []content `xml:"Contents"`
+			}{}
+			for objectKey, body := range objects {
+				if strings.HasPrefix(objectKey, query.Get("prefix")) {
+					result.Contents = append(result.Contents, content{Key: objectKey, Size: int64(len(body))})
+				}
+			}
+			_ = xml.NewEncoder(w).Encode(result)
+			return
+		}
+		body, ok := objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFromS3_CheckUpdate(t *testing.T) {
+	creds := objectstore.Credentials{AccessKeyID: "minio", SecretAccessKey: "minio-secret"}
+	const checksum = "fb04dcb6970e4c3d1873de51fd5a50d7bb46b3383113602665c350ec40b5f990"
+	manifest, err := json.Marshal(releaserdto.ReleaseSummary{
+		Version: "3.0.0",
+		Assets:  []releaserdto.ReleaseAsset{{ArtefactName: "app-example-linux-amd64", Platform: "linux", Arch: "amd64", Checksum: checksum}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed := fakeS3(t, "releases", creds, map[string]string{
+		"app/app-example-linux-amd64-1.0.0":     "v1",
+		"app/app-example-linux-amd64-2.0.0":     "v2",
+		"app/app-example-darwin-arm64-2.0.0":    "v2",
+		"app/old/app-example-linux-amd64-9.0.0": "nested, ignored",
+		"app/checksums.txt":                     checksum + "  app-example-linux-amd64-2.0.0\n",
+	})
+	withManifest := fakeS3(t, "releases", creds, map[string]string{
+		"app/version.json":            string(manifest),
+		"app/app-example-linux-amd64": "v3",
+	})
+
+	tests := []struct {
+		name        string
+		endpoint    string
+		secret      string
+		wantVersion string
+		wantKey     string
+		wantErr     bool
+	}{
+		{name: "listing", endpoint: listed.URL, secret: creds.SecretAccessKey, wantVersion: "2.0.0", wantKey: "/releases/app/app-example-linux-amd64-2.0.0"},
+		{name: "manifest", endpoint: withManifest.URL, secret: creds.SecretAccessKey, wantVersion: "3.0.0", wantKey: "/releases/app/app-example-linux-amd64"},
+		{name: "bad credentials", endpoint: listed.URL, secret: "wrong", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			storeCfg := objectstore.DefaultConfig()
+			storeCfg.WithEndpoint(tc.endpoint).
+				WithBucket("releases").
+				WithCredentials(creds.AccessKeyID, tc.secret, "")
+			s3Cfg := DefaultFromS3Config()
+			s3Cfg.WithClient(objectstore.New(storeCfg)).
+				WithPrefix("app/").
+				WithFilePattern("app-example-{platform}-{arch}{variant}{version}")
+
+			updaterCfg := updaterdto.DefaultUpdaterSvcConfig()
+			updaterCfg.WithRelay(&relay.RelaySvc{})
+			updaterCfg.Platform = "linux"
+			updaterCfg.Architecture = "amd64"
+			updaterCfg.Variant = ""
+
+			client := NewFromS3(&s3Cfg)
+			asset, err := client.CheckUpdate(context.Background(), &updaterCfg)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err=%v wantErr=%v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if asset.Version != tc.wantVersion || asset.Checksum != checksum {
+				t.Fatalf("version=%s checksum=%s", asset.Version, asset.Checksum)
+			}
+
+			if err := client.SignDownloadURLs(context.Background(), &asset); err != nil {
+				t.Fatal(err)
+			}
+			downloadURL, err := url.Parse(asset.DownloadURL)
+			if err != nil || downloadURL.Path != tc.wantKey {
+				t.Fatalf("download url=%s want path %s", asset.DownloadURL, tc.wantKey)
+			}
+			resp, err := http.Get(asset.DownloadURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("presigned download status=%d", resp.StatusCode)
+			}
+		})
+	}
+}
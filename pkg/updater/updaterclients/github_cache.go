@@ -0,0 +1,113 @@
+package updaterclients
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// etagTransport Keeps GET responses carrying an ETag under dir and revalidates
+// them with If-None-Match. GitHub does not count 304 responses against the
+// rate limit, so repeated checks for an unchanged release are free.
+type etagTransport struct {
+	dir  string
+	next http.RoundTripper
+}
+
+// etagEntry A cached response, stored as JSON named by the hash of its request
+type etagEntry struct {
+	ETag   string      `json:"etag"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || t.dir == "" {
+		return t.next.RoundTrip(req)
+	}
+	entryPath := t.entryPath(req)
+	entry, cached := t.load(entryPath)
+	if cached {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+		resp.Body.Close()
+		header := entry.Header.Clone()
+		// Rate limit headers describe now, not when the entry was stored
+		for key, values := range resp.Header {
+			header[key] = values
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+			ContentLength: int64(len(entry.Body)),
+			Request:       req,
+		}, nil
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		body, readErr := io.ReadAll(io.LimitReader(resp.Body, releaseMaxResponseBytes+1))
+		resp.Body.Close()
+		if readErr != nil {
+			return nil, readErr
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if len(body) <= releaseMaxResponseBytes {
+			t.store(entryPath, etagEntry{ETag: resp.Header.Get("ETag"), Header: resp.Header, Body: body})
+		}
+	}
+	return resp, nil
+}
+
+// entryPath names an entry by URL, Accept and credentials, so responses are
+// never shared between tokens and no token is written to disk
+func (t *etagTransport) entryPath(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Accept") + "\n" + req.Header.Get("Authorization")))
+	return filepath.Join(t.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (t *etagTransport) load(entryPath string) (etagEntry, bool) {
+	var entry etagEntry
+	contents, err := os.ReadFile(entryPath)
+	if err != nil || json.Unmarshal(contents, &entry) != nil || entry.ETag == "" {
+		return etagEntry{}, false
+	}
+	return entry, true
+}
+
+// store writes an entry via a temporary file so concurrent checks never read a partial one
+func (t *etagTransport) store(entryPath string, entry etagEntry) {
+	contents, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(t.dir, 0700); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(t.dir, ".entry-*")
+	if err != nil {
+		return
+	}
+	_, writeErr := tmp.Write(contents)
+	if closeErr := tmp.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil || os.Rename(tmp.Name(), entryPath) != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,53 @@
+package updaterclients
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+)
+
+// localFileURL turns a local path in to a file:// URL the updater can download from
+func localFileURL(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	fileURL := url.URL{Scheme: "file", Path: filepath.ToSlash(absPath)}
+	if !strings.HasPrefix(fileURL.Path, "/") {
+		fileURL.Path = "/" + fileURL.Path
+	}
+	return fileURL.String(), nil
+}
+
+// attachSidecars fills in the checksum and signature of a local artefact from
+// the checksums.txt listing and "<artefact>.asc" file sitting next to it. A
+// checksum already on the asset must agree with the listing.
+func attachSidecars(asset *releaserdto.ReleaseAsset, artefactPath string, checksumsPath string) error {
+	if listing, err := os.ReadFile(checksumsPath); err == nil {
+		checksums, parseErr := cryptography.ParseChecksums(listing)
+		if parseErr != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(checksumsPath), parseErr)
+		}
+		listed, ok := checksums[filepath.Base(artefactPath)]
+		switch {
+		case ok && asset.Checksum == "":
+			asset.WithChecksum(listed)
+		case ok && !strings.EqualFold(listed, asset.Checksum):
+			return fmt.Errorf("%s disagrees with the release checksum for %s", filepath.Base(checksumsPath), filepath.Base(artefactPath))
+		}
+	}
+
+	if asset.Signature == "" {
+		if signature, err := os.ReadFile(artefactPath + ".asc"); err == nil {
+			if sigInfo, sigInfoErr := cryptography.DetectSignatureInformation(signature); sigInfoErr == nil {
+				asset.WithSignature(string(signature)).WithSignatureType(sigInfo.Format)
+			}
+		}
+	}
+	return nil
+}
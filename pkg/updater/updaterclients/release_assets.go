@@ -0,0 +1,294 @@
+package updaterclients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+	"github.com/joy-dx/gophorth/pkg/file"
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/stringz"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+// remoteAsset Provider neutral view of a file attached to a hosted release
+type remoteAsset struct {
+	Name string
+	URL  string
+	Size int64
+	// Digest Checksum published by the provider, if any
+	Digest string
+}
+
+// releaseMaxResponseBytes Cap on API responses and sidecar files read in to memory
+const releaseMaxResponseBytes = 8 << 20
+
+// fetchFunc downloads a small release file, e.g. a signature, with provider auth applied
+type fetchFunc func(ctx context.Context, url string) ([]byte, error)
+
+// selectRemoteAsset picks the asset for the current device. With a pattern,
+// names are parsed with the same reverse template as ReleaserSvc.ScanDir,
+// otherwise platform and architecture are guessed from the names.
+func selectRemoteAsset(cfg *updaterdto.UpdaterConfig, pattern string, assets []remoteAsset) (remoteAsset, releaserdto.ReleaseAsset, error) {
+	var found releaserdto.ReleaseAsset
+	if pattern != "" {
+		re, err := stringz.CompileReverseTemplate(stringz.ReverseTemplateOptions{
+			Pattern:           pattern,
+			AllowAnyExtension: true,
+		})
+		if err != nil {
+			return remoteAsset{}, found, err
+		}
+		for _, asset := range assets {
+			if isSignatureName(asset.Name) || isChecksumName(asset.Name) {
+				continue
+			}
+			// Matched without the extension, which the optional {variant} would otherwise swallow
+			g, ok := stringz.MatchReverseTemplate(re, trimArchiveExtension(asset.Name))
+			if !ok || g["platform"] != cfg.Platform || g["arch"] != cfg.Architecture || g["variant"] != cfg.Variant {
+				continue
+			}
+			found.WithPlatform(g["platform"]).
+				WithArch(g["arch"]).
+				WithVariant(g["variant"])
+			return asset, found, nil
+		}
+		return remoteAsset{}, found, fmt.Errorf("no asset matching %q for %s %s", pattern, cfg.Platform, cfg.Architecture)
+	}
+
+	wantOS := strings.ToLower(cfg.Platform)
+	wantArch := strings.ToLower(cfg.Architecture)
+	if wantOS == "" || wantArch == "" {
+		return remoteAsset{}, found, errors.New("platform/arch cannot be empty")
+	}
+	for _, asset := range assets {
+		name := strings.ToLower(asset.Name)
+		if isSignatureName(name) || isChecksumName(name) || isSourceArchive(name) {
+			continue
+		}
+		platform, arch := file.AssetNameGuess(name)
+		if platform != wantOS || arch != wantArch {
+			continue
+		}
+		if cfg.Variant != "" && !strings.Contains(name, strings.ToLower(cfg.Variant)) {
+			continue
+		}
+		found.WithPlatform(platform).
+			WithArch(arch).
+			WithVariant(cfg.Variant)
+		return asset, found, nil
+	}
+	return remoteAsset{}, found, errors.New("no asset found")
+}
+
+// discoverSidecars looks through the other release files for the checksum and
+// signature of chosen: "<name>.sha256", a checksums listing, and "<name>.asc",
+// "<name>.sig" or "<name>.pem". Missing sidecars are not an error, but a listed
+// checksum that disagrees with the provider digest is ErrChecksumConflict.
+func discoverSidecars(ctx context.Context, fetch fetchFunc, assets []remoteAsset, chosen remoteAsset) (checksum string, signature string, err error) {
+	digest := strings.TrimPrefix(strings.ToLower(chosen.Digest), "sha256:")
+	byName := make(map[string]remoteAsset, len(assets))
+	for _, asset := range assets {
+		byName[asset.Name] = asset
+	}
+
+	var listedIn string
+	if asset, ok := byName[chosen.Name+".sha256"]; ok {
+		contents, fetchErr := fetch(ctx, asset.URL)
+		if fetchErr != nil {
+			return digest, "", fmt.Errorf("fetch %s: %w", asset.Name, fetchErr)
+		}
+		if fields := strings.Fields(string(contents)); len(fields) > 0 {
+			checksum, listedIn = strings.ToLower(fields[0]), asset.Name
+		}
+	}
+	if checksum == "" {
+		for _, asset := range assets {
+			if !isChecksumName(asset.Name) || strings.HasPrefix(asset.Name, chosen.Name) {
+				continue
+			}
+			contents, fetchErr := fetch(ctx, asset.URL)
+			if fetchErr != nil {
+				return digest, "", fmt.Errorf("fetch %s: %w", asset.Name, fetchErr)
+			}
+			checksums, parseErr := cryptography.ParseChecksums(contents)
+			if parseErr != nil {
+				continue
+			}
+			if listed, ok := checksums[chosen.Name]; ok {
+				checksum, listedIn = listed, asset.Name
+				break
+			}
+		}
+	}
+	switch {
+	case checksum == "":
+		checksum = digest
+	case digest != "" && checksum != digest:
+		return "", "", fmt.Errorf("%w: %s lists %s for %s, the host publishes %s", updaterdto.ErrChecksumConflict, listedIn, checksum, chosen.Name, digest)
+	}
+
+	for _, suffix := range []string{".asc", ".sig", ".pem"} {
+		asset, ok := byName[chosen.Name+suffix]
+		if !ok {
+			continue
+		}
+		contents, fetchErr := fetch(ctx, asset.URL)
+		if fetchErr != nil {
+			return checksum, "", fmt.Errorf("fetch %s: %w", asset.Name, fetchErr)
+		}
+		signature = string(contents)
+		break
+	}
+	return checksum, signature, nil
+}
+
+func isSignatureName(name string) bool {
+	n := strings.ToLower(name)
+	return strings.HasSuffix(n, ".asc") || strings.HasSuffix(n, ".sig") || strings.HasSuffix(n, ".pem")
+}
+
+// archiveExtensions Suffixes trimmed before matching names against a reverse template
+var archiveExtensions = []string{".tar.gz", ".tar.xz", ".tar.zst", ".tar.bz2", ".tgz", ".txz", ".tar", ".zip", ".gz", ".exe", ".dmg", ".pkg", ".msi", ".deb", ".rpm", ".AppImage"}
+
+func trimArchiveExtension(name string) string {
+	lower := strings.ToLower(name)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, strings.ToLower(ext)) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// httpFetch performs a GET for release APIs and sidecar files, letting the
+// caller decide per URL whether to attach credentials
+func httpFetch(ctx context.Context, client *http.Client, rawURL string, auth func(req *http.Request)) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if auth != nil {
+		auth(req)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, releaseMaxResponseBytes))
+}
+
+func sameHost(rawURL string, baseURL string) bool {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(target.Host, base.Host)
+}
+
+// summaryAssets lists the assets of a releaser summary, defaulting their
+// version and release notes to the summary's and dropping names that are not
+// plain files
+func summaryAssets(summary releaserdto.ReleaseSummary) []releaserdto.ReleaseAsset {
+	assets := make([]releaserdto.ReleaseAsset, 0, len(summary.Assets))
+	for _, asset := range summary.Assets {
+		if asset.ArtefactName == "" || path.Base(asset.ArtefactName) != asset.ArtefactName || strings.Contains(asset.ArtefactName, `\`) {
+			continue
+		}
+		if asset.Version == "" {
+			asset.WithVersion(summary.Version)
+		}
+		asset.WithReleaseSummary(summary)
+		assets = append(assets, asset)
+	}
+	return assets
+}
+
+// historyNotes lists the release notes of a releaser history index
+func historyNotes(history releaserdto.ReleaseHistory) []updaterdto.ReleaseNote {
+	notes := make([]updaterdto.ReleaseNote, 0, len(history.Releases))
+	for _, summary := range history.Releases {
+		notes = append(notes, updaterdto.ReleaseNote{
+			Version:     summary.Version,
+			PublishedAt: summary.PublishedAt,
+			Changelog:   summary.Changelog,
+			ReleaseURL:  summary.ReleaseURL,
+		})
+	}
+	return notes
+}
+
+// patternAssets parses file names with a reverse template, as
+// ReleaserSvc.ScanDir does, with {version} required to rank releases
+func patternAssets(pattern string, allowAnyExtension bool, names []string) ([]releaserdto.ReleaseAsset, error) {
+	re, err := stringz.CompileReverseTemplate(stringz.ReverseTemplateOptions{
+		Pattern:           pattern,
+		AllowAnyExtension: allowAnyExtension,
+		RequireVersion:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var assets []releaserdto.ReleaseAsset
+	for _, name := range names {
+		if isSignatureName(name) || isChecksumName(name) {
+			continue
+		}
+		g, ok := stringz.MatchReverseTemplate(re, name)
+		if !ok {
+			continue
+		}
+		assets = append(assets, releaserdto.ReleaseAsset{
+			ArtefactName: name,
+			Platform:     g["platform"],
+			Arch:         g["arch"],
+			Variant:      g["variant"],
+			Version:      g["version"],
+		})
+	}
+	return assets, nil
+}
+
+// selectHighestAsset picks the highest semantic version built for the current
+// device, skipping prereleases unless allowed
+func selectHighestAsset(cfg *updaterdto.UpdaterConfig, candidates []releaserdto.ReleaseAsset) (releaserdto.ReleaseAsset, bool) {
+	var (
+		best        releaserdto.ReleaseAsset
+		bestVersion *semver.Version
+	)
+	for _, candidate := range candidates {
+		if candidate.Platform != cfg.Platform || candidate.Arch != cfg.Architecture || candidate.Variant != cfg.Variant {
+			continue
+		}
+		version, err := semver.NewVersion(candidate.Version)
+		if err != nil {
+			continue
+		}
+		if version.Prerelease() != "" && !cfg.AllowPrerelease {
+			continue
+		}
+		if bestVersion == nil || version.GreaterThan(bestVersion) {
+			best, bestVersion = candidate, version
+		}
+	}
+	return best, bestVersion != nil
+}
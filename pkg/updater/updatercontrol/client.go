@@ -0,0 +1,163 @@
+package updatercontrol
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/relay/dto"
+)
+
+// baseURL Host part of request URLs, unused as every request goes to the socket
+const baseURL = "http://updater"
+
+// Client Talks to a Server over its unix domain socket
+type Client struct {
+	http *http.Client
+}
+
+func NewClient(socketPath string) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		},
+	}
+	return &Client{http: &http.Client{Transport: transport}}
+}
+
+// State returns the updater state of the running app
+func (c *Client) State(ctx context.Context) (*updaterdto.UpdaterState, error) {
+	var state updaterdto.UpdaterState
+	return &state, c.do(ctx, http.MethodGet, ROUTE_STATE, nil, &state)
+}
+
+// Check asks the running app to check for the latest release
+func (c *Client) Check(ctx context.Context) (releaserdto.ReleaseAsset, error) {
+	var asset releaserdto.ReleaseAsset
+	return asset, c.do(ctx, http.MethodPost, ROUTE_CHECK, nil, &asset)
+}
+
+// Download starts downloading the update found by the last check, follow it with Job
+func (c *Client) Download(ctx context.Context) (updaterdto.JobProgress, error) {
+	var progress updaterdto.JobProgress
+	return progress, c.do(ctx, http.MethodPost, ROUTE_DOWNLOAD, nil, &progress)
+}
+
+// Apply starts downloading, unless already downloaded, and performing the update
+func (c *Client) Apply(ctx context.Context) (updaterdto.JobProgress, error) {
+	var progress updaterdto.JobProgress
+	return progress, c.do(ctx, http.MethodPost, ROUTE_APPLY, nil, &progress)
+}
+
+// Job returns the progress of the last job started
+func (c *Client) Job(ctx context.Context) (updaterdto.JobProgress, error) {
+	var progress updaterdto.JobProgress
+	return progress, c.do(ctx, http.MethodGet, ROUTE_JOB, nil, &progress)
+}
+
+// CancelJob cancels the last job started, returning once it has stopped
+func (c *Client) CancelJob(ctx context.Context) (updaterdto.JobProgress, error) {
+	var progress updaterdto.JobProgress
+	return progress, c.do(ctx, http.MethodPost, ROUTE_JOB_CANCEL, nil, &progress)
+}
+
+// Skip stops version being offered, the pending update when version is empty
+func (c *Client) Skip(ctx context.Context, version string) (*updaterdto.UpdaterState, error) {
+	var state updaterdto.UpdaterState
+	return &state, c.do(ctx, http.MethodPost, ROUTE_SKIP, SkipRequest{Version: version}, &state)
+}
+
+// Rollback reinstates the version kept before the last update
+func (c *Client) Rollback(ctx context.Context) (*updaterdto.UpdaterState, error) {
+	var state updaterdto.UpdaterState
+	return &state, c.do(ctx, http.MethodPost, ROUTE_ROLLBACK, nil, &state)
+}
+
+// Events calls fn for each relay event at least as severe as minLevel, every
+// event when empty, until ctx is cancelled or the server stops
+func (c *Client) Events(ctx context.Context, minLevel dto.RelayLevel, fn func(dto.Event)) error {
+	route := ROUTE_EVENTS
+	if minLevel != "" {
+		route += "?" + url.Values{"level": {string(minLevel)}}.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+route, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("updater control: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var event dto.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return fmt.Errorf("parse event: %w", err)
+		}
+		fn(event)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
+
+func (c *Client) do(ctx context.Context, method string, route string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		contents, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(contents)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+route, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("updater control: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return responseError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("parse %s response: %w", route, err)
+	}
+	return nil
+}
+
+// responseError turns an error response back in to an error, matching the
+// server side error with errors.Is where it is one of knownErrors
+func responseError(resp *http.Response) error {
+	var body ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error == "" {
+		return fmt.Errorf("updater control: %s", resp.Status)
+	}
+	for _, known := range knownErrors {
+		if body.Error == known.Error() {
+			return known
+		}
+	}
+	return fmt.Errorf("updater control: %s", body.Error)
+}
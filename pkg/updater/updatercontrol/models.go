@@ -0,0 +1,40 @@
+package updatercontrol
+
+import (
+	"errors"
+
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+// Routes of the control API, served over HTTP on the socket
+const (
+	ROUTE_STATE      = "/v1/state"
+	ROUTE_CHECK      = "/v1/check"
+	ROUTE_DOWNLOAD   = "/v1/download"
+	ROUTE_APPLY      = "/v1/apply"
+	ROUTE_JOB        = "/v1/job"
+	ROUTE_JOB_CANCEL = "/v1/job/cancel"
+	ROUTE_SKIP       = "/v1/skip"
+	ROUTE_ROLLBACK   = "/v1/rollback"
+	// ROUTE_EVENTS Streams relay events as newline delimited JSON
+	ROUTE_EVENTS = "/v1/events"
+)
+
+// ErrJobRunning A download or update job is already running
+var ErrJobRunning = errors.New("a job is already running")
+
+// ErrNoJob No download or update job has been started
+var ErrNoJob = errors.New("no job started")
+
+// SkipRequest Body of ROUTE_SKIP, an empty version skips the pending update
+type SkipRequest struct {
+	Version string `json:"version"`
+}
+
+// ErrorResponse Body of every failed request
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// knownErrors Errors the client hands back as themselves, for errors.Is
+var knownErrors = []error{ErrJobRunning, ErrNoJob, updaterdto.ErrNoPreviousVersion, updaterdto.ErrServiceInoperable}
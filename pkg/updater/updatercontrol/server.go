@@ -0,0 +1,359 @@
+package updatercontrol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/relay/dto"
+)
+
+// SINK_REF Ref of the server when registered as a relay sink
+const SINK_REF = "updatercontrol"
+
+// Server Lets other processes, e.g. a tray companion or admin tooling, query
+// and drive the updater of a running app over a unix domain socket. Register
+// it as a relay sink for ROUTE_EVENTS to stream events.
+type Server struct {
+	cfg     ServerConfig
+	updater updaterdto.UpdaterInterface
+	// mu Serialises calls in to the updater and guards job. Calls that change
+	// the pending update are refused while a job works on it.
+	mu  sync.Mutex
+	job *updaterdto.Job
+
+	subscribersMu sync.Mutex
+	subscribers   map[chan dto.Event]struct{}
+}
+
+func NewServer(cfg ServerConfig, updater updaterdto.UpdaterInterface) *Server {
+	if cfg.SocketMode == 0 {
+		cfg.SocketMode = DefaultServerConfig().SocketMode
+	}
+	if cfg.EventBuffer <= 0 {
+		cfg.EventBuffer = DefaultServerConfig().EventBuffer
+	}
+	return &Server{
+		cfg:         cfg,
+		updater:     updater,
+		subscribers: map[chan dto.Event]struct{}{},
+	}
+}
+
+// ListenAndServe serves the control API on SocketPath until ctx is cancelled.
+// The socket is removed when the server stops.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	listener, err := listen(s.cfg.SocketPath, s.cfg.SocketMode)
+	if err != nil {
+		return err
+	}
+	httpServer := &http.Server{
+		Handler: s.Handler(),
+		// Ends event streams, which never go idle, when the server stops
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+	if err := httpServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serve control api: %w", err)
+	}
+	return nil
+}
+
+// listen creates the socket with mode, replacing a stale socket left by a
+// crashed process but never one a running server still answers on
+func listen(path string, mode os.FileMode) (net.Listener, error) {
+	if path == "" {
+		return nil, errors.New("no control socket path configured")
+	}
+	if info, err := os.Lstat(path); err == nil {
+		// Never remove something that is not a socket, the path may be misconfigured
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("control socket path %s exists and is not a socket", path)
+		}
+		if conn, dialErr := net.DialTimeout("unix", path, time.Second); dialErr == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("control socket %s already in use", path)
+		}
+		if removeErr := os.Remove(path); removeErr != nil {
+			return nil, fmt.Errorf("remove stale control socket: %w", removeErr)
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("listen on control socket: %w", err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("set control socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// Handler returns the control API, for serving on a listener of your own
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+ROUTE_STATE, s.handleState)
+	mux.HandleFunc("POST "+ROUTE_CHECK, s.handleCheck)
+	mux.HandleFunc("POST "+ROUTE_DOWNLOAD, s.handleDownload)
+	mux.HandleFunc("POST "+ROUTE_APPLY, s.handleApply)
+	mux.HandleFunc("GET "+ROUTE_JOB, s.handleJob)
+	mux.HandleFunc("POST "+ROUTE_JOB_CANCEL, s.handleJobCancel)
+	mux.HandleFunc("POST "+ROUTE_SKIP, s.handleSkip)
+	mux.HandleFunc("POST "+ROUTE_ROLLBACK, s.handleRollback)
+	mux.HandleFunc("GET "+ROUTE_EVENTS, s.handleEvents)
+	return mux
+}
+
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	state := s.updater.State()
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, state)
+}
+
+func (s *Server) handleCheck(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	if s.jobRunning() {
+		s.mu.Unlock()
+		writeError(w, ErrJobRunning)
+		return
+	}
+	asset, err := s.updater.CheckLatest(r.Context())
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, asset)
+}
+
+func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	s.startJob(w, r, func(ctx context.Context) *updaterdto.Job {
+		return s.updater.StartDownload(ctx, nil)
+	}, false)
+}
+
+func (s *Server) handleApply(w http.ResponseWriter, r *http.Request) {
+	s.startJob(w, r, s.updater.StartUpdate, true)
+}
+
+// startJob runs a job detached from the request, one at a time
+func (s *Server) startJob(w http.ResponseWriter, r *http.Request, start func(ctx context.Context) *updaterdto.Job, applies bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.jobRunning() {
+		writeError(w, ErrJobRunning)
+		return
+	}
+	job := start(context.WithoutCancel(r.Context()))
+	s.job = job
+	if applies && s.cfg.OnApplied != nil {
+		go func() {
+			if job.Wait() == nil {
+				s.cfg.OnApplied()
+			}
+		}()
+	}
+	writeJSON(w, http.StatusAccepted, job.Progress())
+}
+
+// jobRunning reports whether the last job started is still running, call with mu held
+func (s *Server) jobRunning() bool {
+	if s.job == nil {
+		return false
+	}
+	select {
+	case <-s.job.Done():
+		return false
+	default:
+		return true
+	}
+}
+
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	job := s.job
+	s.mu.Unlock()
+	if job == nil {
+		writeError(w, ErrNoJob)
+		return
+	}
+	writeJSON(w, http.StatusOK, job.Progress())
+}
+
+func (s *Server) handleJobCancel(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	job := s.job
+	s.mu.Unlock()
+	if job == nil {
+		writeError(w, ErrNoJob)
+		return
+	}
+	job.Cancel()
+	select {
+	case <-job.Done():
+	case <-r.Context().Done():
+		return
+	}
+	writeJSON(w, http.StatusOK, job.Progress())
+}
+
+func (s *Server) handleSkip(w http.ResponseWriter, r *http.Request) {
+	var request SkipRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid skip request: %s", err.Error())})
+		return
+	}
+	s.mu.Lock()
+	if s.jobRunning() {
+		s.mu.Unlock()
+		writeError(w, ErrJobRunning)
+		return
+	}
+	err := s.updater.SkipVersion(request.Version)
+	state := s.updater.State()
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, state)
+}
+
+func (s *Server) handleRollback(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	if s.jobRunning() {
+		s.mu.Unlock()
+		writeError(w, ErrJobRunning)
+		return
+	}
+	err := s.updater.Rollback(r.Context())
+	state := s.updater.State()
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, state)
+	if s.cfg.OnApplied != nil {
+		go s.cfg.OnApplied()
+	}
+}
+
+// handleEvents streams relay events until the client or server goes away. An
+// optional level query parameter sets the least severe level sent.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: "streaming unsupported"})
+		return
+	}
+	minLevel := dto.RelayLevel(r.URL.Query().Get("level"))
+
+	events := make(chan dto.Event, s.cfg.EventBuffer)
+	s.subscribersMu.Lock()
+	s.subscribers[events] = struct{}{}
+	s.subscribersMu.Unlock()
+	defer func() {
+		s.subscribersMu.Lock()
+		delete(s.subscribers, events)
+		s.subscribersMu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-events:
+			if !levelIncluded(event.Level, minLevel) {
+				continue
+			}
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// levelIncluded reports whether level is at least as severe as minLevel.
+// Meta events, and every event when no minimum is set, are included.
+func levelIncluded(level dto.RelayLevel, minLevel dto.RelayLevel) bool {
+	minIdx := slices.Index(dto.Levels, minLevel)
+	idx := slices.Index(dto.Levels, level)
+	return minIdx < 0 || idx < 0 || idx <= minIdx
+}
+
+func (s *Server) Ref() string {
+	return SINK_REF
+}
+
+func (s *Server) Debug(e dto.RelayEventInterface) { s.publish(dto.Debug, e) }
+func (s *Server) Info(e dto.RelayEventInterface)  { s.publish(dto.Info, e) }
+func (s *Server) Warn(e dto.RelayEventInterface)  { s.publish(dto.Warn, e) }
+func (s *Server) Error(e dto.RelayEventInterface) { s.publish(dto.Error, e) }
+func (s *Server) Fatal(e dto.RelayEventInterface) { s.publish(dto.Fatal, e) }
+func (s *Server) Meta(e dto.RelayEventInterface)  { s.publish(dto.Meta, e) }
+
+// publish hands an event to every streaming client. Clients whose buffer is
+// full miss the event rather than holding up the updater.
+func (s *Server) publish(level dto.RelayLevel, e dto.RelayEventInterface) {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	if len(s.subscribers) == 0 {
+		return
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	event := dto.Event{
+		Channel:   e.RelayChannel(),
+		Ref:       e.RelayType(),
+		Level:     level,
+		Timestamp: time.Now(),
+		Data:      data,
+	}
+	for subscriber := range s.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrJobRunning), errors.Is(err, updaterdto.ErrNoPreviousVersion):
+		status = http.StatusConflict
+	case errors.Is(err, ErrNoJob):
+		status = http.StatusNotFound
+	case errors.Is(err, updaterdto.ErrServiceInoperable):
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
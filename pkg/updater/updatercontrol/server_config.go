@@ -0,0 +1,57 @@
+package updatercontrol
+
+import (
+	"os"
+	"time"
+)
+
+// ServerConfig Control server configuration
+type ServerConfig struct {
+	// SocketPath Unix domain socket the server listens on. Its directory must
+	// exist and should only be reachable by the users allowed to connect.
+	SocketPath string
+	// SocketMode Permissions of the socket file, the only access control. 0600
+	// limits access to the daemon's user, 0660 adds its group.
+	SocketMode os.FileMode
+	// EventBuffer Events held per streaming client before further events are
+	// dropped for it, so slow clients never hold up the updater
+	EventBuffer int
+	// ShutdownTimeout How long requests may take to finish once the server stops
+	ShutdownTimeout time.Duration
+	// OnApplied Optional, called once an update or rollback has been handed to
+	// the helper. The app should quit so the helper can replace it.
+	OnApplied func()
+}
+
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		SocketMode:      0600,
+		EventBuffer:     64,
+		ShutdownTimeout: 5 * time.Second,
+	}
+}
+
+func (c *ServerConfig) WithSocketPath(path string) *ServerConfig {
+	c.SocketPath = path
+	return c
+}
+
+func (c *ServerConfig) WithSocketMode(mode os.FileMode) *ServerConfig {
+	c.SocketMode = mode
+	return c
+}
+
+func (c *ServerConfig) WithEventBuffer(events int) *ServerConfig {
+	c.EventBuffer = events
+	return c
+}
+
+func (c *ServerConfig) WithShutdownTimeout(timeout time.Duration) *ServerConfig {
+	c.ShutdownTimeout = timeout
+	return c
+}
+
+func (c *ServerConfig) WithOnApplied(fn func()) *ServerConfig {
+	c.OnApplied = fn
+	return c
+}
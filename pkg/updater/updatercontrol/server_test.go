@@ -0,0 +1,183 @@
+package updatercontrol
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+	"github.com/joy-dx/relay/dto"
+)
+
+// fakeUpdater Implements the calls the server makes, the embedded interface
+// panics on any other
+type fakeUpdater struct {
+	updaterdto.UpdaterInterface
+	skipped []string
+}
+
+func (f *fakeUpdater) State() *updaterdto.UpdaterState {
+	return &updaterdto.UpdaterState{Version: "1.0.0", SkippedVersions: f.skipped}
+}
+
+func (f *fakeUpdater) SkipVersion(version string) error {
+	f.skipped = append(f.skipped, version)
+	return nil
+}
+
+func (f *fakeUpdater) Rollback(ctx context.Context) error {
+	return updaterdto.ErrNoPreviousVersion
+}
+
+// StartUpdate runs until cancelled
+func (f *fakeUpdater) StartUpdate(ctx context.Context) *updaterdto.Job {
+	return updaterdto.StartJob(ctx, func(ctx context.Context) error {
+		updaterdto.ReportJobStage(ctx, updaterdto.JOB_DOWNLOADING)
+		<-ctx.Done()
+		return ctx.Err()
+	}, nil)
+}
+
+type testEvent struct {
+	Msg string `json:"msg"`
+}
+
+func (e testEvent) RelayChannel() dto.EventChannel { return "test" }
+func (e testEvent) RelayType() dto.EventRef        { return "test.event" }
+func (e testEvent) Message() string                { return e.Msg }
+func (e testEvent) ToSlog() []slog.Attr            { return nil }
+
+func startServer(t *testing.T) (*Server, *Client, string) {
+	t.Helper()
+	socketPath := filepath.Join(t.TempDir(), "updater.sock")
+	cfg := DefaultServerConfig()
+	cfg.WithSocketPath(socketPath)
+	server := NewServer(cfg, &fakeUpdater{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- server.ListenAndServe(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-served; err != nil {
+			t.Errorf("serve: %v", err)
+		}
+	})
+
+	client := NewClient(socketPath)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := client.State(context.Background()); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("control server never came up")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return server, client, socketPath
+}
+
+func TestServer_Requests(t *testing.T) {
+	_, client, socketPath := startServer(t)
+	ctx := context.Background()
+
+	info, err := os.Stat(socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Fatalf("socket mode=%o want 600", mode)
+	}
+	second := NewServer(ServerConfig{SocketPath: socketPath}, &fakeUpdater{})
+	if err := second.ListenAndServe(ctx); err == nil {
+		t.Fatal("expected a second server on the same socket to fail")
+	}
+
+	state, err := client.Skip(ctx, "2.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.SkippedVersions) != 1 || state.SkippedVersions[0] != "2.0.0" {
+		t.Fatalf("skipped=%v", state.SkippedVersions)
+	}
+	if _, err := client.Rollback(ctx); !errors.Is(err, updaterdto.ErrNoPreviousVersion) {
+		t.Fatalf("rollback err=%v want %v", err, updaterdto.ErrNoPreviousVersion)
+	}
+	if _, err := client.Job(ctx); !errors.Is(err, ErrNoJob) {
+		t.Fatalf("job err=%v want %v", err, ErrNoJob)
+	}
+
+	if _, err := client.Apply(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Apply(ctx); !errors.Is(err, ErrJobRunning) {
+		t.Fatalf("second apply err=%v want %v", err, ErrJobRunning)
+	}
+	progress, err := client.CancelJob(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Stage != updaterdto.JOB_CANCELLED {
+		t.Fatalf("stage=%s want %s", progress.Stage, updaterdto.JOB_CANCELLED)
+	}
+}
+
+func TestServer_Events(t *testing.T) {
+	server, client, _ := startServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan dto.Event, 4)
+	streamed := make(chan error, 1)
+	go func() {
+		streamed <- client.Events(ctx, dto.Info, func(event dto.Event) {
+			received <- event
+		})
+	}()
+
+	// Publish until the stream is subscribed
+	deadline := time.After(5 * time.Second)
+	var event dto.Event
+	for event.Ref == "" {
+		server.Debug(testEvent{Msg: "too verbose"})
+		server.Warn(testEvent{Msg: "hello"})
+		select {
+		case event = <-received:
+		case <-time.After(20 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("no event streamed")
+		}
+	}
+	if event.Level != dto.Warn || event.Ref != "test.event" || string(event.Data) != `{"msg":"hello"}` {
+		t.Fatalf("event=%+v data=%s", event, event.Data)
+	}
+
+	cancel()
+	if err := <-streamed; err != nil {
+		t.Fatalf("events: %v", err)
+	}
+}
+
+func TestServer_ListenKeepsNonSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "updater.sock")
+	if err := os.WriteFile(socketPath, []byte("not a socket"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(ServerConfig{SocketPath: socketPath}, &fakeUpdater{})
+	// Bounded in case the file is replaced and the server starts serving
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := server.ListenAndServe(ctx); err == nil {
+		t.Fatal("expected an error listening over a regular file")
+	}
+	if contents, err := os.ReadFile(socketPath); err != nil || string(contents) != "not a socket" {
+		t.Fatalf("file at the socket path was changed: %q %v", contents, err)
+	}
+}
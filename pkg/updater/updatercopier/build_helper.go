@@ -0,0 +1,84 @@
+package updatercopier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// HelperPackage Go package path of the update helper source
+const HelperPackage = "github.com/joy-dx/gophorth/pkg/updater/updatercopier/cmd"
+
+// BuildHelperOptions Configuration for compiling the update helper on demand
+type BuildHelperOptions struct {
+	// GoBinary Path or name of the go toolchain binary
+	GoBinary string
+	// GOOS Target platform, defaults to the running platform
+	GOOS string
+	// GOARCH Target architecture, defaults to the running architecture
+	GOARCH string
+	// OutputPath Where the compiled helper is written
+	OutputPath string
+	// WorkDir Directory of a module requiring gophorth, used to resolve HelperPackage
+	WorkDir string
+}
+
+func DefaultBuildHelperOptions() BuildHelperOptions {
+	return BuildHelperOptions{
+		GoBinary: "go",
+		GOOS:     runtime.GOOS,
+		GOARCH:   runtime.GOARCH,
+	}
+}
+
+func (o *BuildHelperOptions) WithGoBinary(path string) *BuildHelperOptions {
+	o.GoBinary = path
+	return o
+}
+
+func (o *BuildHelperOptions) WithTarget(goos string, goarch string) *BuildHelperOptions {
+	o.GOOS = goos
+	o.GOARCH = goarch
+	return o
+}
+
+func (o *BuildHelperOptions) WithOutputPath(path string) *BuildHelperOptions {
+	o.OutputPath = path
+	return o
+}
+
+func (o *BuildHelperOptions) WithWorkDir(path string) *BuildHelperOptions {
+	o.WorkDir = path
+	return o
+}
+
+// BuildHelper compiles the update helper with the go toolchain. Useful for
+// targets without an embedded helper, either at release time to ship alongside
+// the app or at runtime on machines with a toolchain available.
+func BuildHelper(ctx context.Context, opts BuildHelperOptions) (string, error) {
+	if opts.OutputPath == "" {
+		return "", fmt.Errorf("build helper: output path required")
+	}
+	outputPath, err := filepath.Abs(opts.OutputPath)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return "", fmt.Errorf("build helper: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, opts.GoBinary, "build", "-trimpath", "-ldflags", "-s -w", "-o", outputPath, HelperPackage)
+	cmd.Dir = opts.WorkDir
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=0", "GOOS="+opts.GOOS, "GOARCH="+opts.GOARCH)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("build helper: %w: %s", err, output.String())
+	}
+	return outputPath, nil
+}
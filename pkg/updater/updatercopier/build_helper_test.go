@@ -0,0 +1,43 @@
+package updatercopier
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBuildHelper(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles the helper")
+	}
+	goBinary, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go toolchain available")
+	}
+
+	if _, err := BuildHelper(context.Background(), DefaultBuildHelperOptions()); err == nil {
+		t.Fatal("expected an error without an output path")
+	}
+
+	name := "update-helper"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	opts := DefaultBuildHelperOptions()
+	opts.WithGoBinary(goBinary).
+		WithOutputPath(filepath.Join(t.TempDir(), "bin", name))
+	helperPath, err := BuildHelper(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := exec.Command(helperPath, HelperPreflightArg).Output()
+	if err != nil {
+		t.Fatalf("preflight: %v", err)
+	}
+	if strings.TrimSpace(string(output)) != "ok" {
+		t.Fatalf("preflight output=%q want ok", output)
+	}
+}
@@ -2,16 +2,25 @@ package main
 
 import (
 	"fmt"
-	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
+
+	"github.com/joy-dx/gophorth/pkg/updater/updaterswap"
 )
 
+// preflightArg Runs no update, only confirms the helper can execute on this machine
+const preflightArg = "--preflight"
+
 func main() {
+	if len(os.Args) == 2 && os.Args[1] == preflightArg {
+		fmt.Println("ok")
+		os.Exit(0)
+	}
 	if len(os.Args) < 3 {
 		fmt.Println("Usage: update-helper <old_path> <new_path> <log_path>")
 		os.Exit(1)
@@ -31,144 +40,87 @@ func main() {
 
 	logLine(logFile, "Updater starting. old=%s new=%s", pathToReplace, replacementFilePath)
 
-	backupPath := pathToReplace + ".bak"
+	// Step 1: Finish or revert a swap interrupted during an earlier run
+	outcome, err := updaterswap.Recover(pathToReplace, journalLogger(logFile))
+	if err != nil {
+		logLine(logFile, "Recovering interrupted swap failed: %v", err)
+		os.Exit(1)
+	}
+	if outcome != updaterswap.OUTCOME_NONE {
+		logLine(logFile, "Interrupted swap recovered: %s", outcome)
+	}
 
-	// Step 1: Back up existing binary
-	logLine(logFile, "Creating backup at %s", backupPath)
-	if err := copyPath(pathToReplace, backupPath); err != nil {
-		logLine(logFile, "Backup failed: %v", err)
+	// Step 2: Stage the replacement next to the existing version
+	swapper := updaterswap.New(pathToReplace, journalLogger(logFile))
+	if err := swapper.Stage(replacementFilePath); err != nil {
+		logLine(logFile, "Staging failed: %v", err)
 		os.Exit(1)
 	}
 
-	// Step 2: Wait for main app to fully exit
-	successfulRename := false
-	logLine(logFile, "Starting rename process")
+	// Step 3: Wait for main app to fully exit
+	successfulSwap := false
+	logLine(logFile, "Starting swap process")
 	for i := 0; i < 20; i++ {
-		if err := os.RemoveAll(pathToReplace); err != nil {
-			logLine(logFile, "Failed to remove old version (attempt %d): %v", i+1, err)
+		if err := swapper.Swap(); err != nil {
+			logLine(logFile, "Swap failed attempt %d: %v", i+1, err)
 			time.Sleep(1 * time.Second)
 			continue
 		}
-		if err := os.Rename(replacementFilePath, pathToReplace); err != nil {
-			logLine(logFile, "renaming failed attempt %d: %v", i+1, err)
-			time.Sleep(1 * time.Second)
-		} else {
-			logLine(logFile, "Successfully replaced old binary.")
-			successfulRename = true
-			break
-		}
-
+		logLine(logFile, "Successfully replaced old binary. Previous version kept at %s", swapper.Journal().Backup)
+		successfulSwap = true
+		break
 	}
 
-	if !successfulRename {
-		logLine(logFile, "Replacement failed after 20 attempts. Restoring backup.")
-		restoreBackup(logFile, backupPath, pathToReplace)
+	if !successfulSwap {
+		logLine(logFile, "Replacement failed after 20 attempts. Discarding staged replacement.")
+		if err := swapper.Rollback(); err != nil {
+			logLine(logFile, "Failed to discard staged replacement: %v", err)
+		}
 		os.Exit(2)
 	}
 
+	// Hand the target over before launching, otherwise the new app's own
+	// recovery could finish the swap, deleting the backup, while we still need it
+	if err := swapper.Release(); err != nil {
+		logLine(logFile, "Failed to release swap journal: %v", err)
+	}
+
 	logLine(logFile, "Attempting to launch new binary")
 	if err := launchApp(logFile, pathToReplace); err != nil {
 		logLine(logFile, "Launch failed: %v", err)
 		logLine(logFile, "Rolling back to backup.")
-		restoreBackup(logFile, backupPath, pathToReplace)
+		restoreBackup(logFile, swapper, pathToReplace)
 		os.Exit(3)
 	}
 
 	logLine(logFile, "New binary launched successfully.")
-	cleanupHelper(logFile, backupPath)
-	logLine(logFile, "Helper finished.")
-
-}
-
-// Detects if the path is a directory (.app on mac) and copies accordingly.
-func copyPath(src, dst string) error {
-	info, err := os.Stat(src)
-	if err != nil {
-		return err
-	}
-
-	// On macOS, .app is actually a directory
-	if runtime.GOOS == "darwin" && info.IsDir() && filepath.Ext(src) == ".app" {
-		return copyDir(src, dst)
+	if err := swapper.Commit(); err != nil {
+		logLine(logFile, "Failed to commit swap: %v", err)
 	}
+	cleanupHelper(logFile)
+	logLine(logFile, "Helper finished.")
 
-	if info.IsDir() {
-		return copyDir(src, dst)
-	}
-	return copyFile(src, dst)
 }
 
-// For regular file copy
-func copyFile(src, dst string) error {
-	srcFile, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer srcFile.Close()
-
-	dstFile, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer dstFile.Close()
-
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
-		return err
-	}
-	return dstFile.Sync()
-}
+func cleanupHelper(logFile *os.File) {
+	logLine(logFile, "Cleaning temporary files.")
 
-// For recursive directory copy (used for .app and fallback dirs)
-func copyDir(src, dst string) error {
-	info, err := os.Stat(src)
+	self, err := os.Executable()
 	if err != nil {
-		return err
-	}
-	if err := os.MkdirAll(dst, info.Mode()); err != nil {
-		return err
+		self = os.Args[0]
 	}
-	entries, err := os.ReadDir(src)
-	if err != nil {
-		return err
+	// Only remove directories the updater extracted to, app supplied helpers are reused
+	helperDir := filepath.Dir(self)
+	if !strings.HasPrefix(filepath.Base(helperDir), "gophorth-helper-") {
+		logLine(logFile, "Helper not extracted by the updater, leaving %s in place", self)
+		return
 	}
-	for _, entry := range entries {
-		srcPath := filepath.Join(src, entry.Name())
-		dstPath := filepath.Join(dst, entry.Name())
-		if entry.IsDir() {
-			if err := copyDir(srcPath, dstPath); err != nil {
-				return err
-			}
-		} else {
-			if err := copyFile(srcPath, dstPath); err != nil {
-				return err
-			}
-		}
+	// A running executable can be unlinked on unix, the process keeps its open inode
+	if err := os.RemoveAll(helperDir); err != nil {
+		logLine(logFile, "Error removing helper (%s): %v", helperDir, err)
+		return
 	}
-	return nil
-}
-
-func cleanupHelper(logFile *os.File, backupPath string) {
-	logLine(logFile, "Cleaning temporary files.")
-
-	// Remove backup — works for files or .app directories
-	if err := os.RemoveAll(backupPath); err != nil {
-		logLine(logFile, fmt.Sprintf(
-			"Error cleaning up backup files at %s: %v", backupPath, err,
-		))
-	}
-
-	// Self-delete after leaving a short delay
-	self := os.Args[0]
-	go func() {
-		time.Sleep(1 * time.Second)
-		if err := os.RemoveAll(self); err != nil {
-			logLine(logFile, fmt.Sprintf(
-				"Error removing helper (%s): %v", self, err,
-			))
-		} else {
-			logLine(logFile, "Helper self-deleted successfully.")
-		}
-	}()
+	logLine(logFile, "Helper self-deleted successfully.")
 }
 
 // launchApp starts the target application in a platform‑safe way.
@@ -204,16 +156,10 @@ func logLine(logFile *os.File, msg string, args ...interface{}) {
 	}
 }
 
-func restoreBackup(logFile *os.File, backupPath, targetPath string) {
-	logLine(logFile, "Restoring backup from %s to %s", backupPath, targetPath)
-
-	// Remove existing broken version (handles .app directories)
-	if err := os.RemoveAll(targetPath); err != nil {
-		logLine(logFile, "Failed to remove unwanted new version: %v", err)
-	}
+func restoreBackup(logFile *os.File, swapper *updaterswap.Swapper, targetPath string) {
+	logLine(logFile, "Restoring backup from %s to %s", swapper.Journal().Backup, targetPath)
 
-	// Move backup into place
-	if err := os.Rename(backupPath, targetPath); err != nil {
+	if err := swapper.Rollback(); err != nil {
 		logLine(logFile, "Failed to restore backup: %v", err)
 		return
 	}
@@ -224,3 +170,10 @@ func restoreBackup(logFile *os.File, backupPath, targetPath string) {
 		logLine(logFile, "Old version relaunched successfully.")
 	}
 }
+
+// journalLogger routes swap progress to the update log
+func journalLogger(logFile *os.File) updaterswap.LogFunc {
+	return func(format string, args ...interface{}) {
+		logLine(logFile, format, args...)
+	}
+}
@@ -5,5 +5,7 @@ package updatercopier
 
 import "embed"
 
-//go:embed assets/update-helper-darwin-amd64
+const embeddedHelperName = "assets/update-helper-darwin-amd64"
+
+//go:embed assets/update-helper-darwin-amd64 assets/update-helper-darwin-amd64.sha256
 var embeddedHelpers embed.FS
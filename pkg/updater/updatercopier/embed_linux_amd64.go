@@ -5,5 +5,7 @@ package updatercopier
 
 import "embed"
 
-//go:embed assets/update-helper-linux-amd64
+const embeddedHelperName = "assets/update-helper-linux-amd64"
+
+//go:embed assets/update-helper-linux-amd64 assets/update-helper-linux-amd64.sha256
 var embeddedHelpers embed.FS
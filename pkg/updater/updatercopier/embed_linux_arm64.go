@@ -5,5 +5,7 @@ package updatercopier
 
 import "embed"
 
-//go:embed assets/update-helper-linux-arm64
+const embeddedHelperName = "assets/update-helper-linux-arm64"
+
+//go:embed assets/update-helper-linux-arm64 assets/update-helper-linux-arm64.sha256
 var embeddedHelpers embed.FS
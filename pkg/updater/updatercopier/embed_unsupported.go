@@ -0,0 +1,16 @@
+//go:build !(darwin && amd64) && !(darwin && arm64) && !(linux && amd64) && !(linux && arm64)
+// +build !darwin !amd64
+// +build !darwin !arm64
+// +build !linux !amd64
+// +build !linux !arm64
+
+package updatercopier
+
+import "embed"
+
+// No prebuilt helper ships for this GOOS/GOARCH. Supply one with
+// UpdaterConfig.WithHelperPath / WithHelperFunc, build one with BuildHelper or
+// enable UpdaterConfig.InProcessFallback.
+const embeddedHelperName = ""
+
+var embeddedHelpers embed.FS
@@ -1,40 +1,130 @@
 package updatercopier
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
+	"time"
+
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
 )
 
-func ExtractHelper(extractPath string) (string, error) {
-	var helperNameBuilder strings.Builder
+// HelperDirPrefix Name prefix of the private directories helpers are extracted to
+const HelperDirPrefix = "gophorth-helper-"
+
+// HelperPreflightArg Argument making a helper exit successfully without updating, used to check it runs
+const HelperPreflightArg = "--preflight"
 
-	switch runtime.GOOS {
-	case "darwin":
-		helperNameBuilder.WriteString("assets/update-helper-darwin")
-	default:
-		helperNameBuilder.WriteString("assets/update-helper-linux")
+// staleHelperAge Helpers finish within seconds, anything older was left behind by an earlier run
+const staleHelperAge = 10 * time.Minute
+
+// HasEmbeddedHelper reports whether a prebuilt helper ships for the running GOOS/GOARCH
+func HasEmbeddedHelper() bool {
+	return embeddedHelperName != ""
+}
+
+// EmbeddedHelperChecksum returns the SHA-256 of the embedded helper recorded when it was built
+func EmbeddedHelperChecksum() (string, error) {
+	if !HasEmbeddedHelper() {
+		return "", updaterdto.ErrNoHelper
+	}
+	recorded, err := embeddedHelpers.ReadFile(embeddedHelperName + ".sha256")
+	if err != nil {
+		return "", fmt.Errorf("failed to read embedded helper checksum: %w", err)
+	}
+	// shasum format: "<checksum>  <filename>"
+	fields := strings.Fields(string(recorded))
+	if len(fields) == 0 {
+		return "", errors.New("embedded helper checksum is empty")
 	}
+	return fields[0], nil
+}
 
-	switch runtime.GOARCH {
-	case "amd64":
-		helperNameBuilder.WriteString("-amd64")
-	case "arm64":
-		helperNameBuilder.WriteString("-arm64")
+// ExtractHelper writes the embedded helper to a freshly created private (0700)
+// directory with a random name within extractPath and verifies the written file
+// against the checksum recorded at build time. Stale helpers from earlier runs
+// are removed first.
+func ExtractHelper(extractPath string) (string, error) {
+	if !HasEmbeddedHelper() {
+		return "", updaterdto.ErrNoHelper
 	}
 
-	data, err := embeddedHelpers.ReadFile(helperNameBuilder.String())
+	expectedChecksum, err := EmbeddedHelperChecksum()
+	if err != nil {
+		return "", err
+	}
+	data, err := embeddedHelpers.ReadFile(embeddedHelperName)
 	if err != nil {
 		return "", fmt.Errorf("failed to read embedded helper: %w", err)
 	}
 
-	tmpPath := filepath.Join(extractPath, "/gophorth-helper")
+	if err := os.MkdirAll(extractPath, 0700); err != nil {
+		return "", fmt.Errorf("failed to create extract path: %w", err)
+	}
+	RemoveStaleHelpers(extractPath)
 
-	if err := os.WriteFile(tmpPath, data, 0755); err != nil {
+	helperDir, err := os.MkdirTemp(extractPath, HelperDirPrefix)
+	if err != nil {
+		return "", fmt.Errorf("failed to create helper directory: %w", err)
+	}
+	helperPath := filepath.Join(helperDir, "gophorth-helper")
+
+	helperFile, err := os.OpenFile(helperPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0700)
+	if err != nil {
+		_ = os.RemoveAll(helperDir)
+		return "", fmt.Errorf("failed to write helper: %w", err)
+	}
+	if _, err := helperFile.Write(data); err != nil {
+		helperFile.Close()
+		_ = os.RemoveAll(helperDir)
+		return "", fmt.Errorf("failed to write helper: %w", err)
+	}
+	if err := helperFile.Close(); err != nil {
+		_ = os.RemoveAll(helperDir)
 		return "", fmt.Errorf("failed to write helper: %w", err)
 	}
 
-	return tmpPath, nil
+	if err := VerifyHelper(helperPath, expectedChecksum); err != nil {
+		_ = os.RemoveAll(helperDir)
+		return "", err
+	}
+
+	return helperPath, nil
+}
+
+// VerifyHelper checks the helper on disk matches the expected SHA-256 before it is executed
+func VerifyHelper(helperPath string, expectedChecksum string) error {
+	actualChecksum, err := cryptography.Sha256SumFile(helperPath)
+	if err != nil {
+		return fmt.Errorf("failed to checksum helper: %w", err)
+	}
+	if !strings.EqualFold(actualChecksum, strings.TrimSpace(expectedChecksum)) {
+		return fmt.Errorf("%w: expected %s, got %s", updaterdto.ErrHelperIntegrity, expectedChecksum, actualChecksum)
+	}
+	return nil
+}
+
+// RemoveStaleHelpers deletes helpers left in extractPath by earlier runs,
+// including the fixed location used by older releases
+func RemoveStaleHelpers(extractPath string) {
+	_ = os.Remove(filepath.Join(extractPath, "gophorth-helper"))
+
+	entries, err := os.ReadDir(extractPath)
+	if err != nil {
+		return
+	}
+	threshold := time.Now().Add(-staleHelperAge)
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), HelperDirPrefix) {
+			continue
+		}
+		info, infoErr := entry.Info()
+		if infoErr != nil || info.ModTime().After(threshold) {
+			continue
+		}
+		_ = os.RemoveAll(filepath.Join(extractPath, entry.Name()))
+	}
 }
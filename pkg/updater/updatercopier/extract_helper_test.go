@@ -0,0 +1,105 @@
+package updatercopier
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joy-dx/gophorth/pkg/updater/updaterdto"
+)
+
+func TestExtractHelper(t *testing.T) {
+	if !HasEmbeddedHelper() {
+		t.Skip("no helper embedded for this platform")
+	}
+	extractPath := t.TempDir()
+
+	legacyHelper := filepath.Join(extractPath, "gophorth-helper")
+	staleDir := filepath.Join(extractPath, HelperDirPrefix+"stale")
+	if err := os.WriteFile(legacyHelper, []byte("legacy"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(staleDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	staleTime := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(staleDir, staleTime, staleTime); err != nil {
+		t.Fatal(err)
+	}
+
+	helperPath, err := ExtractHelper(extractPath)
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+
+	helperDir := filepath.Dir(helperPath)
+	if filepath.Dir(helperDir) != extractPath || !strings.HasPrefix(filepath.Base(helperDir), HelperDirPrefix) {
+		t.Fatalf("helper extracted to unexpected location %s", helperPath)
+	}
+	dirInfo, err := os.Stat(helperDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dirInfo.Mode().Perm() != 0700 {
+		t.Fatalf("helper dir mode=%v want 0700", dirInfo.Mode().Perm())
+	}
+	helperInfo, err := os.Stat(helperPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if helperInfo.Mode().Perm() != 0700 {
+		t.Fatalf("helper mode=%v want 0700", helperInfo.Mode().Perm())
+	}
+
+	for _, stale := range []string{legacyHelper, staleDir} {
+		if _, err := os.Lstat(stale); !os.IsNotExist(err) {
+			t.Fatalf("expected stale helper %s to be removed, err=%v", stale, err)
+		}
+	}
+
+	secondPath, err := ExtractHelper(extractPath)
+	if err != nil {
+		t.Fatalf("second extract: %v", err)
+	}
+	if filepath.Dir(secondPath) == helperDir {
+		t.Fatalf("expected a fresh directory per extraction, got %s twice", helperDir)
+	}
+}
+
+func TestVerifyHelper(t *testing.T) {
+	helperPath := filepath.Join(t.TempDir(), "helper")
+	if err := os.WriteFile(helperPath, []byte("helper"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		checksum string
+		wantErr  error
+	}{
+		{
+			name:     "matching checksum",
+			checksum: "e81d3b0e9d82feaaf5f6e55bdff24731d7eee08632ffa63801e6397290c5d20a",
+		},
+		{
+			name:     "matching checksum upper case",
+			checksum: "E81D3B0E9D82FEAAF5F6E55BDFF24731D7EEE08632FFA63801E6397290C5D20A",
+		},
+		{
+			name:     "mismatched checksum",
+			checksum: strings.Repeat("0", 64),
+			wantErr:  updaterdto.ErrHelperIntegrity,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyHelper(helperPath, tc.checksum)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err=%v want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
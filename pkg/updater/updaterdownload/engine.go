@@ -0,0 +1,358 @@
+package updaterdownload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrChecksumMismatch The completed download does not match the expected checksum
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Request Describes a single download
+type Request struct {
+	URL string
+	// Destination Final path. Data is written to PartPath(Destination) until complete
+	Destination string
+	// Checksum Optional expected SHA-256, verified from the hash kept while downloading
+	Checksum string
+	// Header Optional, e.g. credentials for a private release. Dropped when a
+	// redirect leaves the host of URL.
+	Header http.Header
+}
+
+// Progress Snapshot of a running download
+type Progress struct {
+	URL            string
+	Downloaded     int64
+	Total          int64
+	BytesPerSecond float64
+}
+
+type ProgressFunc func(Progress)
+
+// Engine Downloads artefacts with a bandwidth limit and pause controls that
+// can be changed while downloads run. Interrupted downloads leave a .part file
+// which the next Download of the same destination resumes.
+type Engine struct {
+	cfg     EngineConfig
+	limiter *limiter
+	gate    gate
+}
+
+func New(cfg EngineConfig) *Engine {
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	if cfg.ProgressInterval <= 0 {
+		cfg.ProgressInterval = time.Second
+	}
+	if cfg.SegmentSize <= 0 {
+		cfg.SegmentSize = DefaultEngineConfig().SegmentSize
+	}
+	return &Engine{
+		cfg:     cfg,
+		limiter: newLimiter(cfg.RateLimit),
+	}
+}
+
+// PartPath returns where an in-flight download of destination is written
+func PartPath(destination string) string {
+	return destination + ".part"
+}
+
+// ValidatorPath returns where the ETag or Last-Modified of the artefact a
+// partial download came from is kept, checked with If-Range before resuming
+func ValidatorPath(destination string) string {
+	return PartPath(destination) + ".validator"
+}
+
+// SetRateLimit changes the bytes per second limit of running and future downloads, 0 removes it
+func (e *Engine) SetRateLimit(bytesPerSecond int64) {
+	e.limiter.setRate(bytesPerSecond)
+}
+
+func (e *Engine) RateLimit() int64 {
+	return e.limiter.getRate()
+}
+
+// Pause stops downloads reading from the network until Resume is called
+func (e *Engine) Pause() {
+	e.gate.pause()
+}
+
+func (e *Engine) Resume() {
+	e.gate.unpause()
+}
+
+func (e *Engine) Paused() bool {
+	return e.gate.paused()
+}
+
+// Download fetches req.URL to req.Destination. Cancelling ctx keeps the partial
+// file for a later call to resume from. A checksum mismatch discards it.
+func (e *Engine) Download(ctx context.Context, req Request, progress ProgressFunc) error {
+	partPath := PartPath(req.Destination)
+	if err := os.MkdirAll(filepath.Dir(partPath), 0755); err != nil {
+		return fmt.Errorf("create destination folder: %w", err)
+	}
+	part, err := os.OpenFile(partPath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("open partial download: %w", err)
+	}
+	defer part.Close()
+
+	// Resuming needs the hash of what is already on disk
+	hasher := sha256.New()
+	offset, err := io.Copy(hasher, part)
+	if err != nil {
+		return fmt.Errorf("read partial download: %w", err)
+	}
+	validator := ""
+	if offset > 0 {
+		validator = readValidator(req.Destination)
+		if validator == "" && req.Checksum == "" {
+			// Nothing could tell whether the partial file belongs to this artefact
+			if err := resetPart(part, hasher); err != nil {
+				return err
+			}
+			offset = 0
+		}
+	}
+
+	if e.cfg.Segments > 1 {
+		total, rangesSupported, probeValidator, probeErr := e.probeRanges(ctx, req, validator)
+		if probeErr != nil {
+			return probeErr
+		}
+		switch {
+		case rangesSupported && offset >= total:
+			return e.complete(part, hasher, req)
+		case rangesSupported && total-offset >= 2*e.cfg.SegmentSize:
+			if offset == 0 {
+				if err := writeValidator(req.Destination, probeValidator); err != nil {
+					return err
+				}
+			}
+			if err := e.downloadSegmented(ctx, part, hasher, req, probeValidator, offset, total, progress); err != nil {
+				_ = part.Sync()
+				return err
+			}
+			return e.complete(part, hasher, req)
+		}
+		// No range support or too small to be worth splitting, use a single stream
+	}
+
+	httpReq, err := newGet(ctx, req)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	if offset > 0 {
+		httpReq.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if validator != "" {
+			// A changed artefact is sent whole rather than spliced on to the old bytes
+			httpReq.Header.Set("If-Range", validator)
+		}
+	}
+	resp, err := e.client(req).Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("start download: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		// Server honoured the range, append to what we have
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// Partial file already holds everything
+		return e.complete(part, hasher, req)
+	case resp.StatusCode == http.StatusOK:
+		if offset > 0 {
+			// Range ignored or the artefact changed, start over
+			if err := resetPart(part, hasher); err != nil {
+				return err
+			}
+			offset = 0
+		}
+		if err := writeValidator(req.Destination, responseValidator(resp)); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("bad HTTP status: %s", resp.Status)
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	if err := e.copy(ctx, part, hasher, resp.Body, req.URL, offset, total, progress); err != nil {
+		// Keep what was written so the download can resume
+		_ = part.Sync()
+		return err
+	}
+	return e.complete(part, hasher, req)
+}
+
+// copy streams body to the partial file and hasher, honouring pause and the rate limit
+func (e *Engine) copy(ctx context.Context, part *os.File, hasher hash.Hash, body io.Reader, url string, downloaded int64, total int64, progress ProgressFunc) error {
+	buf := make([]byte, 32*1024)
+	lastReport := time.Now()
+	lastDownloaded := downloaded
+	for {
+		read, readErr := e.read(ctx, body, buf)
+		if read > 0 {
+			if _, err := part.Write(buf[:read]); err != nil {
+				return fmt.Errorf("write partial download: %w", err)
+			}
+			hasher.Write(buf[:read])
+			downloaded += int64(read)
+		}
+		if progress != nil && (time.Since(lastReport) >= e.cfg.ProgressInterval || errors.Is(readErr, io.EOF)) {
+			elapsed := time.Since(lastReport).Seconds()
+			speed := 0.0
+			if elapsed > 0 {
+				speed = float64(downloaded-lastDownloaded) / elapsed
+			}
+			progress(Progress{URL: url, Downloaded: downloaded, Total: total, BytesPerSecond: speed})
+			lastReport = time.Now()
+			lastDownloaded = downloaded
+		}
+		if errors.Is(readErr, io.EOF) {
+			if total >= 0 && downloaded < total {
+				return fmt.Errorf("download ended early at %d of %d bytes", downloaded, total)
+			}
+			return nil
+		}
+		if readErr != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("file transfer failed for %s: %w", url, readErr)
+		}
+	}
+}
+
+// read performs a single read from body once unpaused and within the rate limit
+func (e *Engine) read(ctx context.Context, body io.Reader, buf []byte) (int, error) {
+	if err := e.gate.wait(ctx); err != nil {
+		return 0, err
+	}
+	n := e.limiter.allowance(len(buf))
+	if err := e.limiter.wait(ctx, n); err != nil {
+		return 0, err
+	}
+	return body.Read(buf[:n])
+}
+
+// complete verifies the checksum and moves the partial file in to place
+func (e *Engine) complete(part *os.File, hasher hash.Hash, req Request) error {
+	if err := part.Sync(); err != nil {
+		return fmt.Errorf("sync download: %w", err)
+	}
+	if err := part.Close(); err != nil {
+		return fmt.Errorf("close download: %w", err)
+	}
+	partPath := PartPath(req.Destination)
+	_ = os.Remove(ValidatorPath(req.Destination))
+	if req.Checksum != "" {
+		actual := hex.EncodeToString(hasher.Sum(nil))
+		if !strings.EqualFold(actual, strings.TrimSpace(req.Checksum)) {
+			_ = os.Remove(partPath)
+			return fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, req.Checksum, actual)
+		}
+	}
+	if err := os.Rename(partPath, req.Destination); err != nil {
+		return fmt.Errorf("move download in to place: %w", err)
+	}
+	return nil
+}
+
+// resetPart empties the partial file to download from the start
+func resetPart(part *os.File, hasher hash.Hash) error {
+	if err := part.Truncate(0); err != nil {
+		return fmt.Errorf("reset partial download: %w", err)
+	}
+	if _, err := part.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("reset partial download: %w", err)
+	}
+	hasher.Reset()
+	return nil
+}
+
+// responseValidator returns what If-Range can compare against, a strong ETag
+// or failing that Last-Modified. Weak ETags are not allowed in If-Range.
+func responseValidator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
+
+func readValidator(destination string) string {
+	contents, err := os.ReadFile(ValidatorPath(destination))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(contents))
+}
+
+// writeValidator records the validator of a download starting from scratch,
+// removing a stale one when the server sent none
+func writeValidator(destination string, validator string) error {
+	if validator == "" {
+		if err := os.Remove(ValidatorPath(destination)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove download validator: %w", err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(ValidatorPath(destination), []byte(validator), 0600); err != nil {
+		return fmt.Errorf("record download validator: %w", err)
+	}
+	return nil
+}
+
+// newGet builds the GET of req.URL, carrying req.Header
+func newGet(ctx context.Context, req Request) (*http.Request, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, req.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range req.Header {
+		httpReq.Header[key] = append([]string(nil), values...)
+	}
+	return httpReq, nil
+}
+
+// client returns the configured client, wrapped for requests with headers so
+// a redirect to another host, e.g. object storage, never receives them
+func (e *Engine) client(req Request) *http.Client {
+	if len(req.Header) == 0 {
+		return e.cfg.Client
+	}
+	client := *e.cfg.Client
+	checkRedirect := e.cfg.Client.CheckRedirect
+	client.CheckRedirect = func(redirect *http.Request, via []*http.Request) error {
+		if !strings.EqualFold(redirect.URL.Host, via[0].URL.Host) {
+			for key := range req.Header {
+				redirect.Header.Del(key)
+			}
+		}
+		if checkRedirect != nil {
+			return checkRedirect(redirect, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &client
+}
@@ -0,0 +1,55 @@
+package updaterdownload
+
+import (
+	"net/http"
+	"time"
+)
+
+// EngineConfig Download engine configuration
+type EngineConfig struct {
+	// Client HTTP client used for requests
+	Client *http.Client
+	// RateLimit Maximum bytes per second read from the network, 0 for unlimited
+	RateLimit int64
+	// ProgressInterval How often progress callbacks fire
+	ProgressInterval time.Duration
+	// Segments Number of byte ranges fetched concurrently, 1 for a single stream
+	Segments int
+	// SegmentSize Size of each byte range request. Segments awaiting hashing are
+	// held in memory, at most Segments * 2 * SegmentSize bytes
+	SegmentSize int64
+}
+
+func DefaultEngineConfig() EngineConfig {
+	return EngineConfig{
+		Client:           http.DefaultClient,
+		ProgressInterval: time.Second,
+		Segments:         1,
+		SegmentSize:      4 * 1024 * 1024,
+	}
+}
+
+func (c *EngineConfig) WithClient(client *http.Client) *EngineConfig {
+	c.Client = client
+	return c
+}
+
+func (c *EngineConfig) WithRateLimit(bytesPerSecond int64) *EngineConfig {
+	c.RateLimit = bytesPerSecond
+	return c
+}
+
+func (c *EngineConfig) WithProgressInterval(interval time.Duration) *EngineConfig {
+	c.ProgressInterval = interval
+	return c
+}
+
+func (c *EngineConfig) WithSegments(segments int) *EngineConfig {
+	c.Segments = segments
+	return c
+}
+
+func (c *EngineConfig) WithSegmentSize(bytes int64) *EngineConfig {
+	c.SegmentSize = bytes
+	return c
+}
@@ -0,0 +1,210 @@
+package updaterdownload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newArtefactServer(t *testing.T, artefact []byte) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "artefact", time.Time{}, bytes.NewReader(artefact))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func checksumOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestEngine_Download(t *testing.T) {
+	artefact := bytes.Repeat([]byte("gophorth"), 4096)
+	server := newArtefactServer(t, artefact)
+
+	tests := []struct {
+		name     string
+		existing []byte
+		checksum string
+		wantErr  error
+	}{
+		{
+			name:     "fresh download",
+			checksum: checksumOf(artefact),
+		},
+		{
+			name:     "resumes partial file",
+			existing: artefact[:10000],
+			checksum: checksumOf(artefact),
+		},
+		{
+			name:     "partial file already complete",
+			existing: artefact,
+			checksum: checksumOf(artefact),
+		},
+		{
+			name:     "checksum mismatch discards download",
+			checksum: checksumOf([]byte("other")),
+			wantErr:  ErrChecksumMismatch,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			destination := filepath.Join(t.TempDir(), "artefact")
+			if tc.existing != nil {
+				if err := os.WriteFile(PartPath(destination), tc.existing, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			engine := New(DefaultEngineConfig())
+			err := engine.Download(context.Background(), Request{URL: server.URL, Destination: destination, Checksum: tc.checksum}, nil)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err=%v want %v", err, tc.wantErr)
+			}
+			if _, statErr := os.Stat(PartPath(destination)); !os.IsNotExist(statErr) {
+				t.Fatalf("partial file left behind: %v", statErr)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+			contents, err := os.ReadFile(destination)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(contents, artefact) {
+				t.Fatalf("downloaded %d bytes, want %d", len(contents), len(artefact))
+			}
+		})
+	}
+}
+
+func TestEngine_RateLimit(t *testing.T) {
+	artefact := bytes.Repeat([]byte("x"), 30*1024)
+	server := newArtefactServer(t, artefact)
+
+	cfg := DefaultEngineConfig()
+	cfg.WithRateLimit(20 * 1024)
+	engine := New(cfg)
+
+	started := time.Now()
+	if err := engine.Download(context.Background(), Request{URL: server.URL, Destination: filepath.Join(t.TempDir(), "artefact")}, nil); err != nil {
+		t.Fatal(err)
+	}
+	// The first second worth is not available up front, so 30KiB at 20KiB/s takes about 1.5s
+	if elapsed := time.Since(started); elapsed < time.Second {
+		t.Fatalf("download took %s, rate limit not applied", elapsed)
+	}
+}
+
+func TestEngine_PauseAndCancelKeepsPartial(t *testing.T) {
+	artefact := bytes.Repeat([]byte("x"), 64*1024)
+	server := newArtefactServer(t, artefact)
+
+	cfg := DefaultEngineConfig()
+	cfg.WithRateLimit(16 * 1024).WithProgressInterval(10 * time.Millisecond)
+	engine := New(cfg)
+	destination := filepath.Join(t.TempDir(), "artefact")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	paused := make(chan int64, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- engine.Download(ctx, Request{URL: server.URL, Destination: destination}, func(p Progress) {
+			if p.Downloaded > 0 && !engine.Paused() {
+				engine.Pause()
+				paused <- p.Downloaded
+			}
+		})
+	}()
+
+	downloadedAtPause := <-paused
+	time.Sleep(300 * time.Millisecond)
+	info, err := os.Stat(PartPath(destination))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != downloadedAtPause {
+		t.Fatalf("download progressed while paused: %d -> %d", downloadedAtPause, info.Size())
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err=%v want context.Canceled", err)
+	}
+	if _, err := os.Stat(PartPath(destination)); err != nil {
+		t.Fatalf("partial file not kept: %v", err)
+	}
+
+	engine.Resume()
+	engine.SetRateLimit(0)
+	if err := engine.Download(context.Background(), Request{URL: server.URL, Destination: destination, Checksum: checksumOf(artefact)}, nil); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+}
+
+func TestEngine_ResumeValidatesPartial(t *testing.T) {
+	artefact := bytes.Repeat([]byte("gophorth"), 64*1024)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v2"`)
+		http.ServeContent(w, r, "artefact", time.Time{}, bytes.NewReader(artefact))
+	}))
+	t.Cleanup(server.Close)
+	stale := bytes.Repeat([]byte("stale"), 2000)
+
+	tests := []struct {
+		name      string
+		existing  []byte
+		validator string
+		segments  int
+	}{
+		{name: "matching validator resumes", existing: artefact[:10000], validator: `"v2"`},
+		{name: "changed artefact starts over", existing: stale, validator: `"v1"`},
+		{name: "changed artefact starts over when segmented", existing: stale, validator: `"v1"`, segments: 4},
+		{name: "no validator or checksum starts over", existing: stale},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			destination := filepath.Join(t.TempDir(), "artefact")
+			if err := os.WriteFile(PartPath(destination), tc.existing, 0600); err != nil {
+				t.Fatal(err)
+			}
+			if tc.validator != "" {
+				if err := os.WriteFile(ValidatorPath(destination), []byte(tc.validator), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cfg := DefaultEngineConfig()
+			if tc.segments > 0 {
+				cfg.WithSegments(tc.segments).WithSegmentSize(32 * 1024)
+			}
+			// No checksum, only the validator can catch a stale partial file
+			if err := New(cfg).Download(context.Background(), Request{URL: server.URL, Destination: destination}, nil); err != nil {
+				t.Fatal(err)
+			}
+			contents, err := os.ReadFile(destination)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(contents, artefact) {
+				t.Fatalf("downloaded %d bytes not matching the artefact of %d bytes", len(contents), len(artefact))
+			}
+			if _, statErr := os.Stat(ValidatorPath(destination)); !os.IsNotExist(statErr) {
+				t.Fatalf("validator left behind: %v", statErr)
+			}
+		})
+	}
+}
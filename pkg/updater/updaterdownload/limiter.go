@@ -0,0 +1,95 @@
+package updaterdownload
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// limiter is a token bucket holding at most one second worth of bytes. The
+// rate can be changed while downloads are running.
+type limiter struct {
+	mu     sync.Mutex
+	rate   int64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(rate int64) *limiter {
+	return &limiter{rate: rate, last: time.Now()}
+}
+
+func (l *limiter) setRate(rate int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	l.rate = rate
+}
+
+func (l *limiter) getRate() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// allowance returns how many bytes may be read in one go, keeping each read
+// small enough for rate changes to take effect quickly
+func (l *limiter) allowance(want int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate <= 0 {
+		return want
+	}
+	// Reads of a tenth of a second keep throughput smooth
+	chunk := int(l.rate / 10)
+	if chunk < 1 {
+		chunk = 1
+	}
+	if want > chunk {
+		return chunk
+	}
+	return want
+}
+
+// wait blocks until n bytes may be consumed, returning early when ctx ends
+func (l *limiter) wait(ctx context.Context, n int) error {
+	for {
+		l.mu.Lock()
+		if l.rate <= 0 {
+			l.mu.Unlock()
+			return nil
+		}
+		l.refill()
+		if l.tokens >= float64(n) {
+			l.tokens -= float64(n)
+			l.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((float64(n) - l.tokens) / float64(l.rate) * float64(time.Second))
+		l.mu.Unlock()
+
+		// Re-check periodically so a raised limit applies without waiting out the old one
+		if delay > 100*time.Millisecond {
+			delay = 100 * time.Millisecond
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// refill adds tokens for the time passed since the last refill, callers hold mu
+func (l *limiter) refill() {
+	now := time.Now()
+	if l.rate > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+		if l.tokens > float64(l.rate) {
+			l.tokens = float64(l.rate)
+		}
+	}
+	l.last = now
+}
@@ -0,0 +1,252 @@
+package updaterdownload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// segment A byte range of the artefact, end exclusive
+type segment struct {
+	index int
+	start int64
+	end   int64
+}
+
+// probeRanges asks for the first byte to learn the artefact size, whether the
+// server honours range requests and the validator of the artefact. When
+// resuming, the stored validator is sent so a changed artefact reports no range
+// support, leaving the single stream to start over.
+func (e *Engine) probeRanges(ctx context.Context, req Request, validator string) (int64, bool, string, error) {
+	httpReq, err := newGet(ctx, req)
+	if err != nil {
+		return 0, false, "", fmt.Errorf("build request: %w", err)
+	}
+	httpReq.Header.Set("Range", "bytes=0-0")
+	if validator != "" {
+		httpReq.Header.Set("If-Range", validator)
+	}
+	resp, err := e.client(req).Do(httpReq)
+	if err != nil {
+		return 0, false, "", fmt.Errorf("start download: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1))
+
+	if resp.StatusCode != http.StatusPartialContent {
+		if resp.StatusCode >= 400 {
+			return 0, false, "", fmt.Errorf("bad HTTP status: %s", resp.Status)
+		}
+		return 0, false, "", nil
+	}
+	// Content-Range: bytes 0-0/<total>
+	contentRange := resp.Header.Get("Content-Range")
+	slash := strings.LastIndex(contentRange, "/")
+	if slash < 0 {
+		return 0, false, "", nil
+	}
+	total, err := strconv.ParseInt(contentRange[slash+1:], 10, 64)
+	if err != nil {
+		// Unknown total ("*")
+		return 0, false, "", nil
+	}
+	return total, true, responseValidator(resp), nil
+}
+
+// downloadSegmented fetches [offset, total) as concurrent range requests.
+// Segments are held in memory until every earlier one has landed, then hashed
+// and appended in order, so verifying the checksum needs no second read and
+// the partial file never holds anything but a contiguous prefix, even when the
+// process dies mid-download. Workers only run a bounded window ahead of the
+// hashed watermark, capping memory use.
+//
+// On failure the partial file is truncated to the watermark, keeping exactly
+// the hashed prefix for a later download to resume from.
+func (e *Engine) downloadSegmented(ctx context.Context, part *os.File, hasher hash.Hash, req Request, validator string, offset int64, total int64, progress ProgressFunc) error {
+	var segments []segment
+	for start := offset; start < total; start += e.cfg.SegmentSize {
+		end := start + e.cfg.SegmentSize
+		if end > total {
+			end = total
+		}
+		segments = append(segments, segment{index: len(segments), start: start, end: end})
+	}
+	window := e.cfg.Segments * 2
+
+	segmentCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		cond      = sync.NewCond(&mu)
+		next      int
+		hashed    int
+		pending   = map[int][]byte{}
+		failure   error
+		fetched   atomic.Int64
+		waitGroup sync.WaitGroup
+	)
+	fetched.Store(offset)
+
+	worker := func() {
+		defer waitGroup.Done()
+		for {
+			mu.Lock()
+			for failure == nil && next < len(segments) && next >= hashed+window {
+				cond.Wait()
+			}
+			if failure != nil || next >= len(segments) {
+				mu.Unlock()
+				return
+			}
+			current := segments[next]
+			next++
+			mu.Unlock()
+
+			data, err := e.fetchSegment(segmentCtx, req, validator, current, &fetched)
+
+			mu.Lock()
+			if err == nil {
+				pending[current.index] = data
+				for failure == nil {
+					ready, ok := pending[hashed]
+					if !ok {
+						break
+					}
+					if _, writeErr := part.WriteAt(ready, segments[hashed].start); writeErr != nil {
+						err = fmt.Errorf("write partial download: %w", writeErr)
+						break
+					}
+					hasher.Write(ready)
+					delete(pending, hashed)
+					hashed++
+				}
+			}
+			if err != nil {
+				if failure == nil {
+					failure = err
+				}
+				cancel()
+			}
+			cond.Broadcast()
+			mu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	stopReports := make(chan struct{})
+	reportsDone := make(chan struct{})
+	go func() {
+		defer close(reportsDone)
+		if progress == nil {
+			return
+		}
+		ticker := time.NewTicker(e.cfg.ProgressInterval)
+		defer ticker.Stop()
+		lastReport := time.Now()
+		lastFetched := fetched.Load()
+		report := func() {
+			current := fetched.Load()
+			speed := 0.0
+			if elapsed := time.Since(lastReport).Seconds(); elapsed > 0 {
+				speed = float64(current-lastFetched) / elapsed
+			}
+			progress(Progress{URL: req.URL, Downloaded: current, Total: total, BytesPerSecond: speed})
+			lastReport = time.Now()
+			lastFetched = current
+		}
+		for {
+			select {
+			case <-ticker.C:
+				report()
+			case <-stopReports:
+				report()
+				return
+			}
+		}
+	}()
+
+	workers := e.cfg.Segments
+	if workers > len(segments) {
+		workers = len(segments)
+	}
+	waitGroup.Add(workers)
+	for i := 0; i < workers; i++ {
+		go worker()
+	}
+	waitGroup.Wait()
+	close(stopReports)
+	<-reportsDone
+
+	if failure == nil {
+		return nil
+	}
+	watermark := total
+	if hashed < len(segments) {
+		watermark = segments[hashed].start
+	}
+	if err := part.Truncate(watermark); err != nil {
+		return errors.Join(failure, fmt.Errorf("trim partial download: %w", err))
+	}
+	if _, err := part.Seek(watermark, io.SeekStart); err != nil {
+		return errors.Join(failure, err)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return failure
+}
+
+// fetchSegment downloads a single byte range in to memory. With a validator, a
+// server whose artefact changed since the probe sends the whole file instead,
+// failing the segment rather than mixing two versions.
+func (e *Engine) fetchSegment(ctx context.Context, req Request, validator string, current segment, fetched *atomic.Int64) ([]byte, error) {
+	httpReq, err := newGet(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	httpReq.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", current.start, current.end-1))
+	if validator != "" {
+		httpReq.Header.Set("If-Range", validator)
+	}
+	resp, err := e.client(req).Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("start segment %d: %w", current.index, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("segment %d: range not honoured: %s", current.index, resp.Status)
+	}
+
+	data := make([]byte, current.end-current.start)
+	var filled int
+	for filled < len(data) {
+		read, readErr := e.read(ctx, resp.Body, data[filled:])
+		filled += read
+		fetched.Add(int64(read))
+		if readErr != nil {
+			if errors.Is(readErr, io.EOF) && filled == len(data) {
+				break
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if errors.Is(readErr, io.EOF) {
+				return nil, fmt.Errorf("segment %d ended early at %d of %d bytes", current.index, filled, len(data))
+			}
+			return nil, fmt.Errorf("segment %d: %w", current.index, readErr)
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,165 @@
+package updaterdownload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEngine_DownloadSegmented(t *testing.T) {
+	artefact := make([]byte, 1024*1024+123)
+	for i := range artefact {
+		artefact[i] = byte(i * 31)
+	}
+
+	tests := []struct {
+		name         string
+		handler      func(rangeRequests *atomic.Int32) http.HandlerFunc
+		wantSegments bool
+	}{
+		{
+			name: "ranges supported",
+			handler: func(rangeRequests *atomic.Int32) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					if r.Header.Get("Range") != "" {
+						rangeRequests.Add(1)
+					}
+					http.ServeContent(w, r, "artefact", time.Time{}, bytes.NewReader(artefact))
+				}
+			},
+			wantSegments: true,
+		},
+		{
+			name: "ranges ignored falls back to a single stream",
+			handler: func(rangeRequests *atomic.Int32) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					_, _ = w.Write(artefact)
+				}
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var rangeRequests atomic.Int32
+			server := httptest.NewServer(tc.handler(&rangeRequests))
+			defer server.Close()
+
+			cfg := DefaultEngineConfig()
+			cfg.WithSegments(4).WithSegmentSize(64 * 1024)
+			destination := filepath.Join(t.TempDir(), "artefact")
+			err := New(cfg).Download(context.Background(), Request{URL: server.URL, Destination: destination, Checksum: checksumOf(artefact)}, nil)
+			if err != nil {
+				t.Fatalf("download: %v", err)
+			}
+			contents, err := os.ReadFile(destination)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(contents, artefact) {
+				t.Fatal("assembled artefact differs")
+			}
+			// One probe plus a request per segment
+			if gotSegmented := rangeRequests.Load() > 1; gotSegmented != tc.wantSegments {
+				t.Fatalf("range requests=%d, want segmented=%v", rangeRequests.Load(), tc.wantSegments)
+			}
+		})
+	}
+}
+
+func TestEngine_DownloadSegmented_CancelKeepsHashedPrefix(t *testing.T) {
+	artefact := bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+	server := newArtefactServer(t, artefact)
+
+	cfg := DefaultEngineConfig()
+	cfg.WithSegments(4).WithSegmentSize(64 * 1024).WithRateLimit(256 * 1024).WithProgressInterval(10 * time.Millisecond)
+	engine := New(cfg)
+	destination := filepath.Join(t.TempDir(), "artefact")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := engine.Download(ctx, Request{URL: server.URL, Destination: destination}, func(p Progress) {
+		if p.Downloaded > 256*1024 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err=%v want context.Canceled", err)
+	}
+	partial, err := os.ReadFile(PartPath(destination))
+	if err != nil {
+		t.Fatalf("partial file not kept: %v", err)
+	}
+	if len(partial)%(64*1024) != 0 || !bytes.Equal(partial, artefact[:len(partial)]) {
+		t.Fatalf("partial file is not a whole segment prefix: %d bytes", len(partial))
+	}
+
+	engine.SetRateLimit(0)
+	if err := engine.Download(context.Background(), Request{URL: server.URL, Destination: destination, Checksum: checksumOf(artefact)}, nil); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+}
+
+func TestEngine_DownloadSegmented_PartialHoldsOnlyPrefix(t *testing.T) {
+	artefact := bytes.Repeat([]byte("0123456789abcdef"), 32*1024)
+	release := make(chan struct{})
+	var laterSegments atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch rangeHeader := r.Header.Get("Range"); {
+		case rangeHeader == "bytes=0-0":
+		case strings.HasPrefix(rangeHeader, "bytes=0-"):
+			// Hold the first segment back while later ones land
+			<-release
+		default:
+			laterSegments.Add(1)
+		}
+		http.ServeContent(w, r, "artefact", time.Time{}, bytes.NewReader(artefact))
+	}))
+	defer server.Close()
+	var releaseOnce sync.Once
+	releaseFirst := func() { releaseOnce.Do(func() { close(release) }) }
+	// Registered after server.Close so it runs first, a failed test must not leave the handler blocked
+	defer releaseFirst()
+
+	cfg := DefaultEngineConfig()
+	cfg.WithSegments(4).WithSegmentSize(64 * 1024)
+	destination := filepath.Join(t.TempDir(), "artefact")
+	done := make(chan error, 1)
+	go func() {
+		done <- New(cfg).Download(context.Background(), Request{URL: server.URL, Destination: destination}, nil)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for laterSegments.Load() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatal("later segments never requested")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	// A crash now must not leave anything a resume would mistake for data
+	partial, err := os.ReadFile(PartPath(destination))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(partial) != 0 {
+		t.Fatalf("partial file holds %d bytes before the first segment landed", len(partial))
+	}
+
+	releaseFirst()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	contents, err := os.ReadFile(destination)
+	if err != nil || !bytes.Equal(contents, artefact) {
+		t.Fatalf("assembled artefact differs, err=%v", err)
+	}
+}
@@ -18,12 +18,22 @@ func CobraAndViper(cmd *cobra.Command) {
 	configBuilder.AddBoolParam(options.UpdaterAllowDowngrade, false, "allows downgrading to older versions")
 	configBuilder.AddBoolParam(options.UpdaterAllowPrerelease, false, "allows updating to pre-release versions")
 	configBuilder.AddStringParam(options.UpdaterArchitecture, runtime.GOARCH, "If no conforming to GOOS standards, string representing architecture part")
+	configBuilder.AddStringParam(options.UpdaterCacheDir, "", "Optional directory of downloaded artefacts keyed by checksum, may be shared by several apps")
+	configBuilder.AddIntParam(options.UpdaterCacheMaxBytes, 0, "Size cap of the cache directory, least recently used artefacts are evicted beyond it. 0 for no cap")
 	configBuilder.AddDurationParam(options.UpdaterCheckInterval, 48*time.Hour, "How often to check for latest JoyDX version")
 	configBuilder.AddStringParam(options.UpdaterCurrentVersion, "0.0.1", "Semantic version representing current runtime version")
+	configBuilder.AddBoolParam(options.UpdaterDownloadEngine, false, "Download with the built-in engine, supporting a rate limit, pause and resume of partial files")
+	configBuilder.AddIntParam(options.UpdaterDownloadRateLimit, 0, "Bytes per second the download engine may read, 0 for unlimited")
+	configBuilder.AddIntParam(options.UpdaterDownloadSegments, 1, "Byte ranges fetched concurrently by the download engine, enabling it when above 1")
+	configBuilder.AddStringParam(options.UpdaterHelperPath, "", "Optional path to an update helper binary to use instead of the embedded one")
+	configBuilder.AddStringParam(options.UpdaterHelperChecksum, "", "Optional SHA-256 an app supplied helper must match before it is executed")
+	configBuilder.AddBoolParam(options.UpdaterInProcessFallback, false, "Replace the app from within the running process when no update helper is available")
+	configBuilder.AddBoolParam(options.UpdaterKeepPrevious, false, "Keep a copy of the running version before updating so the update can be rolled back")
 	configBuilder.AddStringParam(options.UpdaterLogPath, ".", "Local file system path used during update as log path")
 	configBuilder.AddStringParam(options.UpdaterPlatform, runtime.GOOS, "If no conforming to GOOS standards, string representing platform part")
 	configBuilder.AddStringParam(options.UpdaterPublicKey, "", "Contains ASCII encode EDCSA or PGP public key")
 	configBuilder.AddStringParam(options.UpdaterPublicKeyPath, "", "Path to EDCSA or PGP public key")
+	configBuilder.AddStringParam(options.UpdaterStatePath, "", "Optional file the updater persists state to across runs, required for migrations")
 	configBuilder.AddStringParam(options.UpdaterTemporaryPath, "./tmp", "Where to store download and update artefacts")
 	configBuilder.AddStringParam(options.UpdaterVariant, "", "Represents a download variant that the current device wants")
 }
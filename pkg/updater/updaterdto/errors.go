@@ -1,5 +1,40 @@
 package updaterdto
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 var ErrServiceInoperable = errors.New("service is inoperative")
+
+// ErrNoHelper No update helper is embedded for the running GOOS/GOARCH and none was supplied
+var ErrNoHelper = errors.New("no update helper available for this platform")
+
+// ErrHelperIntegrity The update helper on disk does not match its expected checksum
+var ErrHelperIntegrity = errors.New("update helper failed integrity check")
+
+// ErrNoPreviousVersion No copy of an earlier version was kept to roll back to
+var ErrNoPreviousVersion = errors.New("no previous version kept to roll back to")
+
+// ErrChecksumConflict Checksums published for an artefact, e.g. by the host and in a checksums file, disagree
+var ErrChecksumConflict = errors.New("published checksums disagree")
+
+// ErrRateLimited A release host is refusing requests until its rate limit resets
+var ErrRateLimited = errors.New("rate limited by release host")
+
+// RateLimitError Details of a rate limit response, matching ErrRateLimited with errors.Is
+type RateLimitError struct {
+	Host      string    `json:"host"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited by %s until %s", e.Host, e.ResetAt.Format(time.RFC3339))
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
@@ -2,6 +2,7 @@ package updaterdto
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"github.com/Masterminds/semver"
@@ -12,8 +13,14 @@ type UpdaterInterface interface {
 	CheckLatest(ctx context.Context) (releaserdto.ReleaseAsset, error)
 	DownloadUpdate(ctx context.Context, link *releaserdto.ReleaseAsset) error
 	Hydrate(ctx context.Context) error
+	Migrations() []MigrationResult
 	PerformUpdate(ctx context.Context) error
 	PostInstallCleanup() error
+	Rollback(ctx context.Context) error
+	SimulateUpdate(ctx context.Context) (*SimulationReport, error)
+	SkipVersion(version string) error
+	StartDownload(ctx context.Context, link *releaserdto.ReleaseAsset) *Job
+	StartUpdate(ctx context.Context) *Job
 	State() *UpdaterState
 	Status() UpdateStatus
 	UpdateLog() string
@@ -25,6 +32,25 @@ type CheckClientInterface interface {
 	GetVersionLink() (releaserdto.ReleaseAsset, error)
 }
 
+// DownloadURLSignerInterface Optional for check clients whose download URLs
+// expire, e.g. presigned object storage URLs. Called just before downloading.
+type DownloadURLSignerInterface interface {
+	SignDownloadURLs(ctx context.Context, asset *releaserdto.ReleaseAsset) error
+}
+
+// DownloadHeadersInterface Optional for check clients whose artefacts need
+// credentials, e.g. private GitLab or Gitea projects. Called for every URL
+// downloaded from, returning nil for URLs that need none.
+type DownloadHeadersInterface interface {
+	DownloadHeaders(rawURL string) http.Header
+}
+
+// ReleaseHistoryInterface Optional for check clients able to list past
+// releases, used to combine the notes of every version an update skips
+type ReleaseHistoryInterface interface {
+	ReleaseHistory(ctx context.Context, cfg *UpdaterConfig) ([]ReleaseNote, error)
+}
+
 // UpdateClientInterface Common Methods used to
 type UpdateClientInterface interface {
 	ArtefactPath() string
@@ -0,0 +1,125 @@
+package updaterdto
+
+import (
+	"context"
+	"sync"
+)
+
+// JobStage Step a background job has reached
+type JobStage string
+
+const (
+	JOB_PENDING     JobStage = "pending"
+	JOB_DOWNLOADING JobStage = "downloading"
+	JOB_VERIFYING   JobStage = "verifying"
+	JOB_INSTALLING  JobStage = "installing"
+	JOB_COMPLETE    JobStage = "complete"
+	JOB_CANCELLED   JobStage = "cancelled"
+	JOB_FAILED      JobStage = "failed"
+)
+
+// JobProgress Snapshot of a job, safe to hand to a GUI
+type JobProgress struct {
+	Stage          JobStage `json:"stage"`
+	Downloaded     int64    `json:"downloaded"`
+	Total          int64    `json:"total"`
+	BytesPerSecond float64  `json:"bytes_per_second"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// Job Handle on a long running operation started in the background, e.g. by
+// UpdaterSvc.StartUpdate. Done closes once the job has finished, including
+// any clean up after Cancel.
+type Job struct {
+	cancel   context.CancelFunc
+	done     chan struct{}
+	mu       sync.Mutex
+	progress JobProgress
+	err      error
+}
+
+type jobContextKey struct{}
+
+// StartJob runs fn in the background with a context that Cancel, or the parent
+// context, cancels. When fn fails because of a cancellation, cleanup runs
+// before Done closes so partial state never outlives the job.
+func StartJob(ctx context.Context, fn func(ctx context.Context) error, cleanup func()) *Job {
+	jobCtx, cancel := context.WithCancel(ctx)
+	job := &Job{
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		progress: JobProgress{Stage: JOB_PENDING},
+	}
+	go func() {
+		defer close(job.done)
+		defer cancel()
+		err := fn(context.WithValue(jobCtx, jobContextKey{}, job))
+		cancelled := err != nil && jobCtx.Err() != nil
+		if cancelled && cleanup != nil {
+			cleanup()
+		}
+
+		job.mu.Lock()
+		defer job.mu.Unlock()
+		job.err = err
+		switch {
+		case err == nil:
+			job.progress.Stage = JOB_COMPLETE
+		case cancelled:
+			job.progress.Stage = JOB_CANCELLED
+			job.progress.Error = err.Error()
+		default:
+			job.progress.Stage = JOB_FAILED
+			job.progress.Error = err.Error()
+		}
+	}()
+	return job
+}
+
+func (j *Job) Progress() JobProgress {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.progress
+}
+
+// Cancel asks the job to stop, Wait or Done tell when it has
+func (j *Job) Cancel() {
+	j.cancel()
+}
+
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Wait blocks until the job has finished, returning its error
+func (j *Job) Wait() error {
+	<-j.done
+	return j.Err()
+}
+
+// Err returns the error of a finished job, nil while it runs
+func (j *Job) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+// ReportJobStage records the stage reached by the job running with ctx, if any
+func ReportJobStage(ctx context.Context, stage JobStage) {
+	if job, ok := ctx.Value(jobContextKey{}).(*Job); ok {
+		job.mu.Lock()
+		job.progress.Stage = stage
+		job.mu.Unlock()
+	}
+}
+
+// ReportJobDownload records download progress of the job running with ctx, if any
+func ReportJobDownload(ctx context.Context, downloaded int64, total int64, bytesPerSecond float64) {
+	if job, ok := ctx.Value(jobContextKey{}).(*Job); ok {
+		job.mu.Lock()
+		job.progress.Downloaded = downloaded
+		job.progress.Total = total
+		job.progress.BytesPerSecond = bytesPerSecond
+		job.mu.Unlock()
+	}
+}
@@ -0,0 +1,51 @@
+package updaterdto
+
+import (
+	"context"
+	"time"
+)
+
+// MigrationContext Versions involved in the update a migration is run for
+type MigrationContext struct {
+	FromVersion string
+	ToVersion   string
+}
+
+type MigrationFuncType func(ctx context.Context, migration MigrationContext) error
+
+// Migration Post-update hook run once on the first launch of a new version
+type Migration struct {
+	// Name Unique identifier recorded in persistent state once the migration completes
+	Name string
+	// From Semantic version constraint the previously run version must satisfy, e.g. "<2.0.0"
+	From string
+	// To Semantic version constraint the new version must satisfy and the
+	// previously run version must not, e.g. ">=2.0.0"
+	To string
+	// Up Performs the migration
+	Up MigrationFuncType
+	// Down Optional, reverts the migration when it or a later migration in the same run fails
+	Down MigrationFuncType
+}
+
+// MigrationResult Outcome of a migration attempted during hydration
+type MigrationResult struct {
+	Name        string     `json:"name"`
+	FromVersion string     `json:"from_version"`
+	ToVersion   string     `json:"to_version"`
+	Error       string     `json:"error,omitempty"`
+	RolledBack  bool       `json:"rolled_back"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" ts_type:"string"`
+}
+
+// PersistentState Updater bookkeeping kept across runs at UpdaterConfig.StatePath
+type PersistentState struct {
+	// LastRunVersion Version that last hydrated the updater
+	LastRunVersion string `json:"last_run_version"`
+	// CompletedMigrations Names of migrations that have run, with completion time
+	CompletedMigrations map[string]time.Time `json:"completed_migrations"`
+	// SkippedVersions Versions the user chose not to install
+	SkippedVersions []string `json:"skipped_versions,omitempty"`
+	// PreviousVersion Version of the copy kept for rolling back, see UpdaterConfig.KeepPreviousVersion
+	PreviousVersion string `json:"previous_version,omitempty"`
+}
@@ -8,22 +8,36 @@ import (
 )
 
 type UpdaterState struct {
-	Architecture    string                    `json:"updater_architecture"`
-	Changelog       string                    `json:"updater_changelog"`
-	CheckInterval   time.Duration             `json:"updater_check_interval"`
-	LastUpdateCheck *time.Time                `json:"updater_last_update_check" ts_type:"string"`
-	Log             string                    `json:"updater_log"`
-	LogPath         string                    `json:"updater_log_path"`
-	Platform        string                    `json:"updater_platform"`
-	PublicKey       string                    `json:"updater_public_key"`
-	PublicKeyPath   string                    `json:"updater_public_key_path"`
-	ReleasedAt      *time.Time                `json:"updater_released_at" ts_type:"string"`
-	Status          UpdateStatus              `json:"updater_status"`
-	TemporaryPath   string                    `json:"updater_temporary_path"`
-	UpdateLink      *releaserdto.ReleaseAsset `json:"updater_update_link"`
-	Updating        bool                      `json:"updater_updating"`
-	Variant         string                    `json:"updater_variant"`
-	Version         string                    `json:"updater_version" yaml:"updater_version"`
+	Architecture      string                    `json:"updater_architecture"`
+	Changelog         string                    `json:"updater_changelog"`
+	CombinedChangelog string                    `json:"updater_combined_changelog"`
+	CheckInterval     time.Duration             `json:"updater_check_interval"`
+	DownloadSource    string                    `json:"updater_download_source"`
+	LastUpdateCheck   *time.Time                `json:"updater_last_update_check" ts_type:"string"`
+	Log               string                    `json:"updater_log"`
+	LogPath           string                    `json:"updater_log_path"`
+	Migrations        []MigrationResult         `json:"updater_migrations"`
+	Platform          string                    `json:"updater_platform"`
+	PublicKey         string                    `json:"updater_public_key"`
+	PublicKeyPath     string                    `json:"updater_public_key_path"`
+	ReleasedAt        *time.Time                `json:"updater_released_at" ts_type:"string"`
+	ReleaseNotes      []ReleaseNote             `json:"updater_release_notes"`
+	ReleaseURL        string                    `json:"updater_release_url"`
+	SkippedVersions   []string                  `json:"updater_skipped_versions"`
+	Status            UpdateStatus              `json:"updater_status"`
+	TemporaryPath     string                    `json:"updater_temporary_path"`
+	UpdateLink        *releaserdto.ReleaseAsset `json:"updater_update_link"`
+	Updating          bool                      `json:"updater_updating"`
+	Variant           string                    `json:"updater_variant"`
+	Version           string                    `json:"updater_version" yaml:"updater_version"`
+}
+
+// ReleaseNote Changelog of one published release
+type ReleaseNote struct {
+	Version     string     `json:"version"`
+	PublishedAt *time.Time `json:"published_at,omitempty" ts_type:"string"`
+	Changelog   string     `json:"changelog"`
+	ReleaseURL  string     `json:"release_url,omitempty"`
 }
 
 type UpdaterAgentCfg struct {
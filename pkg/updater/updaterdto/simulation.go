@@ -0,0 +1,66 @@
+package updaterdto
+
+import (
+	"time"
+
+	"github.com/joy-dx/gophorth/pkg/releaser/releaserdto"
+)
+
+type SimulationStepName string
+
+const (
+	SIMULATION_STEP_CHECK     SimulationStepName = "check"
+	SIMULATION_STEP_DOWNLOAD  SimulationStepName = "download"
+	SIMULATION_STEP_SIGNATURE SimulationStepName = "signature"
+	SIMULATION_STEP_VERIFIERS SimulationStepName = "verifiers"
+	SIMULATION_STEP_PREPARE   SimulationStepName = "prepare"
+	SIMULATION_STEP_HELPER    SimulationStepName = "helper"
+	SIMULATION_STEP_SWAP      SimulationStepName = "swap"
+)
+
+type SimulationStepStatus string
+
+const (
+	SIMULATION_PASSED  SimulationStepStatus = "passed"
+	SIMULATION_SKIPPED SimulationStepStatus = "skipped"
+	SIMULATION_FAILED  SimulationStepStatus = "failed"
+)
+
+// SimulationStep Outcome of a single step of a simulated update
+type SimulationStep struct {
+	Name     SimulationStepName   `json:"name"`
+	Status   SimulationStepStatus `json:"status"`
+	Detail   string               `json:"detail"`
+	Duration time.Duration        `json:"duration"`
+}
+
+// SimulationReport Describes what PerformUpdate would do, produced by SimulateUpdate
+type SimulationReport struct {
+	CurrentVersion   string                    `json:"current_version"`
+	UpdateAvailable  bool                      `json:"update_available"`
+	Asset            *releaserdto.ReleaseAsset `json:"asset,omitempty"`
+	ArtefactPath     string                    `json:"artefact_path,omitempty"`
+	DownloadSource   string                    `json:"download_source,omitempty"`
+	ArtefactChecksum string                    `json:"artefact_checksum,omitempty"`
+	// HelperPath Helper that would be executed, empty when replacing in-process
+	HelperPath string `json:"helper_path,omitempty"`
+	InProcess  bool   `json:"in_process"`
+	// Target File or .app bundle that would be replaced
+	Target      string           `json:"target"`
+	StagedPath  string           `json:"staged_path"`
+	BackupPath  string           `json:"backup_path"`
+	JournalPath string           `json:"journal_path"`
+	Steps       []SimulationStep `json:"steps"`
+	StartedAt   time.Time        `json:"started_at"`
+	FinishedAt  time.Time        `json:"finished_at"`
+}
+
+// Passed reports whether every step that ran succeeded
+func (r *SimulationReport) Passed() bool {
+	for _, step := range r.Steps {
+		if step.Status == SIMULATION_FAILED {
+			return false
+		}
+	}
+	return true
+}
@@ -11,6 +11,7 @@ import (
 
 type UpdateFuncType func(ctx context.Context, cfg *UpdaterAgentCfg) (string, error)
 type PrepareFuncType func(ctx context.Context, cfg *UpdaterAgentCfg) error
+type HelperFuncType func(ctx context.Context, cfg *UpdaterAgentCfg) (string, error)
 
 // UpdaterConfig Service configuration struct
 type UpdaterConfig struct {
@@ -32,6 +33,10 @@ type UpdaterConfig struct {
 	TemporaryPath string `json:"temporary_path" yaml:"temporary_path" mapstructure:"temporary_path"`
 	// Variant Represents a download variant that the current device wants
 	Variant string `json:"variant" yaml:"variant" mapstructure:"variant"`
+	// CacheDir Optional directory of downloaded artefacts keyed by checksum, may be shared by several apps
+	CacheDir string `json:"cache_dir,omitempty" yaml:"cache_dir,omitempty" mapstructure:"cache_dir"`
+	// CacheMaxBytes Size cap of CacheDir, least recently used artefacts are evicted beyond it. 0 for no cap
+	CacheMaxBytes int64 `json:"cache_max_bytes,omitempty" yaml:"cache_max_bytes,omitempty" mapstructure:"cache_max_bytes"`
 	// CheckInterval Adding support for periodic checks
 	CheckInterval time.Duration `json:"check_interval,omitempty" yaml:"check_interval,omitempty" mapstructure:"check_interval"`
 	// Version Semantic version representing current runtime version
@@ -42,10 +47,30 @@ type UpdaterConfig struct {
 	LogPath string `json:"log_path,omitempty" yaml:"log_path,omitempty" mapstructure:"log_path"`
 	// CheckClient Agent for retrieving update information
 	CheckClient CheckClientInterface `json:"-" yaml:"-" mapstructure:"-"`
+	// DownloadEngine Download with the built-in engine, supporting a rate limit, pause and resume of partial files
+	DownloadEngine bool `json:"download_engine,omitempty" yaml:"download_engine,omitempty" mapstructure:"download_engine"`
+	// DownloadRateLimit Bytes per second the download engine may read, 0 for unlimited
+	DownloadRateLimit int64 `json:"download_rate_limit,omitempty" yaml:"download_rate_limit,omitempty" mapstructure:"download_rate_limit"`
+	// DownloadSegments Byte ranges fetched concurrently by the download engine, enabling it when above 1
+	DownloadSegments int `json:"download_segments,omitempty" yaml:"download_segments,omitempty" mapstructure:"download_segments"`
 	// DownloadFunc Optional override for downloading the artefact
 	DownloadFunc UpdateFuncType `json:"-" yaml:"-" mapstructure:"-"`
 	// PrepareFunc Preupdate preparation returning path for update material
 	PrepareFunc PrepareFuncType `json:"-" yaml:"-" mapstructure:"-"`
+	// HelperPath Optional path to an update helper binary to use instead of the embedded one
+	HelperPath string `json:"helper_path,omitempty" yaml:"helper_path,omitempty" mapstructure:"helper_path"`
+	// HelperChecksum Optional SHA-256 an app supplied helper must match before it is executed
+	HelperChecksum string `json:"helper_checksum,omitempty" yaml:"helper_checksum,omitempty" mapstructure:"helper_checksum"`
+	// HelperFunc Optional override returning the path of an update helper binary, e.g. built on demand
+	HelperFunc HelperFuncType `json:"-" yaml:"-" mapstructure:"-"`
+	// InProcessFallback Replace the app from within the running process when no update helper is available
+	InProcessFallback bool `json:"in_process_fallback,omitempty" yaml:"in_process_fallback,omitempty" mapstructure:"in_process_fallback"`
+	// KeepPreviousVersion Keep a copy of the running version before updating so the update can be rolled back
+	KeepPreviousVersion bool `json:"keep_previous_version,omitempty" yaml:"keep_previous_version,omitempty" mapstructure:"keep_previous_version"`
+	// StatePath Optional file the updater persists state to across runs, required for migrations
+	StatePath string `json:"state_path,omitempty" yaml:"state_path,omitempty" mapstructure:"state_path"`
+	// Migrations Post-update hooks run in order on the first launch of a new version
+	Migrations []Migration `json:"-" yaml:"-" mapstructure:"-"`
 	// Verifiers Additional procedures for verifying update integrity
 	Verifiers []VerificationMethodInterface `json:"-" yaml:"-" mapstructure:"-"`
 }
@@ -65,6 +90,56 @@ func (c *UpdaterConfig) WithArch(arch string) *UpdaterConfig {
 	return c
 }
 
+func (c *UpdaterConfig) WithCacheDir(path string) *UpdaterConfig {
+	c.CacheDir = path
+	return c
+}
+
+func (c *UpdaterConfig) WithCacheMaxBytes(bytes int64) *UpdaterConfig {
+	c.CacheMaxBytes = bytes
+	return c
+}
+
+func (c *UpdaterConfig) WithDownloadEngine(truthy bool) *UpdaterConfig {
+	c.DownloadEngine = truthy
+	return c
+}
+
+func (c *UpdaterConfig) WithDownloadRateLimit(bytesPerSecond int64) *UpdaterConfig {
+	c.DownloadRateLimit = bytesPerSecond
+	return c
+}
+
+func (c *UpdaterConfig) WithDownloadSegments(segments int) *UpdaterConfig {
+	c.DownloadSegments = segments
+	return c
+}
+
+func (c *UpdaterConfig) WithHelperFunc(userFunc HelperFuncType) *UpdaterConfig {
+	c.HelperFunc = userFunc
+	return c
+}
+
+func (c *UpdaterConfig) WithHelperPath(path string) *UpdaterConfig {
+	c.HelperPath = path
+	return c
+}
+
+func (c *UpdaterConfig) WithHelperChecksum(checksum string) *UpdaterConfig {
+	c.HelperChecksum = checksum
+	return c
+}
+
+func (c *UpdaterConfig) WithInProcessFallback(truthy bool) *UpdaterConfig {
+	c.InProcessFallback = truthy
+	return c
+}
+
+func (c *UpdaterConfig) WithKeepPreviousVersion(truthy bool) *UpdaterConfig {
+	c.KeepPreviousVersion = truthy
+	return c
+}
+
 func (c *UpdaterConfig) WithLastUpdateCheck(time *time.Time) *UpdaterConfig {
 	c.LastUpdateCheck = time
 	return c
@@ -135,6 +210,16 @@ func (c *UpdaterConfig) WithVerifiers(client []VerificationMethodInterface) *Upd
 	return c
 }
 
+func (c *UpdaterConfig) WithMigration(migration Migration) *UpdaterConfig {
+	c.Migrations = append(c.Migrations, migration)
+	return c
+}
+
+func (c *UpdaterConfig) WithStatePath(path string) *UpdaterConfig {
+	c.StatePath = path
+	return c
+}
+
 func (c *UpdaterConfig) WithNetSvc(svc netDTO.NetInterface) *UpdaterConfig {
 	c.NetSvc = svc
 	return c
@@ -0,0 +1,32 @@
+//go:build linux
+
+package updaterswap
+
+import (
+	"errors"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// exchange atomically swaps two paths with renameat2(RENAME_EXCHANGE). false is
+// returned when the kernel or filesystem lacks support so callers can fall back
+// to plain renames.
+func exchange(a, b string) (bool, error) {
+	err := unix.Renameat2(unix.AT_FDCWD, a, unix.AT_FDCWD, b, unix.RENAME_EXCHANGE)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EINVAL) || errors.Is(err, unix.EOPNOTSUPP) {
+		return false, nil
+	}
+	return false, err
+}
+
+func fileID(path string) (uint64, error) {
+	var stat syscall.Stat_t
+	if err := syscall.Lstat(path, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Ino, nil
+}
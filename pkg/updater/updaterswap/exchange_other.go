@@ -0,0 +1,14 @@
+//go:build !linux
+
+package updaterswap
+
+import "errors"
+
+// exchange is unsupported outside Linux, callers fall back to plain renames
+func exchange(a, b string) (bool, error) {
+	return false, nil
+}
+
+func fileID(path string) (uint64, error) {
+	return 0, errors.New("file identity unsupported on this platform")
+}
@@ -0,0 +1,129 @@
+package updaterswap
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// copyPath copies a file or directory tree (e.g. a .app bundle), syncing every
+// file written so the copy survives a crash once this returns.
+func copyPath(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		return copySymlink(src, dst)
+	case info.IsDir():
+		return copyDir(src, dst, info.Mode())
+	default:
+		return copyFile(src, dst, info.Mode())
+	}
+}
+
+func copyFile(src, dst string, mode fs.FileMode) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return err
+	}
+	defer dstFile.Close()
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		return err
+	}
+	return dstFile.Sync()
+}
+
+func copyDir(src, dst string, mode fs.FileMode) error {
+	if err := os.MkdirAll(dst, mode.Perm()); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := copyPath(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return syncDir(dst)
+}
+
+// copySymlink recreates a link as-is. Bundles rely on relative links such as
+// Versions/Current, which must not be followed.
+func copySymlink(src, dst string) error {
+	linkTarget, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+	return os.Symlink(linkTarget, dst)
+}
+
+// syncTree flushes a file, or every file within a directory, to disk
+func syncTree(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type()&os.ModeSymlink != 0 {
+			return nil
+		}
+		if d.IsDir() {
+			return syncDir(path)
+		}
+		return syncFile(path)
+	})
+}
+
+func syncFile(path string) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	return fh.Sync()
+}
+
+// syncDir persists directory entries (creates, renames) on platforms supporting it
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	fh, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("open dir for sync: %w", err)
+	}
+	defer fh.Close()
+	if err := fh.Sync(); err != nil {
+		return fmt.Errorf("sync dir %s: %w", dir, err)
+	}
+	return nil
+}
+
+func writeFileSync(path string, contents []byte, mode fs.FileMode) error {
+	fh, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	if _, err := fh.Write(contents); err != nil {
+		return err
+	}
+	return fh.Sync()
+}
+
+func parentDir(path string) string {
+	return filepath.Dir(filepath.Clean(path))
+}
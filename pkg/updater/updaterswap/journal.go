@@ -0,0 +1,102 @@
+package updaterswap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type Phase string
+
+const (
+	// PHASE_STAGING Staged copy is being written and may be incomplete
+	PHASE_STAGING Phase = "staging"
+	// PHASE_STAGED Staged copy is complete and synced, target untouched
+	PHASE_STAGED Phase = "staged"
+	// PHASE_SWAPPED Target holds the new version, backup holds the old one
+	PHASE_SWAPPED Phase = "swapped"
+	// PHASE_ROLLING_BACK Backup is being moved back in to place
+	PHASE_ROLLING_BACK Phase = "rolling_back"
+)
+
+// Journal records the progress of a replacement so that an interrupted swap
+// can be finished or reverted by a later run of the helper or the updater.
+type Journal struct {
+	Target string `json:"target"`
+	Staged string `json:"staged"`
+	Backup string `json:"backup"`
+	Phase  Phase  `json:"phase"`
+	// StagedID File identity of the staged path, used to detect a completed exchange
+	StagedID uint64 `json:"staged_id,omitempty"`
+	// BackupID File identity of the backup, used to detect a completed restore
+	BackupID  uint64    `json:"backup_id,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// JournalPath returns the location of the journal kept for a given target
+func JournalPath(target string) string {
+	return target + ".gophorth-journal"
+}
+
+// StagedPath returns the sibling path a replacement is staged to before swapping
+func StagedPath(target string) string {
+	return filepath.Join(filepath.Dir(target), "."+filepath.Base(target)+".gophorth-new")
+}
+
+// BackupPath returns the sibling path the current version is moved to during the swap
+func BackupPath(target string) string {
+	return target + ".bak"
+}
+
+// RejectedPath returns the sibling path a rejected version is moved to while the backup is restored
+func RejectedPath(target string) string {
+	return filepath.Join(filepath.Dir(target), "."+filepath.Base(target)+".gophorth-rejected")
+}
+
+// ReadJournal loads the journal for target. A missing journal returns nil, nil
+func ReadJournal(target string) (*Journal, error) {
+	contents, err := os.ReadFile(JournalPath(target))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read journal: %w", err)
+	}
+	var journal Journal
+	if err := json.Unmarshal(contents, &journal); err != nil {
+		return nil, fmt.Errorf("parse journal: %w", err)
+	}
+	return &journal, nil
+}
+
+func (j *Journal) write() error {
+	j.UpdatedAt = time.Now()
+	contents, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	journalPath := JournalPath(j.Target)
+	tmpPath := journalPath + ".tmp"
+	if err := writeFileSync(tmpPath, contents, 0600); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+	if err := os.Rename(tmpPath, journalPath); err != nil {
+		return fmt.Errorf("commit journal: %w", err)
+	}
+	return syncDir(filepath.Dir(journalPath))
+}
+
+func (j *Journal) setPhase(phase Phase) error {
+	j.Phase = phase
+	return j.write()
+}
+
+func (j *Journal) remove() error {
+	if err := os.Remove(JournalPath(j.Target)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove journal: %w", err)
+	}
+	return syncDir(filepath.Dir(j.Target))
+}
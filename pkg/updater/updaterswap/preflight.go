@@ -0,0 +1,25 @@
+package updaterswap
+
+import (
+	"fmt"
+	"os"
+)
+
+// Preflight checks a swap of target could go ahead without touching anything:
+// the target exists, its directory is writable and no interrupted swap is pending.
+func Preflight(target string) error {
+	if _, err := os.Lstat(target); err != nil {
+		return fmt.Errorf("update target: %w", err)
+	}
+	if err := checkWritable(parentDir(target)); err != nil {
+		return fmt.Errorf("update target directory not writable: %w", err)
+	}
+	journal, err := ReadJournal(target)
+	if err != nil {
+		return err
+	}
+	if journal != nil {
+		return fmt.Errorf("interrupted swap pending recovery in phase %s", journal.Phase)
+	}
+	return nil
+}
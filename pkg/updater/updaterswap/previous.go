@@ -0,0 +1,35 @@
+package updaterswap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// PreviousPath returns the sibling path a copy of the running version is kept
+// at, for rolling back once an update has been committed
+func PreviousPath(target string) string {
+	return filepath.Join(filepath.Dir(target), "."+filepath.Base(target)+".gophorth-previous")
+}
+
+// KeepPrevious copies target to PreviousPath. An older copy is only replaced
+// once the new one is complete, so a crash never leaves a partial version to
+// roll back to.
+func KeepPrevious(target string) (string, error) {
+	previous := PreviousPath(target)
+	partial := previous + ".partial"
+	if err := os.RemoveAll(partial); err != nil {
+		return "", fmt.Errorf("clear %s: %w", partial, err)
+	}
+	if err := copyPath(target, partial); err != nil {
+		_ = os.RemoveAll(partial)
+		return "", fmt.Errorf("copy running version: %w", err)
+	}
+	if err := os.RemoveAll(previous); err != nil {
+		return "", fmt.Errorf("remove older copy: %w", err)
+	}
+	if err := os.Rename(partial, previous); err != nil {
+		return "", fmt.Errorf("keep copy: %w", err)
+	}
+	return previous, syncDir(parentDir(previous))
+}
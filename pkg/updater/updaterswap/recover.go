@@ -0,0 +1,111 @@
+package updaterswap
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+type Outcome string
+
+const (
+	// OUTCOME_NONE No interrupted swap was found
+	OUTCOME_NONE Outcome = "none"
+	// OUTCOME_FINISHED The new version was kept
+	OUTCOME_FINISHED Outcome = "finished"
+	// OUTCOME_REVERTED The previous version was restored
+	OUTCOME_REVERTED Outcome = "reverted"
+)
+
+// Recover inspects the journal for target and brings an interrupted swap to
+// a consistent state. Swaps that never reached PHASE_SWAPPED are reverted to
+// the previous version, swaps past that point are finished.
+func Recover(target string, logf LogFunc) (Outcome, error) {
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+	journal, err := ReadJournal(target)
+	if err != nil || journal == nil {
+		return OUTCOME_NONE, err
+	}
+	logf("Found interrupted swap of %s in phase %s", journal.Target, journal.Phase)
+	defer func() {
+		_ = os.RemoveAll(RejectedPath(journal.Target))
+	}()
+
+	switch journal.Phase {
+	case PHASE_STAGING:
+		if err := os.RemoveAll(journal.Staged); err != nil {
+			return OUTCOME_NONE, fmt.Errorf("remove partial staging: %w", err)
+		}
+		return OUTCOME_REVERTED, journal.remove()
+
+	case PHASE_STAGED:
+		if !pathExists(journal.Target) {
+			switch {
+			case pathExists(journal.Backup):
+				if err := os.Rename(journal.Backup, journal.Target); err != nil {
+					return OUTCOME_NONE, fmt.Errorf("restore backup: %w", err)
+				}
+				_ = os.RemoveAll(journal.Staged)
+				return OUTCOME_REVERTED, journal.remove()
+			case pathExists(journal.Staged):
+				// Only the new version survives, so finishing is the only way to keep an app
+				if err := os.Rename(journal.Staged, journal.Target); err != nil {
+					return OUTCOME_NONE, fmt.Errorf("finish swap: %w", err)
+				}
+				return OUTCOME_FINISHED, journal.remove()
+			default:
+				return OUTCOME_NONE, errors.New("target, backup and staged copy are all missing")
+			}
+		}
+
+		targetID, idErr := fileID(journal.Target)
+		swapped := idErr == nil && journal.StagedID != 0 && targetID == journal.StagedID
+		if swapped && !pathExists(journal.Backup) {
+			// Exchange completed before the journal caught up, the staged path holds the previous version
+			journal.Backup = journal.Staged
+		} else if !pathExists(journal.Backup) {
+			// Target never touched
+			if err := os.RemoveAll(journal.Staged); err != nil {
+				return OUTCOME_NONE, fmt.Errorf("remove staged replacement: %w", err)
+			}
+			return OUTCOME_REVERTED, journal.remove()
+		}
+		if err := journal.setPhase(PHASE_ROLLING_BACK); err != nil {
+			return OUTCOME_NONE, err
+		}
+		if err := restoreBackup(journal); err != nil {
+			return OUTCOME_NONE, err
+		}
+		return OUTCOME_REVERTED, journal.remove()
+
+	case PHASE_SWAPPED:
+		if err := os.RemoveAll(journal.Backup); err != nil {
+			return OUTCOME_NONE, fmt.Errorf("remove backup: %w", err)
+		}
+		return OUTCOME_FINISHED, journal.remove()
+
+	case PHASE_ROLLING_BACK:
+		if targetID, idErr := fileID(journal.Target); idErr == nil && journal.BackupID != 0 && targetID == journal.BackupID {
+			// Exchange completed before the rejected version was removed
+			if err := os.RemoveAll(journal.Backup); err != nil {
+				return OUTCOME_NONE, fmt.Errorf("remove rejected version: %w", err)
+			}
+		} else if pathExists(journal.Backup) {
+			if err := restoreBackup(journal); err != nil {
+				return OUTCOME_NONE, err
+			}
+		} else if !pathExists(journal.Target) {
+			return OUTCOME_NONE, errors.New("target and backup are both missing")
+		}
+		return OUTCOME_REVERTED, journal.remove()
+	}
+
+	return OUTCOME_NONE, fmt.Errorf("unknown journal phase %q", journal.Phase)
+}
+
+func pathExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
@@ -0,0 +1,199 @@
+package updaterswap
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+type LogFunc func(format string, args ...interface{})
+
+// Swapper replaces a target file or directory (e.g. a .app bundle) with a
+// replacement in a crash-safe manner:
+//
+//   - The replacement is staged to a sibling path of the target so the swap
+//     never crosses a filesystem boundary
+//   - Staged content is synced to disk before the target is touched
+//   - The swap is an atomic exchange on Linux, otherwise two atomic renames
+//   - Every step is recorded in a journal for Recover to act on
+type Swapper struct {
+	journal Journal
+	logf    LogFunc
+}
+
+func New(target string, logf LogFunc) *Swapper {
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+	return &Swapper{
+		journal: Journal{
+			Target: target,
+			Staged: StagedPath(target),
+			Backup: BackupPath(target),
+		},
+		logf: logf,
+	}
+}
+
+func (s *Swapper) Journal() Journal {
+	return s.journal
+}
+
+// Stage moves the replacement next to the target. A rename is attempted first,
+// falling back to a synced copy when the replacement lives on another filesystem.
+func (s *Swapper) Stage(replacement string) error {
+	// Leftovers from an earlier run would be mistaken for swap progress by Recover
+	for _, leftover := range []string{s.journal.Staged, s.journal.Backup} {
+		if err := os.RemoveAll(leftover); err != nil {
+			return fmt.Errorf("clear %s: %w", leftover, err)
+		}
+	}
+	if err := s.journal.setPhase(PHASE_STAGING); err != nil {
+		return err
+	}
+
+	if err := os.Rename(replacement, s.journal.Staged); err != nil {
+		s.logf("Rename in to staging failed (%v), copying instead", err)
+		if copyErr := copyPath(replacement, s.journal.Staged); copyErr != nil {
+			_ = os.RemoveAll(s.journal.Staged)
+			_ = s.journal.remove()
+			return fmt.Errorf("stage replacement: %w", copyErr)
+		}
+	} else if err := syncTree(s.journal.Staged); err != nil {
+		return fmt.Errorf("sync staged replacement: %w", err)
+	}
+	if err := syncDir(parentDir(s.journal.Staged)); err != nil {
+		return err
+	}
+
+	if stagedID, err := fileID(s.journal.Staged); err == nil {
+		s.journal.StagedID = stagedID
+	}
+	if err := s.journal.setPhase(PHASE_STAGED); err != nil {
+		return err
+	}
+	s.logf("Staged replacement at %s", s.journal.Staged)
+	return nil
+}
+
+// Swap places the staged replacement at the target path, keeping the previous
+// version at the backup path.
+func (s *Swapper) Swap() error {
+	if s.journal.Phase != PHASE_STAGED {
+		return fmt.Errorf("cannot swap from phase %q", s.journal.Phase)
+	}
+
+	exchanged, err := exchange(s.journal.Staged, s.journal.Target)
+	if err != nil {
+		return fmt.Errorf("exchange: %w", err)
+	}
+	if exchanged {
+		s.logf("Exchanged %s with %s", s.journal.Staged, s.journal.Target)
+		// The staged path now holds the previous version
+		s.journal.Backup = s.journal.Staged
+	} else {
+		if err := os.Rename(s.journal.Target, s.journal.Backup); err != nil {
+			return fmt.Errorf("move previous version to backup: %w", err)
+		}
+		if err := os.Rename(s.journal.Staged, s.journal.Target); err != nil {
+			// Put the previous version straight back so the target is never left missing
+			if restoreErr := os.Rename(s.journal.Backup, s.journal.Target); restoreErr != nil {
+				s.logf("Failed to restore previous version after failed swap: %v", restoreErr)
+			}
+			return fmt.Errorf("move replacement in to place: %w", err)
+		}
+	}
+	if err := syncDir(parentDir(s.journal.Target)); err != nil {
+		return err
+	}
+	return s.journal.setPhase(PHASE_SWAPPED)
+}
+
+// Rollback reverts to the previous version. Before a swap, this discards the
+// staged replacement. After a swap, the backup is moved back in to place.
+func (s *Swapper) Rollback() error {
+	switch s.journal.Phase {
+	case PHASE_STAGING, PHASE_STAGED:
+		if err := os.RemoveAll(s.journal.Staged); err != nil {
+			return fmt.Errorf("remove staged replacement: %w", err)
+		}
+		return s.journal.remove()
+	case PHASE_SWAPPED, PHASE_ROLLING_BACK:
+		if err := s.journal.setPhase(PHASE_ROLLING_BACK); err != nil {
+			return err
+		}
+		if err := restoreBackup(&s.journal); err != nil {
+			return err
+		}
+		return s.journal.remove()
+	}
+	return nil
+}
+
+// Release removes the journal of a completed swap while keeping the backup,
+// so the relaunched app finds nothing for Recover to act on. Follow it with
+// Commit once the app has started, or Rollback, which journals again, when it
+// fails to.
+func (s *Swapper) Release() error {
+	if s.journal.Phase != PHASE_SWAPPED {
+		return fmt.Errorf("cannot release from phase %q", s.journal.Phase)
+	}
+	return s.journal.remove()
+}
+
+// Commit accepts the swap, removing the backup and the journal
+func (s *Swapper) Commit() error {
+	if s.journal.Phase != PHASE_SWAPPED {
+		return fmt.Errorf("cannot commit from phase %q", s.journal.Phase)
+	}
+	if err := os.RemoveAll(s.journal.Backup); err != nil {
+		s.logf("Error cleaning up backup files at %s: %v", s.journal.Backup, err)
+	}
+	return s.journal.remove()
+}
+
+// restoreBackup moves the backup over the target, keeping the target present
+// at every point where the platform allows it.
+func restoreBackup(journal *Journal) error {
+	if _, err := os.Stat(journal.Backup); err != nil {
+		return fmt.Errorf("backup unavailable: %w", err)
+	}
+	if _, err := os.Stat(journal.Target); errors.Is(err, os.ErrNotExist) {
+		if err := os.Rename(journal.Backup, journal.Target); err != nil {
+			return fmt.Errorf("restore backup: %w", err)
+		}
+		return syncDir(parentDir(journal.Target))
+	}
+
+	if backupID, err := fileID(journal.Backup); err == nil && journal.BackupID != backupID {
+		journal.BackupID = backupID
+		if err := journal.write(); err != nil {
+			return err
+		}
+	}
+	exchanged, err := exchange(journal.Backup, journal.Target)
+	if err != nil {
+		return fmt.Errorf("exchange backup: %w", err)
+	}
+	if exchanged {
+		// The backup path now holds the rejected version
+		if err := os.RemoveAll(journal.Backup); err != nil {
+			return fmt.Errorf("remove rejected version: %w", err)
+		}
+	} else {
+		rejectedPath := RejectedPath(journal.Target)
+		if err := os.RemoveAll(rejectedPath); err != nil {
+			return fmt.Errorf("clear %s: %w", rejectedPath, err)
+		}
+		if err := os.Rename(journal.Target, rejectedPath); err != nil {
+			return fmt.Errorf("move rejected version aside: %w", err)
+		}
+		if err := os.Rename(journal.Backup, journal.Target); err != nil {
+			return fmt.Errorf("restore backup: %w", err)
+		}
+		if err := os.RemoveAll(rejectedPath); err != nil {
+			return fmt.Errorf("remove rejected version: %w", err)
+		}
+	}
+	return syncDir(parentDir(journal.Target))
+}
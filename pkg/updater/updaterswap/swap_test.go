@@ -0,0 +1,370 @@
+package updaterswap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(contents)
+}
+
+func assertMissing(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be missing, err=%v", path, err)
+	}
+}
+
+func newSwapFixture(t *testing.T) (target string, replacement string) {
+	t.Helper()
+	dir := t.TempDir()
+	target = filepath.Join(dir, "app", "app-example")
+	replacement = filepath.Join(dir, "tmp", "app-example-new")
+	writeFile(t, target, "v1")
+	writeFile(t, replacement, "v2")
+	return target, replacement
+}
+
+func TestSwapper_SwapAndCommit(t *testing.T) {
+	target, replacement := newSwapFixture(t)
+
+	swapper := New(target, t.Logf)
+	if err := swapper.Stage(replacement); err != nil {
+		t.Fatalf("stage: %v", err)
+	}
+	if err := swapper.Swap(); err != nil {
+		t.Fatalf("swap: %v", err)
+	}
+	if got := readFile(t, target); got != "v2" {
+		t.Fatalf("target=%q want v2", got)
+	}
+	if got := readFile(t, swapper.Journal().Backup); got != "v1" {
+		t.Fatalf("backup=%q want v1", got)
+	}
+	if err := swapper.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	assertMissing(t, swapper.Journal().Backup)
+	assertMissing(t, JournalPath(target))
+
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0100 == 0 {
+		t.Fatalf("executable bit lost: %v", info.Mode())
+	}
+}
+
+func TestSwapper_RollbackAfterSwap(t *testing.T) {
+	target, replacement := newSwapFixture(t)
+
+	swapper := New(target, t.Logf)
+	if err := swapper.Stage(replacement); err != nil {
+		t.Fatalf("stage: %v", err)
+	}
+	if err := swapper.Swap(); err != nil {
+		t.Fatalf("swap: %v", err)
+	}
+	if err := swapper.Rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if got := readFile(t, target); got != "v1" {
+		t.Fatalf("target=%q want v1", got)
+	}
+	assertMissing(t, JournalPath(target))
+	assertMissing(t, StagedPath(target))
+	assertMissing(t, BackupPath(target))
+}
+
+func TestSwapper_Release(t *testing.T) {
+	tests := []struct {
+		name     string
+		launched bool
+		want     string
+	}{
+		{name: "launched", launched: true, want: "v2"},
+		{name: "launch failed", want: "v1"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			target, replacement := newSwapFixture(t)
+
+			swapper := New(target, t.Logf)
+			if err := swapper.Stage(replacement); err != nil {
+				t.Fatalf("stage: %v", err)
+			}
+			if err := swapper.Swap(); err != nil {
+				t.Fatalf("swap: %v", err)
+			}
+			if err := swapper.Release(); err != nil {
+				t.Fatalf("release: %v", err)
+			}
+			// The relaunched app recovers while the helper still holds the backup
+			outcome, err := Recover(target, t.Logf)
+			if err != nil || outcome != OUTCOME_NONE {
+				t.Fatalf("recover outcome=%s err=%v want none", outcome, err)
+			}
+			if got := readFile(t, swapper.Journal().Backup); got != "v1" {
+				t.Fatalf("backup=%q want v1", got)
+			}
+
+			if tc.launched {
+				if err := swapper.Commit(); err != nil {
+					t.Fatalf("commit: %v", err)
+				}
+			} else if err := swapper.Rollback(); err != nil {
+				t.Fatalf("rollback: %v", err)
+			}
+			if got := readFile(t, target); got != tc.want {
+				t.Fatalf("target=%q want %s", got, tc.want)
+			}
+			assertMissing(t, JournalPath(target))
+			assertMissing(t, swapper.Journal().Backup)
+		})
+	}
+}
+
+func TestSwapper_Directory(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "Example.app")
+	replacement := filepath.Join(dir, "download", "Example.app")
+	writeFile(t, filepath.Join(target, "Contents", "MacOS", "example"), "v1")
+	writeFile(t, filepath.Join(replacement, "Contents", "MacOS", "example"), "v2")
+	if err := os.Symlink("MacOS", filepath.Join(replacement, "Contents", "Current")); err != nil {
+		t.Fatal(err)
+	}
+
+	swapper := New(target, t.Logf)
+	if err := swapper.Stage(replacement); err != nil {
+		t.Fatalf("stage: %v", err)
+	}
+	if err := swapper.Swap(); err != nil {
+		t.Fatalf("swap: %v", err)
+	}
+	if err := swapper.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if got := readFile(t, filepath.Join(target, "Contents", "Current", "example")); got != "v2" {
+		t.Fatalf("bundle binary=%q want v2", got)
+	}
+}
+
+func TestCopyPath_PreservesSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	writeFile(t, filepath.Join(src, "Versions", "A", "lib"), "lib")
+	if err := os.Symlink("A", filepath.Join(src, "Versions", "Current")); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "dst")
+	if err := copyPath(src, dst); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	link, err := os.Readlink(filepath.Join(dst, "Versions", "Current"))
+	if err != nil {
+		t.Fatalf("expected symlink: %v", err)
+	}
+	if link != "A" {
+		t.Fatalf("link=%q want A", link)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	tests := []struct {
+		name string
+		// interrupt leaves the filesystem as if the helper was killed at a given point
+		interrupt   func(t *testing.T, target, replacement string)
+		wantOutcome Outcome
+		wantTarget  string
+	}{
+		{
+			name:        "no journal",
+			interrupt:   func(t *testing.T, target, replacement string) {},
+			wantOutcome: OUTCOME_NONE,
+			wantTarget:  "v1",
+		},
+		{
+			name: "killed while staging",
+			interrupt: func(t *testing.T, target, replacement string) {
+				journal := Journal{Target: target, Staged: StagedPath(target), Backup: BackupPath(target)}
+				if err := journal.setPhase(PHASE_STAGING); err != nil {
+					t.Fatal(err)
+				}
+				writeFile(t, StagedPath(target), "v")
+			},
+			wantOutcome: OUTCOME_REVERTED,
+			wantTarget:  "v1",
+		},
+		{
+			name: "killed after staging",
+			interrupt: func(t *testing.T, target, replacement string) {
+				if err := New(target, t.Logf).Stage(replacement); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantOutcome: OUTCOME_REVERTED,
+			wantTarget:  "v1",
+		},
+		{
+			name: "killed between renames",
+			interrupt: func(t *testing.T, target, replacement string) {
+				if err := New(target, t.Logf).Stage(replacement); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Rename(target, BackupPath(target)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantOutcome: OUTCOME_REVERTED,
+			wantTarget:  "v1",
+		},
+		{
+			name: "killed after renames before journal update",
+			interrupt: func(t *testing.T, target, replacement string) {
+				if err := New(target, t.Logf).Stage(replacement); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Rename(target, BackupPath(target)); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Rename(StagedPath(target), target); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantOutcome: OUTCOME_REVERTED,
+			wantTarget:  "v1",
+		},
+		{
+			name: "killed after exchange before journal update",
+			interrupt: func(t *testing.T, target, replacement string) {
+				if err := New(target, t.Logf).Stage(replacement); err != nil {
+					t.Fatal(err)
+				}
+				exchanged, err := exchange(StagedPath(target), target)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !exchanged {
+					t.Skip("exchange unsupported")
+				}
+			},
+			wantOutcome: OUTCOME_REVERTED,
+			wantTarget:  "v1",
+		},
+		{
+			name: "killed before launch",
+			interrupt: func(t *testing.T, target, replacement string) {
+				swapper := New(target, t.Logf)
+				if err := swapper.Stage(replacement); err != nil {
+					t.Fatal(err)
+				}
+				if err := swapper.Swap(); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantOutcome: OUTCOME_FINISHED,
+			wantTarget:  "v2",
+		},
+		{
+			name: "killed while rolling back",
+			interrupt: func(t *testing.T, target, replacement string) {
+				swapper := New(target, t.Logf)
+				if err := swapper.Stage(replacement); err != nil {
+					t.Fatal(err)
+				}
+				if err := swapper.Swap(); err != nil {
+					t.Fatal(err)
+				}
+				if err := swapper.journal.setPhase(PHASE_ROLLING_BACK); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Remove(target); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantOutcome: OUTCOME_REVERTED,
+			wantTarget:  "v1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			target, replacement := newSwapFixture(t)
+			tc.interrupt(t, target, replacement)
+
+			outcome, err := Recover(target, t.Logf)
+			if err != nil {
+				t.Fatalf("recover: %v", err)
+			}
+			if outcome != tc.wantOutcome {
+				t.Fatalf("outcome=%s want %s", outcome, tc.wantOutcome)
+			}
+			if got := readFile(t, target); got != tc.wantTarget {
+				t.Fatalf("target=%q want %q", got, tc.wantTarget)
+			}
+			assertMissing(t, JournalPath(target))
+			assertMissing(t, StagedPath(target))
+			assertMissing(t, BackupPath(target))
+		})
+	}
+}
+
+func TestKeepPrevious_RollsBackThroughSwap(t *testing.T) {
+	target, replacement := newSwapFixture(t)
+
+	previous, err := KeepPrevious(target)
+	if err != nil {
+		t.Fatalf("keep previous: %v", err)
+	}
+	writeFile(t, previous+".partial", "stale")
+	if _, err := KeepPrevious(target); err != nil {
+		t.Fatalf("keep previous again: %v", err)
+	}
+	assertMissing(t, previous+".partial")
+
+	update := New(target, t.Logf)
+	if err := update.Stage(replacement); err != nil {
+		t.Fatalf("stage: %v", err)
+	}
+	if err := update.Swap(); err != nil {
+		t.Fatalf("swap: %v", err)
+	}
+	if err := update.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	// Rolling back is an update whose replacement is the kept copy
+	rollback := New(target, t.Logf)
+	if err := rollback.Stage(previous); err != nil {
+		t.Fatalf("stage previous: %v", err)
+	}
+	if err := rollback.Swap(); err != nil {
+		t.Fatalf("swap previous: %v", err)
+	}
+	if err := rollback.Commit(); err != nil {
+		t.Fatalf("commit previous: %v", err)
+	}
+	if got := readFile(t, target); got != "v1" {
+		t.Fatalf("target=%q want v1", got)
+	}
+	assertMissing(t, previous)
+}
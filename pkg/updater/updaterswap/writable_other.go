@@ -0,0 +1,20 @@
+//go:build !unix
+
+package updaterswap
+
+import (
+	"errors"
+	"os"
+)
+
+// checkWritable only confirms dir exists, permissions surface when the swap runs
+func checkWritable(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return errors.New("not a directory")
+	}
+	return nil
+}
@@ -1,14 +1,24 @@
 package selfupdateverification
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/joy-dx/gophorth/pkg/cryptography"
+)
+
 const VerificationChecksumRef = "checksum"
 
 type VerificationChecksum struct {
 	Ref string
+	cfg ChecksumConfig
 }
 
-func NewVerificationChecksum() *VerificationChecksum {
+func NewVerificationChecksum(cfg ChecksumConfig) *VerificationChecksum {
 	return &VerificationChecksum{
 		Ref: VerificationChecksumRef,
+		cfg: cfg,
 	}
 }
 
@@ -16,8 +26,21 @@ func (v *VerificationChecksum) GetRef() string {
 	return v.Ref
 }
 
-func (v *VerificationChecksum) SetConfig() string {}
+func (v *VerificationChecksum) SetConfig(cfg ChecksumConfig) {
+	v.cfg = cfg
+}
 
+// Verify compares the SHA-256 of the artefact with the configured checksum
 func (v *VerificationChecksum) Verify(artefactPath string) error {
+	if v.cfg.Checksum == "" {
+		return errors.New("no checksum configured")
+	}
+	actualChecksum, err := cryptography.Sha256SumFile(artefactPath)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(actualChecksum, strings.TrimSpace(v.cfg.Checksum)) {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", v.cfg.Checksum, actualChecksum)
+	}
 	return nil
 }
@@ -4,6 +4,8 @@ package selfupdateverification
 type ChecksumConfig struct {
 	Ref string
 	URL string
+	// Checksum Expected SHA-256 of the artefact
+	Checksum string
 }
 
 func DefaultChecksumConfig() ChecksumConfig {
@@ -16,6 +18,11 @@ func (c ChecksumConfig) GetRef() string {
 	return c.Ref
 }
 
+func (c *ChecksumConfig) WithChecksum(checksum string) *ChecksumConfig {
+	c.Checksum = checksum
+	return c
+}
+
 func (c *ChecksumConfig) WithURL(url string) *ChecksumConfig {
 	c.URL = url
 	return c